@@ -0,0 +1,151 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithAMPCheck verifies the relationships between pages and their AMP variants:
+// a page's rel="amphtml" link must lead to an AMP page that links back with rel="canonical",
+// and AMP pages must link to their canonical page and only load resources AMP allows.
+// AMP is not checked by default.
+func WithAMPCheck(enabled bool) Option {
+	return func(w *Website) {
+		w.ampCheck = enabled
+	}
+}
+
+// ampReplacements are the elements AMP pages must not use, mapped to the AMP components replacing them, if any.
+var ampReplacements = map[string]string{
+	"img": "amp-img", "video": "amp-video", "audio": "amp-audio", "iframe": "amp-iframe",
+	"embed": "", "object": "", "frame": "", "frameset": "", "applet": "",
+}
+
+// ampRuntime is the origin AMP pages must load their scripts from.
+const ampRuntime = "https://cdn.ampproject.org/"
+
+// ampFontProviders are the origins AMP pages may load stylesheets from.
+var ampFontProviders = []string{
+	"https://fonts.googleapis.com/", "https://fonts.bunny.net/", "https://use.typekit.net/", "https://fast.fonts.net/",
+	"https://maxcdn.bootstrapcdn.com/", "https://use.fontawesome.com/", "https://cloud.typography.com/",
+}
+
+// ampResource is a resource an AMP page must not load.
+type ampResource struct {
+	href    string
+	element string
+}
+
+// recordAMP remembers the AMP relationships of the document and, for AMP pages, the resources AMP forbids.
+// The <html> element is visited first, so AMP pages are recognized before their resources.
+func recordAMP(entity *fsEntity, element string, s *goquery.Selection) {
+	switch element {
+	case "html":
+		_, amp := s.Attr("amp")
+		_, bolt := s.Attr("⚡")
+		entity.amp = amp || bolt
+	case "link":
+		href := s.AttrOr("href", "")
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch {
+			case rel == "amphtml" && len(entity.ampHTML) == 0:
+				entity.ampHTML = href
+			case rel == "canonical" && len(entity.canonical) == 0:
+				entity.canonical = href
+			case rel == "stylesheet" && entity.amp && !ampFontProvider(href):
+				entity.ampResources = append(entity.ampResources, ampResource{href, "stylesheet"})
+			}
+		}
+	case "script":
+		if src, exists := s.Attr("src"); exists && entity.amp && !strings.HasPrefix(src, ampRuntime) {
+			entity.ampResources = append(entity.ampResources, ampResource{src, "script"})
+		}
+	default:
+		// AMP allows fallbacks for browsers without JavaScript.
+		if _, forbidden := ampReplacements[element]; forbidden && entity.amp && s.ParentsFiltered("noscript").Length() == 0 {
+			entity.ampResources = append(entity.ampResources, ampResource{s.AttrOr("src", s.AttrOr("data", "")), element})
+		}
+	}
+}
+
+// ampFontProvider reports whether AMP pages may load the stylesheet.
+func ampFontProvider(href string) bool {
+	for _, provider := range ampFontProviders {
+		if strings.HasPrefix(href, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAMP verifies the AMP relationships of the document and, for AMP pages, the resources they load.
+func validateAMP(website *Website, entity *fsEntity) []error {
+	if !website.ampCheck {
+		return nil
+	}
+
+	var errors []error
+	if !entity.amp && len(entity.ampHTML) > 0 {
+		// Links to missing AMP pages are reported as broken links.
+		if variant := website.linkedPage(entity, entity.ampHTML); variant != nil {
+			if !variant.amp {
+				errors = append(errors, website.newFinding(entity, entity.ampHTML, SeverityWarning, KindPolicy, "rel=amphtml target '%s' is not an AMP page", entity.ampHTML))
+			} else if website.linkedPage(variant, variant.canonical) != entity {
+				errors = append(errors, website.newFinding(entity, entity.ampHTML, SeverityWarning, KindPolicy, "rel=amphtml target '%s' does not link back with rel=canonical", entity.ampHTML))
+			}
+		}
+	}
+	if !entity.amp {
+		return errors
+	}
+
+	if len(entity.canonical) == 0 {
+		errors = append(errors, website.newLinkError(entity, "", KindDocument, "AMP page has no rel=canonical link"))
+	} else if canonical := website.linkedPage(entity, entity.canonical); canonical != nil && canonical != entity {
+		if website.linkedPage(canonical, canonical.ampHTML) != entity {
+			errors = append(errors, website.newFinding(entity, entity.canonical, SeverityWarning, KindPolicy, "rel=canonical target '%s' does not link back with rel=amphtml", entity.canonical))
+		}
+	}
+	for _, resource := range entity.ampResources {
+		switch replacement := ampReplacements[resource.element]; {
+		case resource.element == "script":
+			errors = append(errors, website.newLinkError(entity, resource.href, KindPolicy, "AMP page loads the script '%s' (only scripts from %s are allowed)", resource.href, ampRuntime))
+		case resource.element == "stylesheet":
+			errors = append(errors, website.newLinkError(entity, resource.href, KindPolicy, "AMP page loads the stylesheet '%s' (only font providers are allowed)", resource.href))
+		case len(replacement) > 0:
+			errors = append(errors, website.newLinkError(entity, resource.href, KindPolicy, "AMP page uses <%s> (use <%s> instead)", resource.element, replacement))
+		default:
+			errors = append(errors, website.newLinkError(entity, resource.href, KindPolicy, "AMP page uses <%s>, which AMP does not allow", resource.element))
+		}
+	}
+	return errors
+}
+
+// linkedPage returns the document an internal link, or an absolute link to the website, refers to.
+func (w *Website) linkedPage(entity *fsEntity, href string) *fsEntity {
+	if u, err := url.Parse(sanitizeHref(href)); err == nil && u.IsAbs() {
+		if !w.isSiteLink(u.String()) {
+			return nil
+		}
+		return w.pageEntity(u)
+	}
+	return w.resolveInternal(entity, href)
+}
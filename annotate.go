@@ -0,0 +1,86 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// annotationStyle highlights the annotated links. It is inserted once per annotated document.
+const annotationStyle = `<style>` +
+	`.linkup-finding{outline:2px solid #d93025;background:#fce8e6;cursor:help}` +
+	`.linkup-finding.linkup-warning{outline-color:#f9ab00;background:#fef7e0}` +
+	`.linkup-finding.linkup-info{outline-color:#1a73e8;background:#e8f0fe}` +
+	`</style>`
+
+// Annotate wraps the links and images of an HTML document that have findings in a highlight span
+// whose tooltip lists the findings, so a copy of the website can be reviewed in a browser with its failures in context.
+// Findings are matched to the href and src attributes by their link, so findings of other documents
+// and findings without a link are ignored, as are links that do not appear as a quoted attribute value.
+func Annotate(source []byte, findings []*LinkError) []byte {
+	messages := make(map[string][]string)
+	severities := make(map[string]Severity)
+	var links []string
+	for _, finding := range findings {
+		if finding.Href == "" {
+			continue
+		}
+		if _, exists := messages[finding.Href]; !exists {
+			links = append(links, finding.Href)
+			severities[finding.Href] = finding.Severity
+		}
+		messages[finding.Href] = append(messages[finding.Href], finding.Message)
+		if finding.Severity < severities[finding.Href] {
+			severities[finding.Href] = finding.Severity
+		}
+	}
+	// Annotate longer links first so a link that is a prefix of another is not matched inside it.
+	sort.SliceStable(links, func(i, j int) bool {
+		return len(links[i]) > len(links[j])
+	})
+
+	annotated := false
+	for _, link := range links {
+		open := `<span class="linkup-finding linkup-` + severities[link].String() + `" title="` +
+			html.EscapeString(strings.Join(messages[link], "\n")) + `">`
+		replacement := []byte(strings.Replace(open, "$", "$$", -1) + "${0}</span>")
+		value := regexp.QuoteMeta(link)
+		for _, quote := range []string{`"`, `'`} {
+			attribute := `\s(?:href|src)\s*=\s*` + quote + value + quote
+			for _, pattern := range []*regexp.Regexp{
+				regexp.MustCompile(`(?is)<a\b[^>]*?` + attribute + `[^>]*>.*?</a\s*>`),
+				regexp.MustCompile(`(?i)<(?:img|iframe|video|audio|embed)\b[^>]*?` + attribute + `[^>]*>`),
+			} {
+				if pattern.Match(source) {
+					source = pattern.ReplaceAll(source, replacement)
+					annotated = true
+				}
+			}
+		}
+	}
+	if !annotated {
+		return source
+	}
+
+	if head := regexp.MustCompile(`(?i)</head\s*>`).FindIndex(source); head != nil {
+		return append(source[:head[0]:head[0]], append([]byte(annotationStyle), source[head[0]:]...)...)
+	}
+	return append([]byte(annotationStyle), source...)
+}
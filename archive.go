@@ -0,0 +1,133 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// AddArchive registers every file in a zip, tar, or gzip-compressed tar archive of a built website without extracting it to disk.
+// The archive format is chosen from the file extension: .zip, .tar, .tar.gz, or .tgz.
+// EPUB publications, with the .epub extension, are registered with AddEPUB.
+// The root of the archive is treated as the root of the domain and files are registered like AddDirectory registers them.
+func (w *Website) AddArchive(path string) error {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".epub"):
+		return w.AddEPUB(path)
+	case strings.HasSuffix(lower, ".zip"):
+		return w.addZip(path)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		return w.addTar(reader)
+	case strings.HasSuffix(lower, ".tar"):
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return w.addTar(file)
+	}
+	return fmt.Errorf("unsupported archive format '%s'", path)
+}
+
+// IsArchive reports whether the path names an archive AddArchive can read.
+func IsArchive(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz", ".epub"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Website) addZip(path string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := archiveName(entry.Name)
+		if !isDocumentName(name) {
+			if err := w.AddFile(name); err != nil {
+				return err
+			}
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		err = w.AddDocumentFromReader(name, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Website) addTar(reader io.Reader) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := archiveName(header.Name)
+		if isDocumentName(name) {
+			err = w.AddDocumentFromReader(name, archive)
+		} else {
+			err = w.AddFile(name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// archiveName converts the name of an archive entry into a name relative to the root of the domain.
+func archiveName(name string) string {
+	return strings.TrimPrefix(strings.Replace(name, "\\", "/", -1), "./")
+}
@@ -0,0 +1,100 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Credentials are added to every request sent to a host after it was authenticated.
+type Credentials struct {
+	// Header holds headers to set, such as Authorization.
+	Header http.Header
+
+	// Cookies are sent in addition to any cookies from the cookie jar.
+	Cookies []*http.Cookie
+}
+
+// PreAuthHook authenticates with a protected host before its links are checked.
+type PreAuthHook interface {
+	// Authenticate runs once, before the first link to the host is checked.
+	// It can use the client, which shares the checker's transport and cookie jar, to perform a login POST or obtain a token.
+	// If it fails, every link to the host is reported with the error.
+	Authenticate(ctx context.Context, client *http.Client) (Credentials, error)
+}
+
+// PreAuthFunc adapts a function to the PreAuthHook interface.
+type PreAuthFunc func(ctx context.Context, client *http.Client) (Credentials, error)
+
+// Authenticate calls f(ctx, client).
+func (f PreAuthFunc) Authenticate(ctx context.Context, client *http.Client) (Credentials, error) {
+	return f(ctx, client)
+}
+
+// preAuth caches the outcome of authenticating with each host.
+type preAuth struct {
+	mu      sync.Mutex
+	outcome map[string]*authOutcome
+}
+
+type authOutcome struct {
+	once        sync.Once
+	credentials Credentials
+	err         error
+}
+
+// authenticate applies the credentials for the host of the request, running its hook the first time.
+func (c *HTTPChecker) authenticate(req *http.Request) error {
+	host := strings.ToLower(req.URL.Host)
+	hook, exists := c.PreAuth[host]
+	if !exists {
+		return nil
+	}
+
+	c.auth.mu.Lock()
+	if c.auth.outcome == nil {
+		c.auth.outcome = make(map[string]*authOutcome)
+	}
+	outcome, exists := c.auth.outcome[host]
+	if !exists {
+		outcome = &authOutcome{}
+		c.auth.outcome[host] = outcome
+	}
+	c.auth.mu.Unlock()
+
+	outcome.once.Do(func() {
+		outcome.credentials, outcome.err = hook.Authenticate(req.Context(), c.httpClient())
+	})
+	if outcome.err != nil {
+		return fmt.Errorf("authentication with %s failed: %v", host, outcome.err)
+	}
+
+	for name, values := range outcome.credentials.Header {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	for _, cookie := range outcome.credentials.Cookies {
+		req.AddCookie(cookie)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// BreadcrumbPolicy selects how strictly breadcrumb trails are checked.
+type BreadcrumbPolicy int
+
+const (
+	// BreadcrumbsOff does not check breadcrumb trails. It is the default.
+	BreadcrumbsOff BreadcrumbPolicy = iota
+
+	// BreadcrumbsResolve verifies every breadcrumb links to an existing page.
+	BreadcrumbsResolve
+
+	// BreadcrumbsAncestors additionally verifies every breadcrumb links to an ancestor directory of the page,
+	// or the page itself, in order from the root.
+	BreadcrumbsAncestors
+
+	// BreadcrumbsExact additionally verifies the trail lists every ancestor directory that has an index page.
+	BreadcrumbsExact
+)
+
+// breadcrumb is a link of a breadcrumb trail.
+type breadcrumb struct {
+	href string
+
+	// structured is true if the breadcrumb comes from JSON-LD rather than a link of the document,
+	// in which case it is not validated as an ordinary link.
+	structured bool
+}
+
+// WithBreadcrumbs checks breadcrumb trails marked up with schema.org BreadcrumbList microdata or JSON-LD,
+// or as links within nav[aria-label=breadcrumb], according to the policy.
+// Absolute URLs in breadcrumbs are assumed to refer to this website.
+func WithBreadcrumbs(policy BreadcrumbPolicy) Option {
+	return func(w *Website) {
+		w.breadcrumbs = policy
+	}
+}
+
+// findBreadcrumbs returns the first breadcrumb trail of the document.
+func findBreadcrumbs(doc *goquery.Document) []breadcrumb {
+	var trail []breadcrumb
+
+	doc.Find(`[itemtype$="schema.org/BreadcrumbList"]`).First().Find(`[itemprop~="item"]`).Each(func(i int, s *goquery.Selection) {
+		if href, exists := s.Attr("href"); exists {
+			trail = append(trail, breadcrumb{href: href})
+		} else if id, exists := s.Attr("itemid"); exists {
+			trail = append(trail, breadcrumb{href: id, structured: true})
+		}
+	})
+	if len(trail) > 0 {
+		return trail
+	}
+
+	doc.Find("nav[aria-label]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if !strings.EqualFold(s.AttrOr("aria-label", ""), "breadcrumb") {
+			return true
+		}
+		s.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+			trail = append(trail, breadcrumb{href: s.AttrOr("href", "")})
+		})
+		return false
+	})
+	if len(trail) > 0 {
+		return trail
+	}
+
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		trail = jsonLDBreadcrumbs([]byte(s.Text()))
+		return len(trail) == 0
+	})
+	return trail
+}
+
+// jsonLDBreadcrumbs returns the items of a BreadcrumbList described by JSON-LD.
+func jsonLDBreadcrumbs(data []byte) []breadcrumb {
+	var list struct {
+		Type  string `json:"@type"`
+		Items []struct {
+			Position int             `json:"position"`
+			Item     json.RawMessage `json:"item"`
+		} `json:"itemListElement"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil || list.Type != "BreadcrumbList" {
+		return nil
+	}
+
+	var trail []breadcrumb
+	for _, element := range list.Items {
+		// The item is either a URL or a Thing identified by @id.
+		var href string
+		if err := json.Unmarshal(element.Item, &href); err != nil {
+			var thing struct {
+				ID string `json:"@id"`
+			}
+			json.Unmarshal(element.Item, &thing)
+			href = thing.ID
+		}
+		if len(href) > 0 {
+			trail = append(trail, breadcrumb{href: href, structured: true})
+		}
+	}
+	return trail
+}
+
+// validateBreadcrumbs checks the breadcrumb trail of the document according to the policy.
+func validateBreadcrumbs(website *Website, entity *fsEntity) []error {
+	if website.breadcrumbs == BreadcrumbsOff || len(entity.breadcrumbs) == 0 {
+		return nil
+	}
+
+	// List the directories from the root down to the directory holding the page.
+	var ancestors []*fsEntity
+	for dir := entity.parent; dir != nil; dir = dir.parent {
+		ancestors = append([]*fsEntity{dir}, ancestors...)
+	}
+
+	var errors []error
+	depth := -1
+	listed := make(map[*fsEntity]bool)
+	for _, crumb := range entity.breadcrumbs {
+		href := crumb.href
+		if u, err := url.Parse(href); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			href = u.EscapedPath()
+			if len(href) == 0 {
+				href = "/"
+			}
+		}
+
+		target := website.resolveInternal(entity, href)
+		if target == nil {
+			if crumb.structured {
+				errors = append(errors, website.newLinkError(entity, crumb.href, KindBroken, "broken breadcrumb link '%s'", crumb.href))
+			}
+			continue
+		}
+		if website.breadcrumbs < BreadcrumbsAncestors {
+			continue
+		}
+
+		level := -1
+		if target == entity {
+			level = len(ancestors)
+		} else if isIndexFile(target) {
+			for i, dir := range ancestors {
+				if dir == target.parent {
+					level = i
+					listed[dir] = true
+				}
+			}
+		}
+		switch {
+		case level < 0:
+			errors = append(errors, website.newFinding(entity, crumb.href, SeverityWarning, KindPolicy, "breadcrumb '%s' is not an ancestor of this page", crumb.href))
+		case level <= depth:
+			errors = append(errors, website.newFinding(entity, crumb.href, SeverityWarning, KindPolicy, "breadcrumb '%s' is out of order", crumb.href))
+		default:
+			depth = level
+		}
+	}
+
+	if website.breadcrumbs == BreadcrumbsExact {
+		for _, dir := range ancestors {
+			if index := isPathValid(dir, nil); !listed[dir] && index != nil && index != entity {
+				errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "breadcrumb trail is missing '/%s'", directoryPath(dir)))
+			}
+		}
+	}
+	return errors
+}
+
+// isIndexFile reports whether the file is served when its directory is requested.
+func isIndexFile(entity *fsEntity) bool {
+	return entity.parent != nil && isPathValid(entity.parent, nil) == entity
+}
+
+// directoryPath returns the path of the directory relative to the root, with a trailing slash unless it is the root.
+func directoryPath(dir *fsEntity) string {
+	name := calcFullName(dir)
+	if len(name) > 0 {
+		name += "/"
+	}
+	return name
+}
@@ -0,0 +1,256 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Bucket is a cloud storage bucket holding a website, such as a site served directly from S3 or Google Cloud Storage.
+type Bucket interface {
+	// List returns the names of every object in the website, relative to the root of the domain.
+	List(ctx context.Context) ([]string, error)
+
+	// Open streams the contents of the named object.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// AddBucket registers every object in the bucket without copying the website to disk.
+// Objects are registered like AddDirectory registers files: HTML objects are streamed for parsing and all other objects are registered with AddFile.
+func (w *Website) AddBucket(ctx context.Context, bucket Bucket) error {
+	names, err := bucket.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if len(name) == 0 || strings.HasSuffix(name, "/") {
+			continue // Folder placeholder objects.
+		}
+		if !isDocumentName(name) {
+			if err := w.AddFile(name); err != nil {
+				return err
+			}
+			continue
+		}
+		reader, err := bucket.Open(ctx, name)
+		if err != nil {
+			return err
+		}
+		err = w.AddDocumentFromReader(name, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// S3Bucket lists and reads objects through the Amazon S3 REST API or an S3 compatible service, such as MinIO or Cloudflare R2.
+// Requests are unsigned, so the bucket must be publicly readable unless Client signs requests in its transport.
+type S3Bucket struct {
+	// Endpoint is the URL of the service.
+	// If empty, "https://s3.amazonaws.com" is used.
+	Endpoint string
+
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// Prefix is the key prefix the website is stored under, such as "site/".
+	// It is removed from object names.
+	Prefix string
+
+	// Client sends the requests.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// List pages through the ListObjectsV2 API.
+func (b *S3Bucket) List(ctx context.Context) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {b.Prefix}}
+		if len(token) > 0 {
+			query.Set("continuation-token", token)
+		}
+		var page struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := getBucket(ctx, b.Client, b.base()+"?"+query.Encode(), func(body io.Reader) error {
+			return xml.NewDecoder(body).Decode(&page)
+		}); err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			names = append(names, strings.TrimPrefix(object.Key, b.Prefix))
+		}
+		if !page.IsTruncated || len(page.NextContinuationToken) == 0 {
+			return names, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Open downloads the object.
+func (b *S3Bucket) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return openBucket(ctx, b.Client, b.base()+"/"+escapeKey(b.Prefix+name))
+}
+
+// base returns the path-style URL of the bucket.
+func (b *S3Bucket) base() string {
+	endpoint := b.Endpoint
+	if len(endpoint) == 0 {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(b.Bucket)
+}
+
+// GCSBucket lists and reads objects through the Google Cloud Storage JSON API.
+// Requests are unauthenticated, so the bucket must be publicly readable unless Client authorizes requests in its transport.
+type GCSBucket struct {
+	// Endpoint is the URL of the service.
+	// If empty, "https://storage.googleapis.com" is used.
+	Endpoint string
+
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// Prefix is the object name prefix the website is stored under, such as "site/".
+	// It is removed from object names.
+	Prefix string
+
+	// Client sends the requests.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// List pages through the objects.list API.
+func (b *GCSBucket) List(ctx context.Context) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"prefix": {b.Prefix}, "fields": {"items(name),nextPageToken"}}
+		if len(token) > 0 {
+			query.Set("pageToken", token)
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := getBucket(ctx, b.Client, b.base()+"/o?"+query.Encode(), func(body io.Reader) error {
+			return json.NewDecoder(body).Decode(&page)
+		}); err != nil {
+			return nil, err
+		}
+		for _, object := range page.Items {
+			names = append(names, strings.TrimPrefix(object.Name, b.Prefix))
+		}
+		if len(page.NextPageToken) == 0 {
+			return names, nil
+		}
+		token = page.NextPageToken
+	}
+}
+
+// Open downloads the object.
+func (b *GCSBucket) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return openBucket(ctx, b.Client, b.base()+"/o/"+url.PathEscape(b.Prefix+name)+"?alt=media")
+}
+
+// base returns the URL of the bucket resource.
+func (b *GCSBucket) base() string {
+	endpoint := b.Endpoint
+	if len(endpoint) == 0 {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/storage/v1/b/" + url.PathEscape(b.Bucket)
+}
+
+// ParseBucket returns the bucket named by an "s3://bucket/prefix" or "gs://bucket/prefix" URL.
+// The second result is false if the location is not a bucket URL.
+func ParseBucket(location string) (Bucket, bool) {
+	for scheme, bucket := range map[string]func(name, prefix string) Bucket{
+		"s3://": func(name, prefix string) Bucket { return &S3Bucket{Bucket: name, Prefix: prefix} },
+		"gs://": func(name, prefix string) Bucket { return &GCSBucket{Bucket: name, Prefix: prefix} },
+	} {
+		if !strings.HasPrefix(location, scheme) {
+			continue
+		}
+		name := strings.TrimPrefix(location, scheme)
+		prefix := ""
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, prefix = name[:i], name[i+1:]
+		}
+		if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		return bucket(name, prefix), true
+	}
+	return nil, false
+}
+
+// getBucket sends a GET request and decodes the response body.
+func getBucket(ctx context.Context, client *http.Client, location string, decode func(io.Reader) error) error {
+	body, err := openBucket(ctx, client, location)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return decode(body)
+}
+
+// openBucket sends a GET request and returns the response body if the request succeeded.
+func openBucket(ctx context.Context, client *http.Client, location string) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bucket request '%s' failed with status %d", location, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// escapeKey escapes each segment of an object key for use in a URL path.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
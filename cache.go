@@ -0,0 +1,202 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseCache remembers the outcome of external link checks while the responses are fresh
+// according to their Cache-Control and Expires headers, so explicitly cacheable resources are not refetched.
+// A nil ResponseCache remembers nothing.
+//
+// Within a single website every external link is checked once regardless of the cache,
+// no matter how many documents refer to it.
+type ResponseCache struct {
+	// Entries maps every cached link to the outcome of its check.
+	Entries map[string]CachedResponse `json:"entries"`
+
+	// TTL keeps the outcome of every check that received a response for at least this long,
+	// even if the response is not cacheable, so links are rechecked on a schedule rather than every run.
+	// It is not saved with the cache. If zero, only cacheable responses are remembered.
+	TTL time.Duration `json:"-"`
+}
+
+// CachedResponse is the outcome of an external link check that is reused until it expires.
+type CachedResponse struct {
+	FinalURL      string    `json:"final_url,omitempty"`
+	Redirects     []int     `json:"redirects,omitempty"`
+	StatusCode    int       `json:"status_code"`
+	ContentType   string    `json:"content_type,omitempty"`
+	ContentLength int64     `json:"content_length"`
+	Title         string    `json:"title,omitempty"`
+	ContentHash   uint64    `json:"content_hash,omitempty"`
+	CertExpiry    time.Time `json:"cert_expiry,omitempty"`
+	MissingAnchor bool      `json:"missing_anchor,omitempty"`
+	Expires       time.Time `json:"expires"`
+}
+
+// NewResponseCache returns an empty cache, which can be used to share responses between the websites of a single run.
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{Entries: make(map[string]CachedResponse)}
+}
+
+// LoadResponseCache reads the cache from the named file.
+// An empty cache is returned if the file does not exist.
+func LoadResponseCache(name string) (*ResponseCache, error) {
+	cache := NewResponseCache()
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, err
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]CachedResponse)
+	}
+	return cache, nil
+}
+
+// Save writes the fresh entries of the cache to the named file.
+func (c *ResponseCache) Save(name string) error {
+	now := time.Now()
+	for link, entry := range c.Entries {
+		if !now.Before(entry.Expires) {
+			delete(c.Entries, link)
+		}
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+// WithResponseCache reuses the outcome of external link checks whose responses are still fresh
+// and stores the outcome of new checks with cacheable responses.
+// Responses are not cached by default.
+func WithResponseCache(cache *ResponseCache) Option {
+	return func(w *Website) {
+		w.responseCache = cache
+	}
+}
+
+// lookup returns the cached outcome for the link if it is still fresh.
+func (c *ResponseCache) lookup(link string, now time.Time) (*ExternalResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	entry, exists := c.Entries[link]
+	if !exists || !now.Before(entry.Expires) {
+		return nil, false
+	}
+	return &ExternalResult{
+		URL:           link,
+		FinalURL:      entry.FinalURL,
+		Redirects:     entry.Redirects,
+		StatusCode:    entry.StatusCode,
+		ContentType:   entry.ContentType,
+		ContentLength: entry.ContentLength,
+		Title:         entry.Title,
+		ContentHash:   entry.ContentHash,
+		CertExpiry:    entry.CertExpiry,
+		MissingAnchor: entry.MissingAnchor,
+		Expires:       entry.Expires,
+		Cached:        true,
+	}, true
+}
+
+// store caches the outcome of a check if its response is fresh or the cache has a TTL.
+func (c *ResponseCache) store(result *ExternalResult, now time.Time) {
+	if c == nil || result.Err != nil {
+		return
+	}
+	expires := result.Expires
+	if c.TTL > 0 && expires.Before(now.Add(c.TTL)) {
+		expires = now.Add(c.TTL)
+	}
+	if !now.Before(expires) {
+		return
+	}
+	if c.Entries == nil {
+		c.Entries = make(map[string]CachedResponse)
+	}
+	c.Entries[result.URL] = CachedResponse{
+		FinalURL:      result.FinalURL,
+		Redirects:     result.Redirects,
+		StatusCode:    result.StatusCode,
+		ContentType:   result.ContentType,
+		ContentLength: result.ContentLength,
+		Title:         result.Title,
+		ContentHash:   result.ContentHash,
+		CertExpiry:    result.CertExpiry,
+		MissingAnchor: result.MissingAnchor,
+		Expires:       expires,
+	}
+}
+
+// freshUntil returns when a response stops being fresh according to its Cache-Control and Expires headers.
+// It returns the zero time if the response must not be reused.
+func freshUntil(header http.Header, now time.Time) time.Time {
+	age := time.Duration(0)
+	if seconds, err := strconv.Atoi(header.Get("Age")); err == nil && seconds > 0 {
+		age = time.Duration(seconds) * time.Second
+	}
+
+	if cacheControl := header.Get("Cache-Control"); len(cacheControl) > 0 {
+		maxAge := -1
+		for _, directive := range strings.Split(cacheControl, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			switch {
+			case directive == "no-store" || directive == "no-cache":
+				return time.Time{}
+			case strings.HasPrefix(directive, "max-age="):
+				if seconds, err := strconv.Atoi(strings.Trim(directive[len("max-age="):], `"`)); err == nil {
+					maxAge = seconds
+				}
+			}
+		}
+		if maxAge >= 0 {
+			// Max-age takes precedence over Expires.
+			return now.Add(time.Duration(maxAge)*time.Second - age)
+		}
+	}
+
+	if expires := header.Get("Expires"); len(expires) > 0 {
+		expiry, err := http.ParseTime(expires)
+		if err != nil {
+			// Invalid dates, such as "0", mean the response is already expired.
+			return time.Time{}
+		}
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			// Use the lifetime intended by the server rather than trusting the clocks to agree.
+			return now.Add(expiry.Sub(date) - age)
+		}
+		return expiry
+	}
+	return time.Time{}
+}
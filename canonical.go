@@ -0,0 +1,98 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"net/url"
+	"strings"
+)
+
+// WithHostAliases declares other hosts the website is served at, such as a former domain,
+// which redirect to the host of the URL given with WithSiteURL.
+// Absolute links to an alias are reported as warnings since every visit costs a redirect hop.
+// The host with or without a "www." prefix is always considered an alias of the canonical host,
+// so WithHostAliases can be given no hosts to only enforce the canonical choice between the two.
+func WithHostAliases(hosts ...string) Option {
+	return func(w *Website) {
+		w.canonicalHost = true
+		for _, host := range hosts {
+			w.hostAliases = append(w.hostAliases, strings.ToLower(host))
+		}
+	}
+}
+
+// checkCanonicalHost reports absolute links to an alias of the website's canonical host.
+func checkCanonicalHost(website *Website, entity *fsEntity, raw, href string) *LinkError {
+	if !website.canonicalHost || website.siteURL == nil {
+		return nil
+	}
+	u, err := url.Parse(href)
+	if err != nil || strings.EqualFold(u.Host, website.siteURL.Host) || !website.isHostAlias(strings.ToLower(u.Host)) {
+		return nil
+	}
+	canonical := *u
+	canonical.Scheme = website.siteURL.Scheme
+	canonical.Host = website.siteURL.Host
+	return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "link '%s' uses the non-canonical host '%s' (link to '%s' instead)",
+		href, u.Host, canonical.String())
+}
+
+// isHostAlias reports whether the lowercase host redirects to the canonical host.
+func (w *Website) isHostAlias(host string) bool {
+	canonical := strings.ToLower(w.siteURL.Host)
+	if host == "www."+canonical || "www."+host == canonical {
+		return true
+	}
+	return containsString(w.hostAliases, host)
+}
+
+// defaultPorts are the ports implied by the schemes of website links.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// checkSchemeAndPort reports absolute links to the website that give its default port or use another scheme
+// than the URL given with WithSiteURL, which cost a redirect and fragment caches keyed by URL.
+func checkSchemeAndPort(website *Website, entity *fsEntity, raw, href string) *LinkError {
+	if website.siteURL == nil {
+		return nil
+	}
+	u, err := url.Parse(href)
+	if err != nil || !strings.EqualFold(u.Hostname(), website.siteURL.Hostname()) {
+		return nil
+	}
+	port := u.Port()
+	explicitDefault := len(port) > 0 && port == defaultPorts[strings.ToLower(u.Scheme)]
+	if !explicitDefault && port != website.siteURL.Port() {
+		// Another server on the same host.
+		return nil
+	}
+
+	var reasons []string
+	if !strings.EqualFold(u.Scheme, website.siteURL.Scheme) {
+		reasons = append(reasons, strings.ToLower(u.Scheme)+" instead of "+website.siteURL.Scheme)
+	}
+	if explicitDefault && port != website.siteURL.Port() {
+		reasons = append(reasons, "the default port :"+port)
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	canonical := *u
+	canonical.Scheme = website.siteURL.Scheme
+	canonical.Host = website.siteURL.Host
+	return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "link '%s' uses %s (link to '%s' instead)",
+		href, strings.Join(reasons, " and "), canonical.String())
+}
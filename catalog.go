@@ -0,0 +1,71 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// Catalog translates the messages of findings into another language.
+// It maps the English message formats, as passed to fmt.Sprintf, to translated formats taking the same arguments,
+// such as "broken link '%s'%s" to "defekter Link '%s'%s". Messages without a translation are reported in English.
+// The fingerprints of findings do not depend on the catalog, so baselines work across languages.
+type Catalog map[string]string
+
+// GermanCatalog translates the most common messages into German.
+var GermanCatalog = Catalog{
+	"broken link '%s'%s":          "defekter Link '%s'%s",
+	"broken relative link '%s'%s": "defekter relativer Link '%s'%s",
+	"broken same page link '%s'":  "defekter Link '%s' innerhalb der Seite",
+	"broken target link '%s#%s'":  "defekter Ziel-Link '%s#%s'",
+	"incomplete target '#'":       "unvollständiges Ziel '#'",
+	"id '%s' appears %d times on the page (it should only appear once)": "die ID '%s' kommt %d-mal auf der Seite vor (sie sollte nur einmal vorkommen)",
+	"link '%s' redirects to '%s'":                                       "Link '%s' leitet auf '%s' weiter",
+	"unchecked external link '%s'":                                      "ungeprüfter externer Link '%s'",
+	"encountered status code %[1]d when pinging '%[2]s'":                "Statuscode %[1]d beim Abruf von '%[2]s'",
+	"encountered error when pinging '%[2]s'":                            "Fehler beim Abruf von '%[2]s'",
+	"host unreachable when pinging '%[2]s'":                             "Host nicht erreichbar beim Abruf von '%[2]s'",
+}
+
+// WithCatalog reports the messages of findings translated by the catalog.
+func WithCatalog(catalog Catalog) Option {
+	return func(w *Website) {
+		w.catalog = catalog
+	}
+}
+
+// LoadCatalog reads a catalog from the named JSON file holding an object that maps English message formats to translations.
+func LoadCatalog(name string) (Catalog, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// translate returns the translation of the message format, or the format itself if the catalog has none.
+func (c Catalog) translate(format string) string {
+	if translated, exists := c[format]; exists {
+		return translated
+	}
+	return format
+}
@@ -0,0 +1,118 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ValidateChanged detects broken links affected by a change to the website, such as the files touched by a pull request.
+// The changed names are files that were added, modified, or removed and must be relative to the root of the domain.
+// Only changed documents and documents linking to a changed name are validated, which keeps checks fast on large websites.
+// All files that still exist must be registered before calling this method.
+func (w *Website) ValidateChanged(changed []string) []error {
+	paths := make(map[string]bool)
+	for _, name := range changed {
+		paths[prepareFileName(filepath.ToSlash(name))] = true
+	}
+
+	return w.run(map[string]string{"linkup.changed": strconv.Itoa(len(paths))}, func() []error {
+		var affected []*fsEntity
+		forEachDocument(w.root, func(entity *fsEntity) {
+			if isAffected(entity, paths) {
+				affected = append(affected, entity)
+			}
+		})
+		if w.concurrency > 1 {
+			return validateDocuments(w, affected)
+		}
+		var errors []error
+		for _, entity := range affected {
+			errors = append(errors, validateDocument(w, entity)...)
+		}
+		return errors
+	})
+}
+
+// ChangedFindings detects broken links affected by a change like ValidateChanged, but returns the findings typed.
+// Like Validate, ValidateChanged keeps returning []error for compatibility.
+func (w *Website) ChangedFindings(changed []string) []*LinkError {
+	return LinkErrors(w.ValidateChanged(changed))
+}
+
+func forEachDocument(entity *fsEntity, fn func(entity *fsEntity)) {
+	if entity.directory {
+		for _, child := range entity.children {
+			forEachDocument(child, fn)
+		}
+		return
+	}
+	if entity.document {
+		fn(entity)
+	}
+}
+
+func isAffected(entity *fsEntity, paths map[string]bool) bool {
+	if paths[entity.fullname] {
+		return true
+	}
+
+	for _, href := range entity.hrefs {
+		name, ok := linkedPath(entity, sanitizeHref(href))
+		if !ok {
+			continue
+		}
+		if paths[name] {
+			return true
+		}
+		// A link to a directory is a link to its index file.
+		for _, index := range indexFiles {
+			if paths[path.Join(name, index)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// linkedPath returns the name, relative to the root of the domain, of the file an internal link refers to.
+// The file does not need to exist.
+func linkedPath(entity *fsEntity, href string) (string, bool) {
+	if strings.HasPrefix(href, "http") || strings.Contains(href, "://") {
+		return "", false
+	}
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	if len(href) == 0 {
+		return "", false
+	}
+
+	if strings.HasPrefix(href, "/") {
+		return strings.TrimPrefix(path.Clean(href), "/"), true
+	}
+
+	name := path.Join(path.Dir(entity.fullname), href)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		// The link escapes the root of the domain.
+		return "", false
+	}
+	return name, true
+}
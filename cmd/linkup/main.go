@@ -0,0 +1,1227 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+/*
+Command linkup detects broken links in a website stored on disk.
+
+Usage:
+
+	linkup [flags] directory
+
+The directory is treated as the root of the domain.
+A .zip, .tar, .tar.gz, or .tgz archive of the built website may be given instead of a directory,
+as may a publicly readable bucket written as s3://bucket/prefix or gs://bucket/prefix.
+An .epub publication is validated like a website, and its package manifest, spine, and tables of contents are verified too.
+With the -image-root flag, the argument is instead a container image saved with "docker save"
+and the files beneath the given web root of the image, such as /usr/share/nginx/html, are validated.
+Every problem found is printed on its own line.
+The -template flag renders the problems with a Go text/template instead.
+If the template defines a "finding" template, it is executed for every problem with the *linkup.LinkError,
+followed by the "summary" template, if defined, with the report.
+Otherwise the whole template is executed once with the report.
+The report has the fields Findings, Groups (see linkup.GroupByTarget), Errors, Warnings, and Infos.
+With -report=grouped, the problems with links are instead printed once per link target,
+followed by the documents linking to it. With -report=teamcity, the problems are printed as
+TeamCity service messages so they appear as inspections of the build.
+
+The -changed flag names a file listing changed files, one per line, such as the output of "git diff --name-only".
+Only the changed documents and the documents linking to changed files are then validated.
+Use "-" to read the list from standard input.
+
+The -preset flag configures the conventions of a static site generator, such as -preset=hugo.
+The directory may then be the project holding the generator's configuration or the directory the website was built into.
+
+The -git-history flag searches the given number of git commits for link targets
+that were renamed or deleted and explains broken links accordingly.
+
+The -email flag validates HTML email templates instead of a website: relative links are broken,
+links to anchors are flagged since most email clients ignore them, cid: images must name an attachment
+listed in the -attachments file, and tracking pixels must be https images.
+
+The -content-drift flag remembers a hash of the title and text of every external page in the -history file
+and warns when a page changed substantially since the previous run, such as when a domain was parked.
+
+External links are followed through up to -max-redirects redirects and work if they respond with any 2xx status,
+or one given with -accept-status, such as -accept-status=200-299,301 with -max-redirects=0. The -strict-redirects flag
+warns about links that permanently redirect, with 301 or 308, so stale URLs can be updated.
+
+The -offline flag skips checking external links, which is useful on air-gapped or flaky networks.
+Combine it with -unchecked to list the external links that were skipped.
+Conversely, the -external-only flag checks external links only, for scheduled link rot detection.
+
+The -fix flag repairs links mechanically instead of validating them:
+links to renamed targets are updated, http links are upgraded to https when the secure URL works,
+and trailing slashes are normalized. Use -fix=patch to print a unified diff or -fix=write to edit documents in place.
+
+The -source-map and -source-dir flags report findings against the Markdown or template sources
+the pages were generated from, printing the generated page in parentheses.
+The source map is a JSON object mapping page paths to source files; without one, -source-dir
+matches sources with pages by path, so content/docs/setup.md is matched with docs/setup/index.html.
+
+The -titles flag prints the title of every working external page, and the URL it redirected to,
+so editors can confirm links still lead to the intended content rather than a parked domain.
+
+The -serve flag keeps running after validation and serves the website on the given address
+with the findings of any page available as JSON at /_linkup/findings?url=, for a bookmarklet or
+browser extension to highlight broken links while browsing the site. The url parameter may also
+name a page of a staging deployment given with -site-url.
+
+The -annotate flag writes a copy of the website to the given directory with every link that has findings
+highlighted and the findings in its tooltip, so reviewers can click through the site and see failures in context.
+
+The -issues flag files an issue per broken external host, or per document with -issues-by=document,
+in GitHub or JIRA. Issues filed by earlier runs are updated rather than duplicated.
+GitHub is configured with the GITHUB_REPOSITORY (owner/repo), GITHUB_TOKEN, and optionally GITHUB_API_URL environment variables
+and JIRA with JIRA_URL, JIRA_PROJECT, JIRA_EMAIL, and JIRA_API_TOKEN.
+
+Exit codes:
+
+	0  no problems were found, or none at or above the -fail-on level
+	1  broken links were found, unless -fail-on is "never"
+	2  warnings but no broken links were found and -fail-on is "warning"
+	3  an internal error occurred, such as an unreadable directory
+
+The -fail-on flag decides whether warnings affect the exit code: with the default of "error"
+a run that only finds warnings exits with 0, so pass -fail-on=warning to fail it with 2.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hgs3/linkup"
+	"github.com/hgs3/linkup/linkupissues"
+)
+
+const (
+	exitClean    = 0
+	exitBroken   = 1
+	exitWarnings = 2
+	exitInternal = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("linkup", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	failOn := flags.String("fail-on", "error", "lowest severity that fails the run: error, warning, or never")
+	gitHistory := flags.Int("git-history", 0, "number of git commits to search for renamed or deleted link targets")
+	fix := flags.String("fix", "", "repair links mechanically instead of validating: patch prints a unified diff, write edits documents in place")
+	offline := flags.Bool("offline", false, "skip checking external links")
+	externalOnly := flags.Bool("external-only", false, "check only external links")
+	proxy := flags.String("proxy", "", "proxy URL for external link requests, instead of the HTTP_PROXY and HTTPS_PROXY environment variables")
+	caCert := flags.String("ca-cert", "", "PEM file of additional certificate authorities to trust, such as a corporate CA")
+	timeout := flags.Duration("timeout", 2*time.Second, "time allowed for checking a single external link")
+	deadline := flags.Duration("deadline", 0, "time allowed for the whole run, after which external links are left unchecked")
+	acceptStatus := flags.String("accept-status", "", "comma-separated HTTP status codes and ranges with which external links are working, as in 200-299,301 (default any 2xx)")
+	maxRedirects := flags.Int("max-redirects", 10, "redirects followed at most for a single external link, after which it is broken (0 reports redirects by their status code)")
+	strictRedirects := flags.Bool("strict-redirects", false, "warn about external links that permanently redirect, naming the URL to update them to")
+	getFallback := flags.Bool("get-fallback", false, "retry with GET when a server rejects HEAD requests")
+	maxResponseBytes := flags.Int64("max-response-bytes", 1<<20, "bytes read at most from a single response body")
+	maxTotalBytes := flags.Int64("max-total-bytes", 0, "bytes read at most from all response bodies (0 means no limit)")
+	hostFailures := flags.Int("host-failures", 0, "consecutive failures after which a host is considered unreachable (0 disables)")
+	report := flags.String("report", "flat", "how findings are printed: flat prints one line per finding, grouped prints link findings once per target, teamcity prints TeamCity service messages")
+	catalog := flags.String("catalog", "", "translate messages: de for German, or a JSON file mapping English message formats to translations")
+	serve := flags.String("serve", "", "after validating, serve the website and the findings of its pages as JSON at /_linkup/findings?url= on the address, such as localhost:8080")
+	titles := flags.Bool("titles", false, "fetch external pages to print their titles, and where they redirect to, after the findings")
+	annotate := flags.String("annotate", "", "write a copy of the website to the directory with the links that have findings highlighted")
+	issues := flags.String("issues", "", "file issues for broken links in an issue tracker: github or jira")
+	issuesBy := flags.String("issues-by", "host", "group the filed issues by broken external host or by document: host or document")
+	templateFile := flags.String("template", "", "render findings with the Go text/template in this file instead of printing them")
+	fingerprints := flags.Bool("fingerprints", false, "append the fingerprint identifying each finding across runs, for baselines and suppressions")
+	groupByHost := flags.Bool("group-by-host", false, "summarize external link findings by destination host")
+	contentDrift := flags.Bool("content-drift", false, "warn about external pages whose content changed substantially since the run recorded in -history")
+	historyFile := flags.String("history", "", "file remembering external link outcomes across runs so flaky links are reported as warnings")
+	slugs := flags.String("slugs", "", "infer ids for headings without one using the rules of a generator: github, hugo, jekyll, goldmark, kramdown, mkdocs, or pandoc")
+	normalize := flags.Bool("normalize", false, "normalize external links and strip utm_* parameters before checking them")
+	tracking := flags.Bool("tracking", false, "warn about external links carrying tracking parameters or session ids")
+	routes := flags.String("routes", "", "file listing the client-side routes of a single-page app that fragment router links like /#/settings are validated against")
+	frameworks := flags.Bool("frameworks", false, "extract links from framework attributes such as ng-href, routerLink, and :href")
+	linkAttributes := flags.String("link-attributes", "", "comma-separated element:attribute pairs carrying additional links, such as video:poster-src or *:data-download-url")
+	eventHandlers := flags.Bool("event-handlers", false, "extract links navigated to by inline event handlers such as onclick")
+	scriptLinks := flags.String("script-links", "", "scan inline scripts for path literals matching the regular expression, or \"default\" for paths to pages and directories")
+	dataURILimit := flags.Int("data-uri-limit", 0, "warn about data: URIs whose payload exceeds this many bytes (0 disables)")
+	websockets := flags.String("websockets", "", "check ws:// and wss:// links: handshake completes the WebSocket handshake, connect only opens a connection")
+	smallWeb := flags.Bool("small-web", false, "check gemini:// and gopher:// links")
+	var checkLevels repeatedFlag
+	flags.Var(&checkLevels, "check-level", "check external links matching a glob at a level, as in 'https://news.example/*=skip'; the levels are skip, dns-only, head, get, and get+anchor (repeatable)")
+	var docAnchors repeatedFlag
+	flags.Var(&docAnchors, "doc-anchors", "verify anchored links into external documentation matching the glob still name a documented symbol, or \"default\" for popular API references (repeatable)")
+	var docVersions repeatedFlag
+	flags.Var(&docVersions, "doc-version", "warn about links to documentation matching the glob that follow the latest version, as in 'https://docs.example.com/*=pin:latest:v2.4', or that pin one, as in 'https://docs.example.com/*=latest:stable' (repeatable)")
+	var severityOverrides repeatedFlag
+	flags.Var(&severityOverrides, "severity", "change the severity of matching findings, as in 'info=status:403,link:*://*.linkedin.com/*' or 'error=kind:redirect' (repeatable)")
+	var hostPolicies repeatedFlag
+	flags.Var(&hostPolicies, "host-policy", "override checks for hosts matching a glob, as in '*.example.com=timeout:10s,retries:2,interval:1s' (repeatable)")
+	cookies := flags.Bool("cookies", false, "keep cookies set by servers across external link checks, like a browser session")
+	cacheTTL := flags.Duration("cache-ttl", 0, "keep every external link outcome in the -cache file for at least this long, as in 24h, even if the response is not cacheable")
+	cacheFile := flags.String("cache", "", "file caching external link outcomes across runs while their responses are fresh according to Cache-Control and Expires")
+	citations := flags.Bool("citations", false, "warn about citation links without an archived copy on archive.org or archive.today")
+	metadata := flags.Bool("metadata", false, "warn about documents without exactly one title, h1, meta description, and canonical link")
+	headings := flags.Bool("headings", false, "warn about headings that skip a level, such as an h4 directly following an h2")
+	toc := flags.Bool("toc", false, "warn when in-page tables of contents miss headings or list entries that are not headings")
+	email := flags.Bool("email", false, "validate the documents as HTML email templates: links must be absolute, anchors are flagged, and tracking pixels are checked")
+	attachments := flags.String("attachments", "", "file listing the Content-IDs of the email's attachments, one per line, which cid: images must name (implies -email)")
+	amp := flags.Bool("amp", false, "verify rel=amphtml and rel=canonical links between pages and their AMP variants, and the resources AMP pages load")
+	pagination := flags.Bool("pagination", false, "verify rel=next and rel=prev links form consistent, loop-free chains")
+	breadcrumbs := flags.String("breadcrumbs", "", "check breadcrumb trails: resolve checks the links exist, ancestors also checks they follow the directory ancestry, exact also checks no ancestor is missing")
+	notFoundPage := flags.String("404-page", "404.html", "custom 404 page whose asset URLs must be absolute (empty disables the check)")
+	linkStyle := flags.String("link-style", "", "report internal links not written in the preferred style: relative, root-relative, or absolute")
+	canonicalHost := flags.Bool("canonical-host", false, "warn about absolute links to the www. variant of the -site-url host, or to a -host-aliases host")
+	hostAliases := flags.String("host-aliases", "", "comma-separated hosts redirecting to the -site-url host, whose absolute links are reported")
+	siteURL := flags.String("site-url", "", "URL the website is published at, so absolute links to it are recognized as internal")
+	var unpublished repeatedFlag
+	flags.Var(&unpublished, "unpublished", "glob matching unpublished files, such as drafts/*, that published pages must not link to (repeatable)")
+	deployment := flags.String("deployment", "", "file listing the deployed files, such as an S3 or rsync listing, that internal links are cross-checked against")
+	imageRoot := flags.String("image-root", "", "treat the argument as a saved container image and validate the files beneath this web root, such as /usr/share/nginx/html")
+	concurrency := flags.Int("concurrency", 1, "number of documents to validate at once")
+	suggest := flags.Bool("suggest", false, "name the file a broken internal link most likely meant")
+	memProfile := flags.String("memprofile", "", "write a heap profile to this file after validation")
+	presetName := flags.String("preset", "", "configure the conventions of a generator or host: "+strings.Join(linkup.PresetNames(), ", "))
+	maxDepth := flags.Int("max-depth", 0, "warn about pages more than this many clicks from the home page (0 disables the check)")
+	langCheck := flags.Bool("lang", false, "warn about documents without a valid lang attribute or whose language does not match their locale directory")
+	locales := flags.String("locales", "", "comma-separated top-level directories holding the translations of the website, such as en,fr,de")
+	localeParity := flags.Bool("locale-parity", false, "warn about pages missing from some -locales directories and links leaving the locale of their page")
+	localeExempt := flags.String("locale-exempt", "", "comma-separated globs, relative to the locale directories, of pages that need not be translated")
+	assetManifest := flags.String("asset-manifest", "", "bundler manifest.json mapping logical asset names such as app.css to fingerprinted files")
+	sourceMap := flags.String("source-map", "", "JSON file mapping page paths to the source files they were generated from, to report findings against")
+	sourceDir := flags.String("source-dir", "", "directory of Markdown or template sources to report findings against, matched with the pages by path")
+	positions := flags.Bool("positions", false, "locate links in their documents and print findings as document:line:column")
+	duplicates := flags.Bool("duplicates", false, "warn about documents with identical content at several paths")
+	strict := flags.Bool("strict", false, "warn about malformed markup the HTML parser silently repairs")
+	emptyLinks := flags.String("empty-links", "ignore", "report links with an empty or whitespace-only URL: ignore, warn, or error")
+	targetBlank := flags.Bool("target-blank", false, "warn about external links opened with target=_blank that lack rel=noopener")
+	var relPolicies repeatedFlag
+	flags.Var(&relPolicies, "rel-policy", "link types links to hosts matching a glob must have, or must not have when prefixed with !, as in '*.shop.example=nofollow sponsored' (repeatable)")
+	outbound := flags.String("outbound", "", "print every external host and URL with the documents referencing them instead of validating: text or json")
+	unchecked := flags.Bool("unchecked", false, "list external links that were not checked")
+	changedList := flags.String("changed", "", "file listing the changed files to validate, or - for standard input")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: linkup [flags] directory")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitInternal
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitInternal
+	}
+
+	switch *failOn {
+	case "error", "warning", "never":
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -fail-on value '%s'\n", *failOn)
+		return exitInternal
+	}
+
+	slugifiers := map[string]linkup.Slugifier{
+		"github":   linkup.GitHubSlugs,
+		"hugo":     linkup.HugoSlugs,
+		"jekyll":   linkup.JekyllSlugs,
+		"goldmark": linkup.GoldmarkSlugs,
+		"kramdown": linkup.KramdownSlugs,
+		"mkdocs":   linkup.MkDocsSlugs,
+		"pandoc":   linkup.PandocSlugs,
+	}
+	slugifier, exists := slugifiers[*slugs]
+	if *slugs != "" && !exists {
+		fmt.Fprintf(stderr, "linkup: invalid -slugs value '%s'\n", *slugs)
+		return exitInternal
+	}
+
+	if *offline && *externalOnly {
+		fmt.Fprintln(stderr, "linkup: -offline and -external-only cannot be combined")
+		return exitInternal
+	}
+
+	switch *fix {
+	case "", "patch", "write":
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -fix value '%s'\n", *fix)
+		return exitInternal
+	}
+
+	var jar http.CookieJar
+	if *cookies {
+		jar, _ = cookiejar.New(nil)
+	}
+
+	var client *http.Client
+	if *proxy != "" || *caCert != "" {
+		var err error
+		if client, err = newHTTPClient(*proxy, *caCert); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		client.Jar = jar
+	}
+
+	dir := flags.Arg(0)
+	options := []linkup.Option{
+		linkup.WithExternalChecks(!*offline),
+		linkup.WithInternalChecks(!*externalOnly),
+		linkup.WithReportUnchecked(*unchecked),
+		linkup.WithRequestTimeout(*timeout),
+		linkup.WithOverallDeadline(*deadline),
+		linkup.WithCircuitBreaker(*hostFailures),
+		linkup.WithFrameworkAttributes(*frameworks),
+		linkup.WithEventHandlerLinks(*eventHandlers),
+		linkup.WithDataURILimit(*dataURILimit),
+		linkup.WithSmallWebChecks(*smallWeb),
+		linkup.WithConcurrency(*concurrency),
+		linkup.WithTargetBlankCheck(*targetBlank),
+		linkup.WithMaxClickDepth(*maxDepth),
+		linkup.WithStrictParsing(*strict),
+		linkup.WithDuplicateDetection(*duplicates),
+		linkup.WithPageTitles(*titles),
+		linkup.WithLinePositions(*positions),
+		linkup.WithLangCheck(*langCheck),
+		linkup.WithMetadataAudit(*metadata),
+		linkup.WithHeadingHierarchy(*headings),
+		linkup.WithTOCCheck(*toc),
+		linkup.WithPaginationCheck(*pagination),
+		linkup.WithAMPCheck(*amp),
+		linkup.WithStrictRedirects(*strictRedirects),
+		linkup.WithNotFoundPage(*notFoundPage),
+		linkup.WithExternalChecker(&linkup.HTTPChecker{
+			Client:           client,
+			Jar:              jar,
+			GetFallback:      *getFallback,
+			MaxResponseBytes: *maxResponseBytes,
+			MaxTotalBytes:    *maxTotalBytes,
+		}),
+		linkup.WithMaxRedirects(*maxRedirects),
+	}
+	if *normalize {
+		options = append(options, linkup.WithNormalization(linkup.DefaultNormalization))
+	}
+	if *linkAttributes != "" {
+		attributes := make(map[string][]string)
+		for _, pair := range strings.Split(*linkAttributes, ",") {
+			i := strings.Index(pair, ":")
+			if i <= 0 || i == len(pair)-1 {
+				fmt.Fprintf(stderr, "linkup: invalid -link-attributes value '%s'\n", pair)
+				return exitInternal
+			}
+			element := strings.TrimSpace(pair[:i])
+			attributes[element] = append(attributes[element], strings.TrimSpace(pair[i+1:]))
+		}
+		options = append(options, linkup.WithLinkAttributes(attributes))
+	}
+	levels := map[string]linkup.CheckLevel{
+		"skip":       linkup.CheckSkip,
+		"dns-only":   linkup.CheckDNS,
+		"head":       linkup.CheckHead,
+		"get":        linkup.CheckGet,
+		"get+anchor": linkup.CheckAnchor,
+	}
+	for _, rule := range checkLevels {
+		i := strings.LastIndex(rule, "=")
+		level, exists := levels[rule[i+1:]]
+		if i <= 0 || !exists {
+			fmt.Fprintf(stderr, "linkup: invalid -check-level value '%s'\n", rule)
+			return exitInternal
+		}
+		options = append(options, linkup.WithCheckLevel(rule[:i], level))
+	}
+	if *acceptStatus != "" {
+		codes, err := parseStatusCodes(*acceptStatus)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: invalid -accept-status value '%s': %v\n", *acceptStatus, err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithAcceptedStatusCodes(codes...))
+	}
+	for _, rule := range hostPolicies {
+		pattern, policy, err := parseHostPolicy(rule)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: invalid -host-policy value '%s': %v\n", rule, err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithHostPolicy(pattern, policy))
+	}
+	if *locales != "" {
+		options = append(options, linkup.WithLocales(strings.Split(*locales, ",")...))
+	}
+	if *assetManifest != "" {
+		manifest, err := linkup.LoadAssetManifest(*assetManifest)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithAssetManifest(manifest))
+	}
+	if *sourceMap != "" {
+		sources, err := linkup.LoadSourceMap(*sourceMap)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithSourceMap(sources))
+	}
+	if *sourceDir != "" {
+		options = append(options, linkup.WithSourceDir(*sourceDir))
+	}
+	if *email || *attachments != "" {
+		var ids []string
+		if *attachments != "" {
+			var err error
+			if ids, err = linkup.LoadAttachmentManifest(*attachments); err != nil {
+				fmt.Fprintf(stderr, "linkup: %v\n", err)
+				return exitInternal
+			}
+		}
+		options = append(options, linkup.WithEmailTemplates(ids...))
+	}
+	if *canonicalHost || *hostAliases != "" {
+		var aliases []string
+		if *hostAliases != "" {
+			aliases = strings.Split(*hostAliases, ",")
+		}
+		options = append(options, linkup.WithHostAliases(aliases...))
+	}
+	if *localeParity {
+		var exempt []string
+		if *localeExempt != "" {
+			exempt = strings.Split(*localeExempt, ",")
+		}
+		options = append(options, linkup.WithLocaleParity(exempt...))
+	}
+	for _, pattern := range docAnchors {
+		if pattern == "default" {
+			options = append(options, linkup.WithDocAnchors())
+		} else {
+			options = append(options, linkup.WithDocAnchors(pattern))
+		}
+	}
+	for _, rule := range docVersions {
+		i := strings.LastIndex(rule, "=")
+		var settings []string
+		if i > 0 {
+			settings = strings.Split(rule[i+1:], ":")
+		}
+		switch {
+		case len(settings) == 2 && settings[0] == "latest":
+			options = append(options, linkup.WithDocVersionPolicy(linkup.DocVersionPolicy{Pattern: rule[:i], Latest: settings[1], PreferLatest: true}))
+		case (len(settings) == 2 || len(settings) == 3) && settings[0] == "pin":
+			policy := linkup.DocVersionPolicy{Pattern: rule[:i], Latest: settings[1]}
+			if len(settings) == 3 {
+				policy.Pinned = settings[2]
+			}
+			options = append(options, linkup.WithDocVersionPolicy(policy))
+		default:
+			fmt.Fprintf(stderr, "linkup: invalid -doc-version value '%s'\n", rule)
+			return exitInternal
+		}
+	}
+	for _, rule := range severityOverrides {
+		override, err := parseSeverityOverride(rule)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: invalid -severity value '%s': %v\n", rule, err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithSeverityOverride(override))
+	}
+	for _, rule := range relPolicies {
+		i := strings.Index(rule, "=")
+		if i <= 0 {
+			fmt.Fprintf(stderr, "linkup: invalid -rel-policy value '%s'\n", rule)
+			return exitInternal
+		}
+		var policy linkup.RelPolicy
+		for _, rel := range strings.Fields(rule[i+1:]) {
+			if strings.HasPrefix(rel, "!") {
+				policy.Forbid = append(policy.Forbid, rel[1:])
+			} else {
+				policy.Require = append(policy.Require, rel)
+			}
+		}
+		options = append(options, linkup.WithRelPolicy(rule[:i], policy))
+	}
+	breadcrumbPolicies := map[string]linkup.BreadcrumbPolicy{
+		"":          linkup.BreadcrumbsOff,
+		"resolve":   linkup.BreadcrumbsResolve,
+		"ancestors": linkup.BreadcrumbsAncestors,
+		"exact":     linkup.BreadcrumbsExact,
+	}
+	breadcrumbPolicy, exists := breadcrumbPolicies[*breadcrumbs]
+	if !exists {
+		fmt.Fprintf(stderr, "linkup: invalid -breadcrumbs value '%s'\n", *breadcrumbs)
+		return exitInternal
+	}
+	options = append(options, linkup.WithBreadcrumbs(breadcrumbPolicy))
+	linkStyles := map[string]linkup.LinkStyle{
+		"":              linkup.AnyLinkStyle,
+		"relative":      linkup.PreferRelative,
+		"root-relative": linkup.PreferRootRelative,
+		"absolute":      linkup.PreferAbsolute,
+	}
+	style, exists := linkStyles[*linkStyle]
+	if !exists {
+		fmt.Fprintf(stderr, "linkup: invalid -link-style value '%s'\n", *linkStyle)
+		return exitInternal
+	}
+	options = append(options, linkup.WithLinkStyle(style), linkup.WithSiteURL(*siteURL))
+	options = append(options, linkup.WithUnpublished(unpublished...))
+	if slugifier != nil {
+		options = append(options, linkup.WithHeadingSlugs(slugifier))
+	}
+	if *presetName != "" {
+		preset, err := linkup.LoadPreset(*presetName, dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		// Flags take precedence over the preset.
+		dir = preset.Dir
+		options = append(preset.Options, options...)
+	}
+	if *suggest {
+		options = append(options, linkup.WithSuggestions())
+	}
+	switch *catalog {
+	case "":
+	case "de":
+		options = append(options, linkup.WithCatalog(linkup.GermanCatalog))
+	default:
+		messages, err := linkup.LoadCatalog(*catalog)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithCatalog(messages))
+	}
+	var tmpl *template.Template
+	if *templateFile != "" {
+		var err error
+		if tmpl, err = template.ParseFiles(*templateFile); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+	}
+	var tracker linkupissues.Tracker
+	switch *issues {
+	case "":
+	case "github":
+		repository := strings.SplitN(os.Getenv("GITHUB_REPOSITORY"), "/", 2)
+		if len(repository) != 2 {
+			fmt.Fprintf(stderr, "linkup: GITHUB_REPOSITORY must be set to owner/repo\n")
+			return exitInternal
+		}
+		tracker = &linkupissues.GitHubTracker{Owner: repository[0], Repo: repository[1], Token: os.Getenv("GITHUB_TOKEN"), Endpoint: os.Getenv("GITHUB_API_URL")}
+	case "jira":
+		tracker = &linkupissues.JiraTracker{URL: os.Getenv("JIRA_URL"), Project: os.Getenv("JIRA_PROJECT"), Email: os.Getenv("JIRA_EMAIL"), Token: os.Getenv("JIRA_API_TOKEN")}
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -issues value '%s'\n", *issues)
+		return exitInternal
+	}
+	grouping := linkupissues.ByHost
+	switch *issuesBy {
+	case "host":
+	case "document":
+		grouping = linkupissues.ByDocument
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -issues-by value '%s'\n", *issuesBy)
+		return exitInternal
+	}
+	var grouped, teamcity bool
+	switch *report {
+	case "flat":
+	case "grouped":
+		grouped = true
+	case "teamcity":
+		teamcity = true
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -report value '%s'\n", *report)
+		return exitInternal
+	}
+	switch *emptyLinks {
+	case "ignore":
+	case "warn":
+		options = append(options, linkup.WithEmptyLinks(linkup.EmptyLinksWarn))
+	case "error":
+		options = append(options, linkup.WithEmptyLinks(linkup.EmptyLinksError))
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -empty-links value '%s'\n", *emptyLinks)
+		return exitInternal
+	}
+	switch *websockets {
+	case "":
+	case "handshake", "connect":
+		options = append(options, linkup.WithWebSocketChecker(&linkup.WebSocketChecker{ConnectOnly: *websockets == "connect"}))
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -websockets value '%s'\n", *websockets)
+		return exitInternal
+	}
+	switch *scriptLinks {
+	case "":
+	case "default":
+		options = append(options, linkup.WithScriptLinks(linkup.DefaultScriptLinkPattern))
+	default:
+		pattern, err := regexp.Compile(*scriptLinks)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: invalid -script-links value: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithScriptLinks(pattern))
+	}
+	if *citations {
+		options = append(options, linkup.WithArchivedCitations(linkup.DefaultCitationSelector))
+	}
+	if *tracking {
+		options = append(options, linkup.WithTrackingParams(linkup.DefaultTrackingParams))
+	}
+	if *gitHistory > 0 {
+		renames, err := linkup.GitRenames(dir, *gitHistory)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithRenames(renames))
+	}
+
+	if *routes != "" {
+		clientRoutes, err := linkup.LoadRoutes(*routes)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithClientRoutes(clientRoutes))
+	}
+
+	if *deployment != "" {
+		deployed, err := linkup.LoadDeployment(*deployment)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithDeployment(deployed))
+	}
+
+	var history *linkup.History
+	if *historyFile != "" {
+		var err error
+		if history, err = linkup.LoadHistory(*historyFile); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		options = append(options, linkup.WithHistory(history))
+	}
+	if *contentDrift {
+		if history == nil {
+			fmt.Fprintln(stderr, "linkup: -content-drift requires -history")
+			return exitInternal
+		}
+		options = append(options, linkup.WithContentDrift(true))
+	}
+
+	var cache *linkup.ResponseCache
+	if *cacheTTL > 0 && *cacheFile == "" {
+		fmt.Fprintln(stderr, "linkup: -cache-ttl requires -cache")
+		return exitInternal
+	}
+	if *cacheFile != "" {
+		var err error
+		if cache, err = linkup.LoadResponseCache(*cacheFile); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		cache.TTL = *cacheTTL
+		options = append(options, linkup.WithResponseCache(cache))
+	}
+
+	w := linkup.New(options...)
+	bucket, isBucket := linkup.ParseBucket(dir)
+	if (isBucket || linkup.IsArchive(dir) || *imageRoot != "") && *fix != "" {
+		fmt.Fprintln(stderr, "linkup: -fix can only repair the documents of a directory")
+		return exitInternal
+	}
+	if (isBucket || linkup.IsArchive(dir) || *imageRoot != "") && *annotate != "" {
+		fmt.Fprintln(stderr, "linkup: -annotate can only copy the documents of a directory")
+		return exitInternal
+	}
+	var err error
+	switch {
+	case *imageRoot != "":
+		err = w.AddImage(dir, *imageRoot)
+	case isBucket:
+		err = w.AddBucket(context.Background(), bucket)
+	case linkup.IsArchive(dir):
+		err = w.AddArchive(dir)
+	default:
+		err = w.AddDirectory(dir)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "linkup: %v\n", err)
+		return exitInternal
+	}
+
+	if *fix != "" {
+		if err := applyFixes(w.Fixes(), dir, *fix == "write", stdout); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		return exitClean
+	}
+
+	switch *outbound {
+	case "":
+	case "text":
+		for _, host := range w.Outbound() {
+			fmt.Fprintf(stdout, "%s: %d pages\n", host.Host, host.Pages)
+			for _, link := range host.URLs {
+				fmt.Fprintf(stdout, "\t%s: %d pages\n", link.URL, len(link.Documents))
+			}
+		}
+		return exitClean
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(w.Outbound()); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		return exitClean
+	default:
+		fmt.Fprintf(stderr, "linkup: invalid -outbound value '%s'\n", *outbound)
+		return exitInternal
+	}
+
+	var errs []error
+	if *changedList != "" {
+		changed, err := readChangedList(*changedList, dir, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		errs = w.ValidateChanged(changed)
+	} else {
+		errs = w.Validate()
+	}
+
+	if *memProfile != "" {
+		if err := writeHeapProfile(*memProfile); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		summary := w.Summary()
+		fmt.Fprintf(stderr, "linkup: %d documents, %d files, %d links, about %d KiB\n",
+			summary.Documents, summary.Files, summary.Links, summary.MemoryBytes/1024)
+	}
+
+	if history != nil {
+		if err := history.Save(*historyFile); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+	}
+
+	if cache != nil {
+		if err := cache.Save(*cacheFile); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+	}
+
+	if *annotate != "" {
+		if err := annotateSite(errs, dir, *annotate); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+	}
+
+	if tracker != nil {
+		result, err := linkupissues.File(context.Background(), tracker, linkupissues.Issues(errs, grouping))
+		if err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+		fmt.Fprintf(stderr, "linkup: %d issues created, %d updated, %d unchanged\n", result.Created, result.Updated, result.Unchanged)
+	}
+
+	errorCount := 0
+	warningCount := 0
+	for _, err := range errs {
+		severity := linkup.SeverityError
+		var linkErr *linkup.LinkError
+		if errors.As(err, &linkErr) {
+			severity = linkErr.Severity
+		}
+		switch severity {
+		case linkup.SeverityWarning:
+			warningCount++
+		case linkup.SeverityError:
+			errorCount++
+		}
+		if tmpl != nil || teamcity {
+			// Rendered below.
+			continue
+		}
+		if grouped && linkErr != nil && linkErr.Href != "" {
+			// Printed with its target below.
+			continue
+		}
+		line := err.Error()
+		if linkErr != nil && linkErr.Source != "" {
+			line = fmt.Sprintf("%s: %s (in %s)", linkErr.Source, linkErr.Message, linkErr.Document)
+		} else if linkErr != nil && linkErr.Line > 0 && linkErr.Column > 0 {
+			line = fmt.Sprintf("%s:%d:%d: %s", linkErr.Document, linkErr.Line, linkErr.Column, linkErr.Message)
+		}
+		switch severity {
+		case linkup.SeverityWarning:
+			line = "warning: " + line
+		case linkup.SeverityInfo:
+			line = "info: " + line
+		}
+		if *fingerprints && linkErr != nil {
+			line += " [" + linkErr.Fingerprint + "]"
+		}
+		fmt.Fprintln(stdout, line)
+	}
+
+	if tmpl != nil {
+		if err := renderTemplate(tmpl, errs, stdout); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+	}
+
+	if teamcity && tmpl == nil {
+		printTeamCity(errs, stdout)
+	}
+
+	if grouped && tmpl == nil {
+		for _, group := range linkup.GroupByTarget(errs) {
+			fmt.Fprintf(stdout, "%s: %s on %d pages\n", group.Target, group.Severity, len(group.Documents))
+			for _, message := range group.Messages {
+				fmt.Fprintf(stdout, "\t%s\n", message)
+			}
+			for _, document := range group.Documents {
+				fmt.Fprintf(stdout, "\tin %s\n", document)
+			}
+		}
+	}
+
+	if *groupByHost {
+		for _, summary := range linkup.GroupByHost(errs) {
+			fmt.Fprintf(stdout, "%s: %d findings, %d failed requests, worst status %d\n", summary.Host, summary.Findings, summary.Failures, summary.WorstStatus)
+		}
+	}
+
+	if *titles {
+		for _, result := range w.ExternalResults() {
+			if result.Err != nil || result.StatusCode >= 400 {
+				continue
+			}
+			line := fmt.Sprintf("%s: '%s'", result.URL, result.Title)
+			if result.FinalURL != "" && result.FinalURL != result.URL {
+				line += " (at " + result.FinalURL + ")"
+			}
+			fmt.Fprintln(stdout, line)
+		}
+	}
+
+	if *serve != "" {
+		fmt.Fprintf(stderr, "linkup: serving on http://%s/ with findings at /_linkup/findings?url=\n", *serve)
+		if err := http.ListenAndServe(*serve, findingsMux(w, errs, dir, !isBucket && !linkup.IsArchive(dir) && *imageRoot == "")); err != nil {
+			fmt.Fprintf(stderr, "linkup: %v\n", err)
+			return exitInternal
+		}
+	}
+
+	if *failOn == "never" {
+		return exitClean
+	}
+	if errorCount > 0 {
+		return exitBroken
+	}
+	if warningCount > 0 && *failOn == "warning" {
+		return exitWarnings
+	}
+	return exitClean
+}
+
+// report is the data passed to -template templates.
+type report struct {
+	Findings []*linkup.LinkError
+	Groups   []linkup.FindingGroup
+	Errors   int
+	Warnings int
+	Infos    int
+}
+
+// renderTemplate renders the findings with a user supplied template.
+func renderTemplate(tmpl *template.Template, errs []error, stdout io.Writer) error {
+	data := report{Groups: linkup.GroupByTarget(errs)}
+	for _, err := range errs {
+		var linkErr *linkup.LinkError
+		if !errors.As(err, &linkErr) {
+			linkErr = &linkup.LinkError{Message: err.Error()}
+		}
+		data.Findings = append(data.Findings, linkErr)
+		switch linkErr.Severity {
+		case linkup.SeverityError:
+			data.Errors++
+		case linkup.SeverityWarning:
+			data.Warnings++
+		case linkup.SeverityInfo:
+			data.Infos++
+		}
+	}
+
+	finding := tmpl.Lookup("finding")
+	if finding == nil {
+		return tmpl.Execute(stdout, data)
+	}
+	for _, linkErr := range data.Findings {
+		if err := finding.Execute(stdout, linkErr); err != nil {
+			return err
+		}
+	}
+	if summary := tmpl.Lookup("summary"); summary != nil {
+		return summary.Execute(stdout, data)
+	}
+	return nil
+}
+
+// teamcityEscaper escapes the values of TeamCity service messages.
+var teamcityEscaper = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]",
+	"\u0085", "|x", "\u2028", "|l", "\u2029", "|p")
+
+// printTeamCity prints the findings as TeamCity inspections, one inspection type per severity,
+// and reports a build problem if there are errors.
+func printTeamCity(errs []error, stdout io.Writer) {
+	severities := []linkup.Severity{linkup.SeverityError, linkup.SeverityWarning, linkup.SeverityInfo}
+	descriptions := []string{"Broken links", "Link warnings", "Link notes"}
+	for i, severity := range severities {
+		fmt.Fprintf(stdout, "##teamcity[inspectionType id='linkup.%s' name='%s' category='LinkUp' description='%s found by LinkUp']\n",
+			severity, descriptions[i], descriptions[i])
+	}
+
+	errorCount := 0
+	for _, err := range errs {
+		finding := &linkup.LinkError{Message: err.Error()}
+		errors.As(err, &finding)
+		level := strings.ToUpper(finding.Severity.String())
+		file := finding.Document
+		if finding.Source != "" {
+			file = finding.Source
+		}
+		if finding.Severity == linkup.SeverityError {
+			errorCount++
+		}
+		attributes := ""
+		if finding.Line > 0 && finding.Source == "" {
+			attributes = fmt.Sprintf(" line='%d'", finding.Line)
+		}
+		if finding.Fingerprint != "" {
+			attributes += fmt.Sprintf(" fingerprint='%s'", finding.Fingerprint)
+		}
+		fmt.Fprintf(stdout, "##teamcity[inspection typeId='linkup.%s' message='%s' file='%s'%s SEVERITY='%s']\n",
+			finding.Severity, teamcityEscaper.Replace(finding.Message), teamcityEscaper.Replace(file), attributes, level)
+	}
+	if errorCount > 0 {
+		fmt.Fprintf(stdout, "##teamcity[buildProblem description='LinkUp found %d broken links' identity='linkup']\n", errorCount)
+	}
+}
+
+// repeatedFlag collects the values of a flag that can be given more than once.
+type repeatedFlag []string
+
+func (f *repeatedFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// parseHostPolicy parses a host pattern followed by comma-separated settings, as in "*.example.com=timeout:10s,retries:2".
+func parseHostPolicy(rule string) (string, linkup.HostPolicy, error) {
+	var policy linkup.HostPolicy
+	i := strings.Index(rule, "=")
+	if i <= 0 {
+		return "", policy, errors.New("missing host pattern")
+	}
+	for _, setting := range strings.Split(rule[i+1:], ",") {
+		parts := strings.SplitN(setting, ":", 2)
+		if len(parts) != 2 {
+			return "", policy, fmt.Errorf("malformed setting '%s'", setting)
+		}
+		var err error
+		switch parts[0] {
+		case "timeout":
+			policy.Timeout, err = time.ParseDuration(parts[1])
+		case "interval":
+			policy.Interval, err = time.ParseDuration(parts[1])
+		case "retries":
+			policy.Retries, err = strconv.Atoi(parts[1])
+		default:
+			err = fmt.Errorf("unknown setting '%s'", parts[0])
+		}
+		if err != nil {
+			return "", policy, err
+		}
+	}
+	return rule[:i], policy, nil
+}
+
+// parseSeverityOverride parses a severity followed by comma-separated conditions, as in "info=status:403,link:*://*.example.com/*".
+// A kind condition may be repeated to match findings of any of the kinds, as in "warning=kind:anchor,kind:redirect".
+func parseSeverityOverride(rule string) (linkup.SeverityOverride, error) {
+	var override linkup.SeverityOverride
+	i := strings.Index(rule, "=")
+	if i <= 0 {
+		return override, errors.New("missing severity")
+	}
+	switch rule[:i] {
+	case "error":
+		override.Severity = linkup.SeverityError
+	case "warning":
+		override.Severity = linkup.SeverityWarning
+	case "info":
+		override.Severity = linkup.SeverityInfo
+	default:
+		return override, fmt.Errorf("unknown severity '%s'", rule[:i])
+	}
+	for _, condition := range strings.Split(rule[i+1:], ",") {
+		parts := strings.SplitN(condition, ":", 2)
+		if len(parts) != 2 {
+			return override, fmt.Errorf("malformed condition '%s'", condition)
+		}
+		switch parts[0] {
+		case "link":
+			override.Link = parts[1]
+		case "message":
+			override.Message = parts[1]
+		case "status":
+			status, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return override, err
+			}
+			override.Status = status
+		case "kind":
+			kind, ok := linkup.ParseKind(parts[1])
+			if !ok {
+				return override, fmt.Errorf("unknown kind '%s'", parts[1])
+			}
+			override.Kinds = append(override.Kinds, kind)
+		default:
+			return override, fmt.Errorf("unknown condition '%s'", parts[0])
+		}
+	}
+	return override, nil
+}
+
+// parseStatusCodes parses comma-separated status codes and inclusive ranges of them, as in "200-299,301".
+func parseStatusCodes(list string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(list, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(field), "-", 2)
+		low, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		high := low
+		if len(bounds) == 2 {
+			if high, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		if low < 100 || high > 599 || low > high {
+			return nil, fmt.Errorf("invalid status code range '%s'", field)
+		}
+		for code := low; code <= high; code++ {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// writeHeapProfile writes a profile of the live heap for "go tool pprof".
+func writeHeapProfile(name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	runtime.GC()
+	return pprof.WriteHeapProfile(file)
+}
+
+// applyFixes repairs the documents of the website in place or prints the repairs as a unified diff.
+func applyFixes(fixes []linkup.Fix, dir string, write bool, stdout io.Writer) error {
+	byDocument := make(map[string][]linkup.Fix)
+	var documents []string
+	for _, fix := range fixes {
+		if _, exists := byDocument[fix.Document]; !exists {
+			documents = append(documents, fix.Document)
+		}
+		byDocument[fix.Document] = append(byDocument[fix.Document], fix)
+	}
+
+	for _, document := range documents {
+		name := filepath.Join(dir, filepath.FromSlash(document))
+		source, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		fixed := linkup.ApplyFixes(source, byDocument[document])
+		if !write {
+			fmt.Fprint(stdout, linkup.Patch(document, source, fixed))
+			continue
+		}
+		if err := ioutil.WriteFile(name, fixed, 0644); err != nil {
+			return err
+		}
+		for _, fix := range byDocument[document] {
+			fmt.Fprintf(stdout, "%s: replaced '%s' with '%s' (%s)\n", document, fix.Old, fix.New, fix.Reason)
+		}
+	}
+	return nil
+}
+
+// findingsMux serves the findings of every page and, if the website is a directory, the website itself,
+// so the findings endpoint and the pages share an origin.
+func findingsMux(w *linkup.Website, errs []error, dir string, static bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/_linkup/findings", w.FindingsHandler(errs))
+	if static {
+		mux.Handle("/", http.FileServer(http.Dir(dir)))
+	}
+	return mux
+}
+
+// newHTTPClient returns a client for external link requests sent through the proxy, if any,
+// that trusts the certificate authorities in the PEM file, if any, besides the system's.
+func newHTTPClient(proxy, caCert string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -proxy: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if caCert != "" {
+		pem, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in '%s'", caCert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// annotateSite copies the website directory to the output directory,
+// highlighting the links that have findings in the copies of their documents.
+func annotateSite(errs []error, dir, output string) error {
+	byDocument := make(map[string][]*linkup.LinkError)
+	for _, err := range errs {
+		var linkErr *linkup.LinkError
+		if errors.As(err, &linkErr) {
+			byDocument[linkErr.Document] = append(byDocument[linkErr.Document], linkErr)
+		}
+	}
+
+	return filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(output, rel)
+		if info.IsDir() {
+			if filepath.Clean(name) == filepath.Clean(output) {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if findings := byDocument[filepath.ToSlash(rel)]; len(findings) > 0 {
+			data = linkup.Annotate(data, findings)
+		}
+		return ioutil.WriteFile(target, data, 0644)
+	})
+}
+
+// readChangedList reads the names of changed files and makes them relative to the website directory.
+// Files outside the website directory are ignored.
+func readChangedList(name, dir string, stdin io.Reader) ([]string, error) {
+	reader := stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var changed []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		rel, err := filepath.Rel(dir, line)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		changed = append(changed, filepath.ToSlash(rel))
+	}
+	return changed, scanner.Err()
+}
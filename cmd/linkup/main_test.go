@@ -0,0 +1,299 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hgs3/linkup"
+)
+
+func TestExitCodeClean(t *testing.T) {
+	verifyExitCode(t, []string{"../../testdata/relative"}, exitClean)
+}
+
+func TestExitCodeBroken(t *testing.T) {
+	verifyExitCode(t, []string{"../../testdata/relative_error"}, exitBroken)
+	verifyExitCode(t, []string{"--fail-on=warning", "../../testdata/relative_error"}, exitBroken)
+	verifyExitCode(t, []string{"--fail-on=never", "../../testdata/relative_error"}, exitClean)
+}
+
+func TestExitCodeInternalError(t *testing.T) {
+	verifyExitCode(t, []string{}, exitInternal)
+	verifyExitCode(t, []string{"--fail-on=sometimes", "../../testdata/relative"}, exitInternal)
+	verifyExitCode(t, []string{"../../testdata/does_not_exist"}, exitInternal)
+}
+
+func TestChangedFiles(t *testing.T) {
+	stdin := strings.NewReader("../../testdata/relative_error/index.html\nREADME.md\n")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-changed", "-", "../../testdata/relative_error"}, stdin, &stdout, &stderr); code != exitBroken {
+		t.Error("Unexpected exit code", code)
+	}
+	if stdout.String() != "index.html: broken relative link 'download/../index.html'\n" {
+		t.Error("Unexpected output", stdout.String())
+	}
+}
+
+func TestGroupedReport(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-report", "grouped", "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr); code != exitBroken {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	expected := "/index.html: error on 2 pages\n" +
+		"\tbroken relative link '../../index.html'\n" +
+		"\tbroken relative link 'download/../index.html'\n" +
+		"\tin blog/index.html\n" +
+		"\tin index.html\n" +
+		"/blog/second-post.html: error on 1 pages\n" +
+		"\tbroken relative link '../blog/second-post.html'\n" +
+		"\tin blog/index.html\n"
+	if stdout.String() != expected {
+		t.Error("Unexpected output", stdout.String())
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	run([]string{"-fingerprints", "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatal("Unexpected output", stdout.String())
+	}
+	for _, line := range lines {
+		if i := strings.LastIndex(line, " ["); i < 0 || len(line)-i != len(" [0123456789abcdef]") {
+			t.Error("Missing fingerprint", line)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	perFinding := filepath.Join(dir, "finding.tmpl")
+	ioutil.WriteFile(perFinding, []byte(`{{define "finding"}}|| {{.Document}} || {{.Href}} || {{.Severity}} ||
+{{end}}{{define "summary"}}{{.Errors}} errors, {{.Warnings}} warnings
+{{end}}`), 0644)
+	whole := filepath.Join(dir, "report.tmpl")
+	ioutil.WriteFile(whole, []byte(`{{range .Groups}}{{.Target}} {{len .Documents}}
+{{end}}`), 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-template", perFinding, "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr); code != exitBroken {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if len(lines) != 5 || lines[3] != "3 errors, 0 warnings" || !strings.Contains(stdout.String(), "|| index.html || download/../index.html || error ||\n") {
+		t.Error("Unexpected output", stdout.String())
+	}
+
+	stdout.Reset()
+	run([]string{"-template", whole, "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr)
+	if stdout.String() != "/index.html 2\n/blog/second-post.html 1\n" {
+		t.Error("Unexpected output", stdout.String())
+	}
+
+	if code := run([]string{"-template", filepath.Join(dir, "missing.tmpl"), "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr); code != exitInternal {
+		t.Error("Unexpected exit code", code)
+	}
+}
+
+func TestTeamCityReport(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-report", "teamcity", "-changed", "-", "../../testdata/relative_error"}, strings.NewReader("../../testdata/relative_error/index.html\n"), &stdout, &stderr); code != exitBroken {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	expected := "##teamcity[inspectionType id='linkup.error' name='Broken links' category='LinkUp' description='Broken links found by LinkUp']\n" +
+		"##teamcity[inspectionType id='linkup.warning' name='Link warnings' category='LinkUp' description='Link warnings found by LinkUp']\n" +
+		"##teamcity[inspectionType id='linkup.info' name='Link notes' category='LinkUp' description='Link notes found by LinkUp']\n" +
+		"##teamcity[inspection typeId='linkup.error' message='broken relative link |'download/../index.html|'' file='index.html' fingerprint='bdbc302416ab0880' SEVERITY='ERROR']\n" +
+		"##teamcity[buildProblem description='LinkUp found 1 broken links' identity='linkup']\n"
+	if stdout.String() != expected {
+		t.Error("Unexpected output", stdout.String())
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-annotate", dir, "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr); code != exitBroken {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	index, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), `<span class="linkup-finding linkup-error" title="broken relative link &#39;download/../index.html&#39;"><a href="download/../index.html">Downloads</a></span>`) {
+		t.Error("Unexpected annotated document", string(index))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blog")); err != nil {
+		t.Error("The website was not copied", err)
+	}
+}
+
+func TestFindingsMux(t *testing.T) {
+	w := linkup.New()
+	if err := w.AddDirectory("../../testdata/relative_error"); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(findingsMux(w, w.Validate(), "../../testdata/relative_error", true))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/_linkup/findings?url=" + server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var page linkup.PageFindings
+	err = json.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if err != nil || page.Document != "index.html" || len(page.Findings) != 1 || page.Findings[0].Href != "download/../index.html" {
+		t.Error("Unexpected findings", page, err)
+	}
+
+	resp, err = http.Get(server.URL + "/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Error("The website is not served", resp.StatusCode)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-positions", "../../testdata/relative_error"}, strings.NewReader(""), &stdout, &stderr); code != exitBroken {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "\nindex.html:8:12: broken relative link 'download/../index.html'\n") &&
+		!strings.HasPrefix(stdout.String(), "index.html:8:12: broken relative link 'download/../index.html'\n") {
+		t.Error("Unexpected output", stdout.String())
+	}
+}
+
+func TestCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	site := filepath.Join(dir, "site")
+	os.Mkdir(site, 0755)
+	ioutil.WriteFile(filepath.Join(site, "index.html"), []byte(`<a href="`+server.URL+`/">Internal</a>`), 0644)
+	ca := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+
+	verifyExitCode(t, []string{site}, exitBroken)
+	verifyExitCode(t, []string{"-ca-cert", ca, site}, exitClean)
+	verifyExitCode(t, []string{"-ca-cert", filepath.Join(site, "index.html"), site}, exitInternal)
+}
+
+func TestStrictRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte(`<a href="`+server.URL+`/moved">Moved</a>`), 0644)
+
+	verifyExitCode(t, []string{dir}, exitClean)
+	verifyExitCode(t, []string{"-max-redirects", "0", dir}, exitBroken)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-strict-redirects", "-fail-on", "warning", dir}, strings.NewReader(""), &stdout, &stderr); code != exitWarnings {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	if expected := "warning: index.html: link '" + server.URL + "/moved' permanently redirects to '" + server.URL + "/new' (update the link)\n"; stdout.String() != expected {
+		t.Error("Unexpected output", stdout.String())
+	}
+}
+
+func TestAcceptStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte(`<a href="`+server.URL+`/found">Found</a>`), 0644)
+
+	verifyExitCode(t, []string{"-max-redirects", "0", dir}, exitBroken)
+	verifyExitCode(t, []string{"-max-redirects", "0", "-accept-status", "200-299,302", dir}, exitClean)
+	verifyExitCode(t, []string{"-accept-status", "299-200", dir}, exitInternal)
+	verifyExitCode(t, []string{"-accept-status", "ok", dir}, exitInternal)
+}
+
+func TestOffline(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-offline", "-unchecked", "../../testdata/external_error"}, strings.NewReader(""), &stdout, &stderr); code != exitClean {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	expected := "info: index.html: unchecked external link 'https://www.google.com/does_not_exist'\n" +
+		"info: index.html: unchecked external link 'https://fake12371ivnd985Vkf8K98Qnm.com/'\n"
+	if stdout.String() != expected {
+		t.Error("Unexpected output", stdout.String())
+	}
+}
+
+func TestFixPatch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-fix=patch", "../../testdata/fix"}, strings.NewReader(""), &stdout, &stderr); code != exitClean {
+		t.Error("Unexpected exit code", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "-  <a href=\"blog\">Blog</a>\n+  <a href=\"blog/\">Blog</a>\n") {
+		t.Error("Unexpected patch", stdout.String())
+	}
+}
+
+func verifyExitCode(t *testing.T, args []string, expected int) {
+	var stdout, stderr bytes.Buffer
+	if code := run(args, strings.NewReader(""), &stdout, &stderr); code != expected {
+		t.Error("Unexpected exit code", args, code, expected)
+	}
+}
@@ -0,0 +1,72 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"sort"
+	"sync"
+)
+
+// WithConcurrency validates documents on the given number of goroutines, which speeds up internal link resolution on large websites.
+// The findings of each document are merged in document name order, so the output is deterministic.
+// External links are still checked one at a time so per-host throttling, retries, and the circuit breaker behave the same.
+// A value of one or less validates documents sequentially, which is the default.
+func WithConcurrency(workers int) Option {
+	return func(w *Website) {
+		w.concurrency = workers
+	}
+}
+
+// validateDocuments validates the documents on the configured number of goroutines and merges their findings in order.
+func validateDocuments(website *Website, documents []*fsEntity) []error {
+	sort.Slice(documents, func(i, j int) bool { return documents[i].fullname < documents[j].fullname })
+
+	results := make([][]error, len(documents))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < website.concurrency && i < len(documents); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results[job] = validateDocument(website, documents[job])
+			}
+		}()
+	}
+	for job := range documents {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	var errors []error
+	for _, result := range results {
+		errors = append(errors, result...)
+	}
+	return errors
+}
+
+// collectFiles returns every file beneath the entity.
+func collectFiles(entity *fsEntity, files []*fsEntity) []*fsEntity {
+	if !entity.directory {
+		return append(files, entity)
+	}
+	for _, child := range entity.children {
+		files = collectFiles(child, files)
+	}
+	return files
+}
@@ -0,0 +1,94 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/base64"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// WithDataURILimit reports data: URIs whose decoded payload exceeds the given number of bytes as warnings,
+// since large inline payloads bloat pages and cannot be cached separately.
+// Data URIs are not checked for size by default.
+func WithDataURILimit(bytes int) Option {
+	return func(w *Website) {
+		w.dataURILimit = bytes
+	}
+}
+
+// isDataURI reports whether the link is a data: URI.
+func isDataURI(href string) bool {
+	return len(href) >= 5 && strings.EqualFold(href[:5], "data:")
+}
+
+// checkDataURI verifies the media type and payload of a data: URI.
+// The raw link is checked because unescaping it would corrupt base64 payloads.
+func checkDataURI(website *Website, entity *fsEntity, raw string) *LinkError {
+	uri := strings.TrimSpace(raw)
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 {
+		return website.newLinkError(entity, raw, KindMalformed, "malformed data URI (missing comma)")
+	}
+
+	header := uri[5:comma]
+	payload := uri[comma+1:]
+	encoded := false
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		encoded = true
+		header = header[:len(header)-len(";base64")]
+	}
+
+	if len(header) > 0 {
+		mediaType := header
+		if strings.HasPrefix(mediaType, ";") {
+			// Parameters without a media type apply to the default of text/plain.
+			mediaType = "text/plain" + mediaType
+		}
+		if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+			return website.newLinkError(entity, raw, KindMalformed, "data URI has an invalid media type '%s'", header)
+		}
+	}
+
+	var size int
+	if encoded {
+		// Whitespace is permitted within base64 payloads.
+		payload = strings.Join(strings.Fields(payload), "")
+		if unescaped, err := url.PathUnescape(payload); err == nil {
+			payload = unescaped
+		}
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			if data, err = base64.RawStdEncoding.DecodeString(payload); err != nil {
+				return website.newLinkError(entity, raw, KindMalformed, "data URI has an undecodable base64 payload")
+			}
+		}
+		size = len(data)
+	} else {
+		unescaped, err := url.PathUnescape(payload)
+		if err != nil {
+			return website.newLinkError(entity, raw, KindMalformed, "data URI has an invalid percent-encoded payload")
+		}
+		size = len(unescaped)
+	}
+
+	if website.dataURILimit > 0 && size > website.dataURILimit {
+		return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "data URI payload is %d bytes (the limit is %d bytes)", size, website.dataURILimit)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// s3Listing matches a line of "aws s3 ls --recursive" output: the date, time, size, and key.
+	s3Listing = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\s+\d+\s+(.+)$`)
+
+	// rsyncListing matches a line of "rsync --list-only" output: the mode, size, date, time, and path.
+	rsyncListing = regexp.MustCompile(`^([-dlpscbD])[-rwxsStT]{9}\s+[\d,.]+\s+\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} (.+)$`)
+)
+
+// WithDeployment cross-checks internal links against the files that were actually deployed.
+// Links to files that are registered locally but absent from the deployment are reported as broken,
+// which catches files excluded by an upload filter, a .gitignore, or a failed sync.
+// File names are relative to the root of the domain.
+func WithDeployment(files []string) Option {
+	return func(w *Website) {
+		w.deployed = make(map[string]bool, len(files))
+		for _, file := range files {
+			w.deployed[prepareFileName(file)] = true
+		}
+	}
+}
+
+// LoadDeployment reads a manifest of the deployed files, one per line.
+// Lines may be plain paths, like the output of "find" or a GitHub Pages artifact listing,
+// or the output of "aws s3 ls --recursive" or "rsync --list-only", in which case directories are skipped.
+// A leading "./" is removed from each path and blank lines are ignored.
+func LoadDeployment(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if match := s3Listing.FindStringSubmatch(line); match != nil {
+			line = match[1]
+		} else if match := rsyncListing.FindStringSubmatch(line); match != nil {
+			if match[1] != "-" {
+				continue
+			}
+			line = match[2]
+		} else {
+			line = strings.TrimSpace(line)
+		}
+		line = strings.TrimPrefix(line, "./")
+		if len(line) == 0 || strings.HasSuffix(line, "/") {
+			continue
+		}
+		files = append(files, line)
+	}
+	return files, scanner.Err()
+}
+
+// isDeployed reports whether the file is part of the deployment, which is always true if no deployment manifest was given.
+func (w *Website) isDeployed(entity *fsEntity) bool {
+	return w.deployed == nil || w.deployed[entity.fullname]
+}
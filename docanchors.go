@@ -0,0 +1,56 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+// DefaultDocPatterns match anchored links into popular API documentation,
+// whose anchors name documented symbols and silently disappear when the symbols are renamed or removed.
+var DefaultDocPatterns = []string{
+	"https://pkg.go.dev/*#*",
+	"https://docs.python.org/*#*",
+	"https://docs.rs/*#*",
+	"https://doc.rust-lang.org/*#*",
+	"https://developer.mozilla.org/*#*",
+	"https://nodejs.org/api/*#*",
+	"https://docs.oracle.com/*#*",
+}
+
+// WithDocAnchors verifies the anchored links into external documentation matching the glob patterns still name an element of the page,
+// such as https://pkg.go.dev/net/http#NewRequest, and reports the symbols that are no longer documented.
+// Every documentation page is fetched once no matter how many of its symbols are linked.
+// If no patterns are given, DefaultDocPatterns is used.
+// The patterns take precedence over WithCheckLevel rules given after this option.
+func WithDocAnchors(patterns ...string) Option {
+	if len(patterns) == 0 {
+		patterns = DefaultDocPatterns
+	}
+	return func(w *Website) {
+		for _, pattern := range patterns {
+			w.checkLevels = append(w.checkLevels, checkLevelRule{pattern, CheckAnchor})
+			w.docPatterns = append(w.docPatterns, pattern)
+		}
+	}
+}
+
+// isDocLink reports whether the link points into external documentation configured with WithDocAnchors.
+func (w *Website) isDocLink(link string) bool {
+	for _, pattern := range w.docPatterns {
+		if globMatch(pattern, link) {
+			return true
+		}
+	}
+	return false
+}
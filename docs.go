@@ -0,0 +1,106 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+)
+
+// docusaurusSetting matches a top-level string setting of a Docusaurus configuration, such as baseUrl: '/docs/'.
+var docusaurusSetting = regexp.MustCompile(`(?m)^\s*(url|baseUrl)\s*:\s*['"` + "`" + `]([^'"` + "`" + `]*)['"` + "`" + `]`)
+
+// MkDocsPreset configures LinkUp for a website built with MkDocs.
+// The site_dir, which defaults to site/, is validated, the site_url sets the site URL and base path,
+// and heading ids are inferred with MkDocsSlugs.
+func MkDocsPreset(dir string) (*Preset, error) {
+	project, config := findProject(dir, "mkdocs.yml", "mkdocs.yaml")
+	if len(config) == 0 {
+		return nil, fmt.Errorf("no MkDocs configuration found in '%s' or its parent directory", dir)
+	}
+	settings, err := readSettings(config)
+	if err != nil {
+		return nil, err
+	}
+	preset := &Preset{
+		Dir:     outputDir(dir, project, setting(settings, "site_dir", "site")),
+		Options: []Option{WithHeadingSlugs(MkDocsSlugs)},
+	}
+	preset.Options = append(preset.Options, siteOptions(setting(settings, "site_url", ""))...)
+	return preset, nil
+}
+
+// MdBookPreset configures LinkUp for a book built with mdBook.
+// The build-dir, which defaults to book/, is validated, the site-url of the HTML renderer sets the base path,
+// and heading ids are inferred with GitHubSlugs, which follow the same rules.
+func MdBookPreset(dir string) (*Preset, error) {
+	project, config := findProject(dir, "book.toml")
+	if len(config) == 0 {
+		return nil, fmt.Errorf("no mdBook configuration found in '%s' or its parent directory", dir)
+	}
+	settings, err := readSettings(config)
+	if err != nil {
+		return nil, err
+	}
+	preset := &Preset{
+		Dir:     outputDir(dir, project, setting(settings, "build.build-dir", "book")),
+		Options: []Option{WithHeadingSlugs(GitHubSlugs)},
+	}
+	preset.Options = append(preset.Options, WithBasePath(setting(settings, "output.html.site-url", "/")))
+	return preset, nil
+}
+
+// DocusaurusPreset configures LinkUp for a website built with Docusaurus.
+// The build/ directory is validated, the url and baseUrl settings set the site URL and base path,
+// and heading ids are inferred with GitHubSlugs, which follow the rules of github-slugger.
+// Only string literals in the configuration are understood.
+func DocusaurusPreset(dir string) (*Preset, error) {
+	project, config := findProject(dir, "docusaurus.config.js", "docusaurus.config.ts", "docusaurus.config.mjs")
+	if len(config) == 0 {
+		return nil, fmt.Errorf("no Docusaurus configuration found in '%s' or its parent directory", dir)
+	}
+	data, err := ioutil.ReadFile(config)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	for _, match := range docusaurusSetting.FindAllStringSubmatch(string(data), -1) {
+		if _, exists := settings[match[1]]; !exists {
+			settings[match[1]] = match[2]
+		}
+	}
+	preset := &Preset{
+		Dir:     outputDir(dir, project, "build"),
+		Options: []Option{WithHeadingSlugs(GitHubSlugs)},
+	}
+	if site := settings["url"]; len(site) > 0 {
+		preset.Options = append(preset.Options, WithSiteURL(site+settings["baseUrl"]))
+	}
+	preset.Options = append(preset.Options, WithBasePath(settings["baseUrl"]))
+	return preset, nil
+}
+
+// siteOptions sets the site URL and the base path from the URL the website is published at.
+func siteOptions(site string) []Option {
+	u, err := url.Parse(site)
+	if len(site) == 0 || err != nil {
+		return nil
+	}
+	return []Option{WithSiteURL(site), WithBasePath(u.Path)}
+}
@@ -0,0 +1,86 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DocVersionPolicy requires links to versioned documentation to either pin a version or follow the latest one.
+type DocVersionPolicy struct {
+	// Pattern is a glob pattern selecting the links the policy applies to, such as "https://docs.example.com/*".
+	// In the pattern "*" matches any run of characters, including slashes, and "?" matches a single character.
+	Pattern string
+
+	// Latest is the path segment naming the latest version, such as "latest", "stable", or "current".
+	Latest string
+
+	// Pinned is the version suggested for links to the latest version, such as "v2.4".
+	// If empty, no specific version is suggested.
+	Pinned string
+
+	// PreferLatest reverses the policy: links to a pinned version are reported instead and Latest is suggested,
+	// for teams that want their links to follow the documentation as it evolves.
+	PreferLatest bool
+}
+
+// versionSegment matches path segments naming a version, such as "3.11", "v2", or "1.2.x".
+var versionSegment = regexp.MustCompile(`^v?\d+(\.(\d+|x))*$`)
+
+// WithDocVersionPolicy warns about links to versioned documentation that do not follow the policy.
+// Policies are tried in the order they are given and the first whose pattern matches the link wins.
+func WithDocVersionPolicy(policy DocVersionPolicy) Option {
+	return func(w *Website) {
+		w.docVersions = append(w.docVersions, policy)
+	}
+}
+
+// checkDocVersion returns a warning if the external link does not follow the documentation version policy.
+func checkDocVersion(website *Website, entity *fsEntity, raw, href string) *LinkError {
+	for _, policy := range website.docVersions {
+		if !globMatch(policy.Pattern, href) {
+			continue
+		}
+		scheme := strings.Index(href, "://")
+		if scheme < 0 {
+			return nil
+		}
+		path := scheme + 3 + strings.IndexByte(href[scheme+3:]+"/", '/')
+		segments := strings.Split(href[path:], "/")
+		for i, segment := range segments {
+			if i := strings.IndexAny(segment, "?#"); i >= 0 {
+				segment = segment[:i]
+			}
+			if !policy.PreferLatest && segment == policy.Latest {
+				if len(policy.Pinned) == 0 {
+					return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "link '%s' follows the latest documentation (pin it to a version)", href)
+				}
+				segments[i] = policy.Pinned + segments[i][len(segment):]
+				return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "link '%s' follows the latest documentation (pin it as '%s')",
+					href, href[:path]+strings.Join(segments, "/"))
+			}
+			if policy.PreferLatest && segment != policy.Latest && versionSegment.MatchString(segment) {
+				segments[i] = policy.Latest + segments[i][len(segment):]
+				return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "link '%s' is pinned to version '%s' (follow the latest as '%s')",
+					href, segment, href[:path]+strings.Join(segments, "/"))
+			}
+		}
+		return nil
+	}
+	return nil
+}
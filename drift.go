@@ -0,0 +1,94 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// contentBytes is the amount of page text, following the title, that the content hash covers.
+const contentBytes = 4096
+
+// driftDistance is the number of differing bits, out of 64, beyond which content is considered to have drifted.
+// Edits to a page change a few bits of its hash while unrelated content differs in about half of them.
+const driftDistance = 16
+
+// WithContentDrift warns about external pages whose content changed substantially since the previous run,
+// signaling that a previously good reference may now point at different content, such as a parked domain.
+// The title and start of the text of every page are hashed so similar content has similar hashes and
+// the hashes are remembered in the history given with WithHistory, which is required.
+// Links that would be checked with CheckHead are fetched with CheckGet instead so their content can be hashed.
+func WithContentDrift(enabled bool) Option {
+	return func(w *Website) {
+		w.contentDrift = enabled
+	}
+}
+
+// contentHash returns a similarity hash of the title and the start of the visible text of an HTML page,
+// where pages sharing most of their words have hashes differing in few bits.
+// It returns zero if the page has no text.
+func contentHash(doc *goquery.Document, title string) uint64 {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+	text := body.Text()
+	if len(text) > contentBytes {
+		text = text[:contentBytes]
+	}
+
+	var weights [64]int
+	words := strings.Fields(strings.ToLower(title + " " + text))
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		for bit := range weights {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	if len(words) == 0 {
+		return 0
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
+
+// drifted reports whether the content of the link differs substantially from the content remembered by the previous run.
+func (h *History) drifted(link string, hash uint64) bool {
+	if h == nil || hash == 0 {
+		return false
+	}
+	previous, err := strconv.ParseUint(h.Content[link], 16, 64)
+	if err != nil || previous == 0 {
+		return false
+	}
+	return bits.OnesCount64(previous^hash) > driftDistance
+}
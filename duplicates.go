@@ -0,0 +1,85 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// WithDuplicateDetection warns about documents whose content is identical to another document,
+// which are usually accidental copies reachable at several paths rather than redirects.
+// The copy with the shortest URL is kept and the others are reported with a suggestion to redirect them to it.
+// Documents that redirect with a meta refresh are ignored since identical redirect stubs are expected.
+func WithDuplicateDetection(enabled bool) Option {
+	return func(w *Website) {
+		w.duplicateCheck = enabled
+	}
+}
+
+// findDuplicates groups the documents by content and maps every copy to the document that is kept.
+func (w *Website) findDuplicates() map[*fsEntity]*fsEntity {
+	byHash := make(map[string][]*fsEntity)
+	forEachDocument(w.root, func(entity *fsEntity) {
+		if len(entity.contentHash) > 0 && !entity.refresh {
+			byHash[entity.contentHash] = append(byHash[entity.contentHash], entity)
+		}
+	})
+
+	duplicates := make(map[*fsEntity]*fsEntity)
+	for _, copies := range byHash {
+		if len(copies) < 2 {
+			continue
+		}
+		sort.Slice(copies, func(i, j int) bool {
+			a, b := pageURL(copies[i].fullname), pageURL(copies[j].fullname)
+			if len(a) != len(b) {
+				return len(a) < len(b)
+			}
+			return a < b
+		})
+		for _, copy := range copies[1:] {
+			duplicates[copy] = copies[0]
+		}
+	}
+	return duplicates
+}
+
+// validateDuplicate warns if the document is a copy of another document.
+func validateDuplicate(website *Website, entity *fsEntity) []error {
+	original, exists := website.duplicates[entity]
+	if !exists {
+		return nil
+	}
+	return []error{website.newFinding(entity, "", SeverityWarning, KindDocument,
+		"document has the same content as '%s' (keep one copy and redirect '%s' to '%s')",
+		original.fullname, pageURL(entity.fullname), pageURL(original.fullname))}
+}
+
+// pageURL returns the path a document is served at, relative to the root of the domain.
+func pageURL(name string) string {
+	key := redirectKey(name)
+	if key != strings.Trim(path.Clean("/"+name), "/") {
+		// The document is a directory index.
+		if len(key) == 0 {
+			return "/"
+		}
+		return "/" + key + "/"
+	}
+	return "/" + key
+}
@@ -0,0 +1,129 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithEmailTemplates validates the documents as HTML email templates rather than web pages.
+// Emails have no base URL, so relative links are broken, and most email clients ignore links to fragments.
+// Images referenced with cid: URLs must name the Content-ID of one of the attachments, given without angle brackets.
+// Tracking pixels, images of at most one pixel, must be served over https as an image.
+// Links with the mailto:, tel:, and sms: schemes are accepted.
+func WithEmailTemplates(attachments ...string) Option {
+	return func(w *Website) {
+		w.emailMode = true
+		for _, attachment := range attachments {
+			w.attachments = append(w.attachments, strings.Trim(attachment, "<>"))
+		}
+	}
+}
+
+// LoadAttachmentManifest reads the Content-IDs of the attachments of an email from the named file, one per line.
+// Blank lines and lines starting with # are ignored.
+func LoadAttachmentManifest(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var attachments []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			attachments = append(attachments, line)
+		}
+	}
+	return attachments, scanner.Err()
+}
+
+// emailSchemes are the schemes of links that open another application rather than a page.
+var emailSchemes = []string{"mailto:", "tel:", "sms:"}
+
+// checkEmailLink validates a link of an email template.
+// It returns false if the link is not specific to email and must be checked like any other link.
+func checkEmailLink(website *Website, entity *fsEntity, raw, href string) (*LinkError, bool) {
+	lower := strings.ToLower(href)
+	for _, scheme := range emailSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return nil, true
+		}
+	}
+	switch {
+	case strings.HasPrefix(lower, "cid:"):
+		id := strings.Trim(href[len("cid:"):], "<>")
+		if !containsString(website.attachments, id) {
+			return website.newLinkError(entity, raw, KindBroken, "broken link '%s' (no attachment has the Content-ID '%s')", href, id), true
+		}
+		return nil, true
+	case website.isExternal(href) || isDataURI(href):
+		return nil, false
+	case strings.HasPrefix(href, "#"):
+		return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "anchor link '%s' is ignored by most email clients", href), true
+	case len(href) > 0 && !strings.Contains(href, ":"):
+		return website.newLinkError(entity, raw, KindBroken, "broken link '%s' (links in emails must be absolute URLs)", href), true
+	}
+	return nil, false
+}
+
+// recordPixel remembers the image if it is a tracking pixel.
+func recordPixel(entity *fsEntity, s *goquery.Selection) {
+	src, exists := s.Attr("src")
+	width, widthErr := strconv.Atoi(strings.TrimSuffix(s.AttrOr("width", ""), "px"))
+	height, heightErr := strconv.Atoi(strings.TrimSuffix(s.AttrOr("height", ""), "px"))
+	if exists && widthErr == nil && heightErr == nil && width <= 1 && height <= 1 {
+		entity.pixels = append(entity.pixels, src)
+	}
+}
+
+// validatePixels verifies the tracking pixels of an email template are served over https as images.
+// Pixels that are unreachable are reported with the other external links.
+func validatePixels(website *Website, entity *fsEntity) []error {
+	if !website.emailMode {
+		return nil
+	}
+
+	var errors []error
+	for _, pixel := range entity.pixels {
+		href := sanitizeHref(pixel)
+		if !strings.HasPrefix(strings.ToLower(href), "https://") {
+			if website.isExternal(href) {
+				errors = append(errors, website.newFinding(entity, pixel, SeverityWarning, KindPolicy, "tracking pixel '%s' is not served over https (email clients block it)", href))
+			}
+			continue
+		}
+		if !website.externalChecks {
+			continue
+		}
+
+		website.externalMu.Lock()
+		result := ping(website, website.normalization.Normalize(href))
+		website.externalMu.Unlock()
+		if result != nil && website.isSuccess(result) && len(result.ContentType) > 0 && !strings.HasPrefix(strings.ToLower(result.ContentType), "image/") {
+			errors = append(errors, website.newFinding(entity, pixel, SeverityWarning, KindExternal, "tracking pixel '%s' responds with '%s' instead of an image", href, result.ContentType))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,347 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// epubPackage is the package document of an EPUB registered with AddEPUB.
+type epubPackage struct {
+	opf      string
+	manifest []epubItem
+	spine    []string
+	toc      string
+	files    []string
+	ncx      string
+	ncxLinks []string
+	nav      string
+	navLinks []string
+}
+
+// epubItem is an item of the manifest of an EPUB package document.
+type epubItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// epubContainer is the META-INF/container.xml file naming the package document of an EPUB.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackageDocument is the OPF package document of an EPUB.
+type epubPackageDocument struct {
+	Manifest []epubItem `xml:"manifest>item"`
+	Spine    struct {
+		TOC      string `xml:"toc,attr"`
+		Itemrefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// AddEPUB registers the content documents and assets of an EPUB publication without extracting it.
+// The root of the EPUB container is treated as the root of the domain, so cross-references between its documents
+// are validated like the links of a website. Additionally, the manifest of the package document must list every file,
+// its spine must only name manifest items, and the entries of its NCX and navigation document tables of contents
+// must lead to documents in the spine.
+func (w *Website) AddEPUB(name string) error {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, entry := range archive.File {
+		if !entry.FileInfo().IsDir() {
+			entries[archiveName(entry.Name)] = entry
+		}
+	}
+
+	var container epubContainer
+	if err := decodeZipXML(entries["META-INF/container.xml"], &container); err != nil {
+		return fmt.Errorf("'%s' is not an EPUB: %v", name, err)
+	}
+	pkg := &epubPackage{}
+	for _, rootfile := range container.Rootfiles {
+		if rootfile.MediaType == "application/oebps-package+xml" || len(rootfile.MediaType) == 0 {
+			pkg.opf = archiveName(rootfile.FullPath)
+			break
+		}
+	}
+	var opf epubPackageDocument
+	if err := decodeZipXML(entries[pkg.opf], &opf); err != nil {
+		return fmt.Errorf("'%s' has no package document: %v", name, err)
+	}
+	pkg.manifest = opf.Manifest
+	pkg.toc = opf.Spine.TOC
+	for _, itemref := range opf.Spine.Itemrefs {
+		pkg.spine = append(pkg.spine, itemref.IDRef)
+	}
+
+	documents := make(map[string]bool)
+	for _, item := range pkg.manifest {
+		name := pkg.itemName(item)
+		switch {
+		case item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html":
+			documents[name] = true
+			if hasToken(item.Properties, "nav") {
+				pkg.nav = name
+			}
+		case item.MediaType == "application/x-dtbncx+xml" && (len(pkg.toc) == 0 || item.ID == pkg.toc):
+			pkg.ncx = name
+		}
+	}
+
+	for name, entry := range entries {
+		pkg.files = append(pkg.files, name)
+		if !documents[name] && !isDocumentName(name) {
+			if name == pkg.ncx {
+				if pkg.ncxLinks, err = ncxLinks(entry); err != nil {
+					return err
+				}
+			}
+			if err := w.AddFile(name); err != nil {
+				return err
+			}
+			continue
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		document, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		if name == pkg.nav {
+			pkg.navLinks = navLinks(document)
+		}
+		if err := w.AddDocumentFromReader(name, bytes.NewReader(document)); err != nil {
+			return err
+		}
+	}
+	sort.Strings(pkg.files)
+
+	w.epubs = append(w.epubs, pkg)
+	return nil
+}
+
+// decodeZipXML decodes the XML file of the archive into v.
+func decodeZipXML(entry *zip.File, v interface{}) error {
+	if entry == nil {
+		return fmt.Errorf("file does not exist")
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(v)
+}
+
+// ncxLinks returns the targets of the navigation points of an NCX table of contents.
+func ncxLinks(entry *zip.File) ([]string, error) {
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var links []string
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return links, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "content" {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "src" {
+					links = append(links, attr.Value)
+				}
+			}
+		}
+	}
+}
+
+// navLinks returns the links of the table of contents of an EPUB navigation document.
+func navLinks(document []byte) []string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(document))
+	if err != nil {
+		return nil
+	}
+	var links []string
+	doc.Find("nav").Each(func(i int, nav *goquery.Selection) {
+		if !hasToken(nav.AttrOr("epub:type", ""), "toc") {
+			return
+		}
+		nav.Find("a[href]").Each(func(i int, a *goquery.Selection) {
+			links = append(links, a.AttrOr("href", ""))
+		})
+	})
+	return links
+}
+
+// hasToken reports whether the space-separated list contains the token.
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+// itemName returns the name of the file the manifest item refers to, relative to the root of the container.
+func (pkg *epubPackage) itemName(item epubItem) string {
+	href := item.Href
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Join(path.Dir(pkg.opf), href)
+}
+
+// item returns the manifest item with the id.
+func (pkg *epubPackage) item(id string) (epubItem, bool) {
+	for _, item := range pkg.manifest {
+		if item.ID == id {
+			return item, true
+		}
+	}
+	return epubItem{}, false
+}
+
+// inSpine reports whether the document is part of the reading order of the publication.
+func (pkg *epubPackage) inSpine(entity *fsEntity) bool {
+	for _, id := range pkg.spine {
+		if item, exists := pkg.item(id); exists && pkg.itemName(item) == entity.fullname {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEPUB verifies the package document and tables of contents of the EPUBs the entity belongs to.
+func validateEPUB(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, pkg := range website.epubs {
+		switch entity.fullname {
+		case pkg.opf:
+			errors = append(errors, pkg.validatePackage(website, entity)...)
+		case pkg.ncx:
+			errors = append(errors, pkg.validateTOC(website, entity, pkg.ncxLinks, true)...)
+		case pkg.nav:
+			// The links of the navigation document are checked like any other, so only the spine is verified.
+			errors = append(errors, pkg.validateTOC(website, entity, pkg.navLinks, false)...)
+		}
+	}
+	return errors
+}
+
+// validatePackage verifies the manifest and spine of the package document are consistent with the container.
+func (pkg *epubPackage) validatePackage(website *Website, entity *fsEntity) []error {
+	var errors []error
+	listed := make(map[string]bool)
+	for _, item := range pkg.manifest {
+		name := pkg.itemName(item)
+		listed[name] = true
+		if i := sort.SearchStrings(pkg.files, name); i == len(pkg.files) || pkg.files[i] != name {
+			errors = append(errors, website.newLinkError(entity, item.Href, KindBroken, "broken manifest item '%s' (the file does not exist)", item.Href))
+		}
+	}
+	for _, name := range pkg.files {
+		if !listed[name] && name != pkg.opf && name != "mimetype" && !strings.HasPrefix(name, "META-INF/") {
+			errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "file '%s' is missing from the package manifest", name))
+		}
+	}
+
+	if len(pkg.spine) == 0 {
+		errors = append(errors, website.newLinkError(entity, "", KindDocument, "package has an empty spine"))
+	}
+	spine := make(map[string]bool)
+	for _, id := range pkg.spine {
+		item, exists := pkg.item(id)
+		switch {
+		case !exists:
+			errors = append(errors, website.newLinkError(entity, "", KindBroken, "broken spine item '%s' (no manifest item has that id)", id))
+		case item.MediaType != "application/xhtml+xml":
+			errors = append(errors, website.newFinding(entity, item.Href, SeverityWarning, KindPolicy, "spine item '%s' is not an XHTML content document", item.Href))
+		}
+		spine[id] = true
+	}
+	for _, item := range pkg.manifest {
+		if item.MediaType == "application/xhtml+xml" && !spine[item.ID] && pkg.itemName(item) != pkg.nav {
+			errors = append(errors, website.newFinding(entity, item.Href, SeverityWarning, KindPolicy, "content document '%s' is not in the spine", item.Href))
+		}
+	}
+
+	if len(pkg.toc) > 0 {
+		if _, exists := pkg.item(pkg.toc); !exists {
+			errors = append(errors, website.newLinkError(entity, "", KindBroken, "broken spine toc '%s' (no manifest item has that id)", pkg.toc))
+		}
+	}
+	if len(pkg.nav) == 0 && len(pkg.ncx) == 0 {
+		errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "package has no navigation document or NCX table of contents"))
+	}
+	return errors
+}
+
+// validateTOC verifies the entries of a table of contents lead to documents in the spine.
+// The existence of their targets is only verified if resolve is set.
+func (pkg *epubPackage) validateTOC(website *Website, entity *fsEntity, links []string, resolve bool) []error {
+	var errors []error
+	for _, href := range links {
+		target := website.resolveInternal(entity, href)
+		fragment := ""
+		if i := strings.IndexByte(href, '#'); i >= 0 {
+			fragment = href[i+1:]
+		}
+		switch {
+		case target == nil && resolve:
+			errors = append(errors, website.newLinkError(entity, href, KindBroken, "broken table of contents link '%s'", href))
+		case target == nil:
+		case resolve && len(fragment) > 0 && target.ids[fragment] == 0:
+			errors = append(errors, website.newLinkError(entity, href, KindAnchor, "broken target link '%s'", href))
+		case !pkg.inSpine(target):
+			errors = append(errors, website.newFinding(entity, href, SeverityWarning, KindPolicy, "table of contents link '%s' leads outside the spine", href))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,242 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Severity indicates how serious a LinkError is.
+type Severity int
+
+const (
+	// SeverityError is used for broken links and other problems that must be fixed.
+	SeverityError Severity = iota
+
+	// SeverityWarning is used for problems that do not break the website but should be reviewed.
+	SeverityWarning
+
+	// SeverityInfo is used for informational notes, such as links that were not checked.
+	SeverityInfo
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return "unknown"
+}
+
+// Kind classifies the problem a LinkError describes, so tools can filter findings without parsing their messages.
+type Kind int
+
+const (
+	// KindPolicy is used for links and documents that work but break a convention, such as a link style or rel policy.
+	KindPolicy Kind = iota
+
+	// KindBroken is used for internal links whose target does not exist or is not served.
+	KindBroken
+
+	// KindAnchor is used for links whose fragment names no element of the target page.
+	KindAnchor
+
+	// KindExternal is used for external links that failed or changed when they were checked.
+	KindExternal
+
+	// KindMalformed is used for links that cannot be parsed, or are empty.
+	KindMalformed
+
+	// KindRedirect is used for links that go through redirects.
+	KindRedirect
+
+	// KindUnchecked is used for links that were not checked.
+	KindUnchecked
+
+	// KindDocument is used for problems of the document itself rather than a specific link.
+	KindDocument
+)
+
+// String returns the lowercase name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindPolicy:
+		return "policy"
+	case KindBroken:
+		return "broken"
+	case KindAnchor:
+		return "anchor"
+	case KindExternal:
+		return "external"
+	case KindMalformed:
+		return "malformed"
+	case KindRedirect:
+		return "redirect"
+	case KindUnchecked:
+		return "unchecked"
+	case KindDocument:
+		return "document"
+	}
+	return "unknown"
+}
+
+// ParseKind returns the kind with the lowercase name returned by Kind.String.
+// It returns false if no kind has the name.
+func ParseKind(name string) (Kind, bool) {
+	for kind := KindPolicy; kind <= KindDocument; kind++ {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+// LinkError describes a broken link or other problem detected on a web page.
+// Every error returned by Validate and ValidateChanged is a *LinkError; Findings and ChangedFindings return them typed.
+type LinkError struct {
+	// Document is the name of the web page the problem was detected on.
+	Document string
+
+	// Source is the name of the source file the web page was generated from, such as a Markdown file or template.
+	// It is empty unless a source map was given with WithSourceMap or WithSourceDir.
+	Source string
+
+	// Href is the link as it appears in the document.
+	// It is empty if the problem is not caused by a specific link.
+	Href string
+
+	// Target is what the link resolves to: the normalized URL of an external link,
+	// or the path of an internal link relative to the root of the domain, such as "/docs/setup/#install".
+	// It is empty if the problem is not caused by a specific link.
+	Target string
+
+	// Kind classifies the problem.
+	Kind Kind
+
+	// Line and Column locate the link in the document, counting from 1.
+	// They are zero if the position is unknown; links are only located with WithLinePositions.
+	Line   int
+	Column int
+
+	// StatusCode is the HTTP status code received when checking an external link, or zero.
+	StatusCode int
+
+	// Message describes the problem.
+	Message string
+
+	// Severity indicates whether the problem is an error or a warning.
+	Severity Severity
+
+	// External is the result of checking the link if it is an external link.
+	External *ExternalResult
+
+	// Fingerprint identifies the finding across runs so it can be baselined or suppressed.
+	// It is derived from the document, the normalized link, and the rule that reported the problem,
+	// identified by its untranslated message format, so it does not change when the link moves within the document
+	// or the message is translated with WithCatalog. Findings not caused by a link also include their subject,
+	// the text arguments of the message such as a duplicated id or a heading, but not numbers such as line numbers.
+	Fingerprint string
+}
+
+// Error returns the problem formatted as "document: message".
+func (e *LinkError) Error() string {
+	return e.Document + ": " + e.Message
+}
+
+func (w *Website) newLinkError(entity *fsEntity, href string, kind Kind, format string, args ...interface{}) *LinkError {
+	return w.newFinding(entity, href, SeverityError, kind, format, args...)
+}
+
+func (w *Website) newFinding(entity *fsEntity, href string, severity Severity, kind Kind, format string, args ...interface{}) *LinkError {
+	return w.report(w.finding(entity, href, severity, kind, format, args...))
+}
+
+// finding creates a finding without reporting it, so details can be attached first.
+func (w *Website) finding(entity *fsEntity, href string, severity Severity, kind Kind, format string, args ...interface{}) *LinkError {
+	err := &LinkError{
+		Document: entity.fullname,
+		Source:   w.source(entity),
+		Href:     href,
+		Kind:     kind,
+		Message:  fmt.Sprintf(w.catalog.translate(format), args...),
+		Severity: severity,
+	}
+	err.Fingerprint = findingFingerprint(err.Document, w.normalization.Normalize(sanitizeHref(href)), format, args)
+	if len(href) > 0 {
+		if w.isExternal(href) {
+			err.Target = w.normalization.Normalize(href)
+		} else {
+			err.Target = findingTarget(err)
+		}
+	}
+	if position, exists := entity.positions[href]; exists && len(href) > 0 {
+		err.Line, err.Column = position.line, position.column
+	}
+	return err
+}
+
+// LinkErrors returns the findings among the errors returned by Validate and ValidateChanged,
+// so they can be filtered and reported programmatically without a type assertion per error.
+func LinkErrors(errs []error) []*LinkError {
+	findings := make([]*LinkError, 0, len(errs))
+	for _, err := range errs {
+		var linkErr *LinkError
+		if errors.As(err, &linkErr) {
+			findings = append(findings, linkErr)
+		}
+	}
+	return findings
+}
+
+// report applies the severity overrides to the finding and logs it.
+func (w *Website) report(err *LinkError) *LinkError {
+	w.overrideSeverity(err)
+	w.events.finding(err)
+	return err
+}
+
+// findingFingerprint identifies a finding by its document, link, and rule.
+// Findings not caused by a link are told apart by the text arguments of their message.
+func findingFingerprint(document, href, format string, args []interface{}) string {
+	parts := []string{document, href, format}
+	if len(href) == 0 {
+		for _, arg := range args {
+			if subject, ok := arg.(string); ok {
+				parts = append(parts, subject)
+			}
+		}
+	}
+	return fingerprint(parts...)
+}
+
+// fingerprint hashes the parts identifying a finding into a short hexadecimal string.
+func fingerprint(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
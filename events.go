@@ -0,0 +1,100 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type event struct {
+	Time        string `json:"time"`
+	Event       string `json:"event"`
+	Document    string `json:"document,omitempty"`
+	Href        string `json:"href,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Target      string `json:"target,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Column      int    `json:"column,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	Count       int    `json:"count,omitempty"`
+}
+
+// eventLog writes lifecycle events and findings as JSON lines.
+// A nil eventLog discards all events.
+type eventLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	failed  bool
+}
+
+// WithEventLog writes each finding and lifecycle event to writer as a JSON line as soon as it happens.
+// Every line is an object with a "time" and an "event" field where the event is one of
+// "document", "file", "validate_start", "ping", "finding", or "validate_end".
+func WithEventLog(writer io.Writer) Option {
+	return func(w *Website) {
+		if writer != nil {
+			w.events = &eventLog{encoder: json.NewEncoder(writer)}
+		}
+	}
+}
+
+func (l *eventLog) emit(e event) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failed {
+		return
+	}
+	e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	// Stop logging if the writer breaks rather than failing validation.
+	if err := l.encoder.Encode(&e); err != nil {
+		l.failed = true
+	}
+}
+
+func (l *eventLog) document(name string) {
+	l.emit(event{Event: "document", Document: name})
+}
+
+func (l *eventLog) file(name string) {
+	l.emit(event{Event: "file", Document: name})
+}
+
+func (l *eventLog) validateStart() {
+	l.emit(event{Event: "validate_start"})
+}
+
+func (l *eventLog) ping(url string, status int) {
+	l.emit(event{Event: "ping", Href: url, Status: status})
+}
+
+func (l *eventLog) finding(err *LinkError) {
+	l.emit(event{Event: "finding", Document: err.Document, Href: err.Href, Message: err.Message, Severity: err.Severity.String(),
+		Fingerprint: err.Fingerprint, Kind: err.Kind.String(), Target: err.Target, Line: err.Line, Column: err.Column, Status: err.StatusCode})
+}
+
+func (l *eventLog) validateEnd(count int) {
+	l.emit(event{Event: "validate_end", Count: count})
+}
@@ -0,0 +1,534 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ExternalResult describes the outcome of checking an external link.
+type ExternalResult struct {
+	// URL is the link that was checked.
+	URL string
+
+	// FinalURL is the URL of the final response after following redirects.
+	FinalURL string
+
+	// Redirects are the status codes of the redirects followed to reach the final response, in order.
+	Redirects []int
+
+	// StatusCode is the HTTP status code of the final response.
+	// It is zero if the request failed.
+	StatusCode int
+
+	// Latency is the time taken to receive the final response.
+	Latency time.Duration
+
+	// ContentType is the media type reported by the server.
+	ContentType string
+
+	// ContentLength is the size of the response reported by the server or -1 if it is unknown.
+	ContentLength int64
+
+	// Title is the title of the HTML page, if it was fetched with a GET request (see WithPageTitles).
+	Title string
+
+	// ContentHash is a similarity hash of the title and text of the HTML page, if it was fetched with a GET request
+	// (see WithContentDrift). It is zero if the page was not fetched or has no text.
+	ContentHash uint64
+
+	// CertExpiry is when the server's TLS certificate expires.
+	// It is the zero time if the link does not use TLS.
+	CertExpiry time.Time
+
+	// Expires is when the response stops being fresh according to its Cache-Control and Expires headers.
+	// It is the zero time if the response must not be reused.
+	Expires time.Time
+
+	// Cached is true if the result was reused from a ResponseCache rather than checked.
+	Cached bool
+
+	// MissingAnchor is true if the page was fetched with CheckAnchor but no element is named by the fragment of the URL.
+	MissingAnchor bool
+
+	// Err is the error encountered while checking the link, if any.
+	Err error
+}
+
+// ExternalResults returns the result of every external link checked by Validate, sorted by URL.
+func (w *Website) ExternalResults() []ExternalResult {
+	results := make([]ExternalResult, 0, len(w.pingResults))
+	for _, result := range w.pingResults {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].URL < results[j].URL
+	})
+	return results
+}
+
+// HostSummary aggregates the findings for external links to a single host.
+type HostSummary struct {
+	// Host is the lowercase host name, including the port if one was given.
+	Host string
+
+	// Findings is the number of findings for links to the host.
+	Findings int
+
+	// Failures is the number of findings where no response was received.
+	Failures int
+
+	// WorstStatus is the highest HTTP status code received from the host or zero if no response was received.
+	WorstStatus int
+}
+
+// GroupByHost aggregates the findings for external links by destination host.
+// The summaries are sorted so hosts with the most findings come first.
+// Findings that are not about external links are ignored.
+func GroupByHost(errs []error) []HostSummary {
+	byHost := make(map[string]*HostSummary)
+	for _, err := range errs {
+		var linkErr *LinkError
+		if !errors.As(err, &linkErr) || linkErr.External == nil {
+			continue
+		}
+		host := linkHost(linkErr.External.URL)
+		summary, exists := byHost[host]
+		if !exists {
+			summary = &HostSummary{Host: host}
+			byHost[host] = summary
+		}
+		summary.Findings++
+		if linkErr.External.Err != nil {
+			summary.Failures++
+		}
+		if linkErr.External.StatusCode > summary.WorstStatus {
+			summary.WorstStatus = linkErr.External.StatusCode
+		}
+	}
+
+	summaries := make([]HostSummary, 0, len(byHost))
+	for _, summary := range byHost {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Findings != summaries[j].Findings {
+			return summaries[i].Findings > summaries[j].Findings
+		}
+		return summaries[i].Host < summaries[j].Host
+	})
+	return summaries
+}
+
+// ExternalChecker verifies external links.
+// Implementations can replace the default HTTP checker, for example to apply a custom transport policy or to fake responses in tests.
+type ExternalChecker interface {
+	// Check verifies the URL is reachable and describes the outcome.
+	// The check must be abandoned when the context is done.
+	Check(ctx context.Context, url string) ExternalResult
+}
+
+// HTTPChecker is the default ExternalChecker.
+// It sends a HEAD request for every URL.
+type HTTPChecker struct {
+	// Client sends the requests.
+	// If nil, a client whose transport pools connections and supports HTTP/2 is used.
+	// Request timeouts are governed by the context passed to Check (see WithRequestTimeout).
+	Client *http.Client
+
+	// Jar stores cookies set by servers and sends them with later requests to the same host,
+	// so sites that set anti-bot cookies and redirect, or that require a login, behave like a browser session.
+	// Use net/http/cookiejar to create one. If nil, cookies are ignored.
+	// The jar is not used if Client is set.
+	Jar http.CookieJar
+
+	// PreAuth maps lowercase host names, including the port if links give one, to hooks that authenticate
+	// with the host before its links are checked. The resulting credentials are added to every request sent to the host.
+	PreAuth map[string]PreAuthHook
+
+	// GetFallback retries with a GET request when a server rejects HEAD requests
+	// with 405 Method Not Allowed or 501 Not Implemented.
+	GetFallback bool
+
+	// MaxResponseBytes caps the bytes read from a single response body.
+	// If zero, at most 1 MiB is read.
+	MaxResponseBytes int64
+
+	// MaxRedirects caps the redirects followed for a single link; links redirecting more often fail.
+	// If zero, up to 10 redirects are followed like net/http does, unless Client has its own CheckRedirect policy.
+	// If negative, redirects are not followed and the status code of the redirect is the outcome of the check.
+	MaxRedirects int
+
+	// MaxTotalBytes caps the bytes read from all response bodies.
+	// Requests that need a body are no longer sent once the cap is reached.
+	// If zero, there is no cap.
+	MaxTotalBytes int64
+
+	spent  int64
+	once   sync.Once
+	client *http.Client
+	auth   preAuth
+
+	pagesMu sync.Mutex
+	pages   map[string]anchorPage
+}
+
+// anchorPage is a page fetched to verify the fragments of the links to it.
+type anchorPage struct {
+	result  ExternalResult
+	anchors map[string]bool
+}
+
+const defaultMaxResponseBytes = 1 << 20
+
+var errBandwidthExceeded = errors.New("bandwidth limit exceeded")
+
+var errHostUnreachable = errors.New("host unreachable")
+
+// WithExternalChecker replaces the checker used to verify external links.
+func WithExternalChecker(checker ExternalChecker) Option {
+	return func(w *Website) {
+		if checker != nil {
+			w.checker = checker
+		}
+	}
+}
+
+// Check sends a HEAD request for the URL and records the final response.
+// A GET request is sent instead if the check level in the context asks for one,
+// and with CheckAnchor the fragment of the URL must name an element of the fetched page.
+func (c *HTTPChecker) Check(ctx context.Context, url string) ExternalResult {
+	switch CheckLevelFromContext(ctx) {
+	case CheckGet:
+		result, _ := c.request(ctx, "GET", url)
+		return result
+	case CheckAnchor:
+		result, anchors := c.anchorPage(ctx, url)
+		if fragment := linkFragment(url); result.Err == nil && result.StatusCode == http.StatusOK && len(fragment) > 0 {
+			result.MissingAnchor = !anchors[fragment]
+		}
+		return result
+	}
+
+	result, _ := c.request(ctx, "HEAD", url)
+	if c.GetFallback && result.Err == nil && (result.StatusCode == http.StatusMethodNotAllowed || result.StatusCode == http.StatusNotImplemented) {
+		result, _ = c.request(ctx, "GET", url)
+	}
+	return result
+}
+
+// anchorPage fetches the page the URL refers to, without its fragment, and returns the anchors it names.
+// Pages are fetched once, so links to many sections of the same page, as with API documentation, cost a single request.
+func (c *HTTPChecker) anchorPage(ctx context.Context, link string) (ExternalResult, map[string]bool) {
+	page := link
+	if i := strings.Index(page, "#"); i >= 0 {
+		page = page[:i]
+	}
+
+	c.pagesMu.Lock()
+	cached, exists := c.pages[page]
+	c.pagesMu.Unlock()
+	if !exists {
+		result, body := c.request(ctx, "GET", page)
+		cached = anchorPage{result: result, anchors: pageAnchors(body)}
+		if ctx.Err() == nil {
+			c.pagesMu.Lock()
+			if c.pages == nil {
+				c.pages = make(map[string]anchorPage)
+			}
+			c.pages[page] = cached
+			c.pagesMu.Unlock()
+		}
+	}
+
+	result := cached.result
+	result.URL = link
+	return result, cached.anchors
+}
+
+// request sends a single request and returns its outcome along with the start of the body, if one was read.
+func (c *HTTPChecker) request(ctx context.Context, method, url string) (ExternalResult, []byte) {
+	result := ExternalResult{URL: url, ContentLength: -1}
+
+	if method != "HEAD" && c.MaxTotalBytes > 0 && atomic.LoadInt64(&c.spent) >= c.MaxTotalBytes {
+		result.Err = errBandwidthExceeded
+		return result, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		result.Err = err
+		return result, nil
+	}
+	if err := c.authenticate(req); err != nil {
+		result.Err = err
+		return result, nil
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient().Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result, nil
+	}
+	var body []byte
+	if method != "HEAD" {
+		// Drain the body, within limits, so the connection can be reused.
+		body, _ = c.readBody(resp.Body)
+	}
+	resp.Body.Close()
+
+	result.FinalURL = resp.Request.URL.String()
+	result.StatusCode = resp.StatusCode
+	for redirected := resp.Request; redirected.Response != nil; redirected = redirected.Response.Request {
+		result.Redirects = append([]int{redirected.Response.StatusCode}, result.Redirects...)
+	}
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.ContentLength = resp.ContentLength
+	result.Expires = freshUntil(resp.Header, time.Now())
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		result.CertExpiry = resp.TLS.PeerCertificates[0].NotAfter
+	}
+	if len(body) > 0 && isHTML(result.ContentType) {
+		if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body)); err == nil {
+			result.Title = pageTitle(doc)
+			result.ContentHash = contentHash(doc, result.Title)
+		}
+	}
+	return result, body
+}
+
+// httpClient returns the client shared by all checks: the configured client, or a default one, with the redirect policy of MaxRedirects.
+// It is built once, so the checker must not be reconfigured after its first check.
+func (c *HTTPChecker) httpClient() *http.Client {
+	c.once.Do(func() {
+		c.client = c.Client
+		if c.client == nil {
+			c.client = &http.Client{Transport: newTransport(), Jar: c.Jar}
+		}
+		if c.MaxRedirects != 0 || c.client.CheckRedirect == nil {
+			maxRedirects := c.MaxRedirects
+			if maxRedirects == 0 {
+				maxRedirects = 10
+			}
+			limited := *c.client
+			limited.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+				if maxRedirects < 0 {
+					return http.ErrUseLastResponse
+				}
+				if len(via) > maxRedirects {
+					return errTooManyRedirects
+				}
+				return nil
+			}
+			c.client = &limited
+		}
+	})
+	return c.client
+}
+
+// newTransport returns a transport tuned for checking many links against the same hosts.
+// Connections are kept alive and pooled per host, and HTTP/2 is negotiated when the server supports it.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// readBody reads at most MaxResponseBytes from the body and charges them against MaxTotalBytes.
+func (c *HTTPChecker) readBody(body io.Reader) ([]byte, error) {
+	limit := c.MaxResponseBytes
+	if limit <= 0 {
+		limit = defaultMaxResponseBytes
+	}
+	if c.MaxTotalBytes > 0 {
+		remaining := c.MaxTotalBytes - atomic.LoadInt64(&c.spent)
+		if remaining <= 0 {
+			return nil, errBandwidthExceeded
+		}
+		if remaining < limit {
+			limit = remaining
+		}
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(body, limit))
+	atomic.AddInt64(&c.spent, int64(len(data)))
+	return data, err
+}
+
+// checkExternal verifies an external link and returns a finding if it is broken.
+func checkExternal(website *Website, entity *fsEntity, raw, href string) *LinkError {
+	website.externalMu.Lock()
+	defer website.externalMu.Unlock()
+
+	link := website.normalization.Normalize(href)
+	if website.checkLevel(link) == CheckSkip {
+		if website.reportUnchecked {
+			return website.newFinding(entity, raw, SeverityInfo, KindUnchecked, "unchecked external link '%s'", href)
+		}
+		return nil
+	}
+
+	result := ping(website, link)
+	if result == nil {
+		return website.newFinding(entity, raw, SeverityWarning, KindUnchecked, "unchecked external link '%s' (the overall deadline was exceeded)", href)
+	}
+
+	var format string
+	kind := KindExternal
+	switch {
+	case result.Err == errHostUnreachable:
+		format = "host unreachable when pinging '%[2]s'"
+	case errors.Is(result.Err, errTooManyRedirects):
+		format = "encountered too many redirects when pinging '%[2]s'"
+	case result.Err != nil:
+		format = "encountered error when pinging '%[2]s'"
+	case result.MissingAnchor && website.isDocLink(link):
+		format, kind = "broken documentation link '%[2]s' (the symbol '%[3]s' is no longer documented)", KindAnchor
+	case result.MissingAnchor:
+		format, kind = "broken external target link '%[2]s' (the anchor does not exist)", KindAnchor
+	case !website.isSuccess(result):
+		format = "encountered status code %[1]d when pinging '%[2]s'"
+	case website.strictRedirects && len(result.Redirects) > 0 && isPermanentRedirect(result.Redirects[0]):
+		err := website.finding(entity, raw, SeverityWarning, KindRedirect, "link '%s' permanently redirects to '%s' (update the link)", href, result.FinalURL)
+		err.External = result
+		err.StatusCode = result.StatusCode
+		return website.report(err)
+	case website.contentDrift && website.history.drifted(link, result.ContentHash):
+		err := website.finding(entity, raw, SeverityWarning, KindExternal, "the content of '%s' changed substantially since the last run (check it is still the intended page)", href)
+		err.External = result
+		err.StatusCode = result.StatusCode
+		return website.report(err)
+	default:
+		return nil
+	}
+
+	severity := SeverityError
+	if website.history.isFlaky(link) {
+		// Unreliable third parties should not break the build.
+		severity = SeverityWarning
+		format += " (flaky)"
+	}
+	err := website.finding(entity, raw, severity, kind, format, result.StatusCode, href, linkFragment(href))
+	err.External = result
+	err.StatusCode = result.StatusCode
+	return website.report(err)
+}
+
+// isSuccess reports whether the external link is considered working.
+func (w *Website) isSuccess(result *ExternalResult) bool {
+	if result.Err != nil || result.MissingAnchor {
+		return false
+	}
+	if len(w.acceptedStatus) > 0 {
+		return w.acceptedStatus[result.StatusCode]
+	}
+	return result.StatusCode >= 200 && result.StatusCode < 300
+}
+
+// ping checks the external link once per website.
+// It returns nil if the link was not checked because the overall deadline was exceeded.
+func ping(website *Website, link string) *ExternalResult {
+	if result, exists := website.pingResults[link]; exists {
+		return result
+	}
+	if website.ctx.Err() != nil {
+		return nil
+	}
+	if result, fresh := website.responseCache.lookup(link, time.Now()); fresh {
+		website.pingResults[link] = result
+		return result
+	}
+
+	host := linkHost(link)
+	if website.breakerThreshold > 0 && website.hostFailures[host] >= website.breakerThreshold {
+		// Stop waiting out timeouts for a host that keeps failing.
+		result := &ExternalResult{URL: link, ContentLength: -1, Err: errHostUnreachable}
+		website.pingResults[link] = result
+		return result
+	}
+
+	span := website.tracer.StartSpan("linkup.ping", map[string]string{"http.url": link})
+	result := website.check(link, host)
+	if website.ctx.Err() != nil {
+		// The check was interrupted by the overall deadline so its result is meaningless.
+		span.End(website.ctx.Err())
+		return nil
+	}
+	if result.Err == nil {
+		span.SetAttribute("http.status_code", strconv.Itoa(result.StatusCode))
+		website.hostFailures[host] = 0
+	} else {
+		website.hostFailures[host]++
+	}
+	span.End(result.Err)
+	website.events.ping(link, result.StatusCode)
+	website.responseCache.store(&result, time.Now())
+
+	website.pingResults[link] = &result
+	return &result
+}
+
+// isExternal reports whether the link refers to another site, either over HTTP or a scheme with a dedicated checker.
+func (w *Website) isExternal(href string) bool {
+	if strings.HasPrefix(href, "http") {
+		return true
+	}
+	_, exists := w.schemes[linkScheme(href)]
+	return exists
+}
+
+// checkerFor returns the checker for the scheme of the link.
+func (w *Website) checkerFor(link string) ExternalChecker {
+	if checker, exists := w.schemes[linkScheme(link)]; exists {
+		return checker
+	}
+	return w.checker
+}
+
+// linkHost returns the lowercase host, including the port, of an external link.
+func linkHost(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		return strings.ToLower(u.Host)
+	}
+	return ""
+}
@@ -0,0 +1,262 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Fix is a safe, mechanical repair of a single link.
+type Fix struct {
+	// Document is the name of the web page containing the link.
+	Document string
+
+	// Old is the link as it appears in the document.
+	Old string
+
+	// New is the replacement link.
+	New string
+
+	// Reason explains why the link should be replaced.
+	Reason string
+}
+
+// Fixes returns the safe mechanical repairs for the links of all documents:
+// links to targets that were renamed (see WithRenames) are updated,
+// links to redirected paths (see WithRedirects) are updated to where the redirects finally lead,
+// http links are upgraded to https if the secure URL is verified to work,
+// and trailing slashes are normalized so directory links end with a slash and file links do not.
+// The fixes are sorted by document.
+func (w *Website) Fixes() []Fix {
+	var fixes []Fix
+	forEachDocument(w.root, func(entity *fsEntity) {
+		seen := make(map[string]bool)
+		for _, href := range entity.hrefs {
+			if seen[href] {
+				continue
+			}
+			seen[href] = true
+			if fix, ok := w.fixLink(entity, href); ok {
+				fixes = append(fixes, fix)
+			}
+		}
+	})
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Document < fixes[j].Document
+	})
+	return fixes
+}
+
+func (w *Website) fixLink(entity *fsEntity, raw string) (Fix, bool) {
+	// Only rewrite links that appear verbatim in the source document.
+	if raw != sanitizeHref(raw) || strings.ContainsAny(raw, "\"'&<>?") {
+		return Fix{}, false
+	}
+	fix := Fix{Document: entity.fullname, Old: raw}
+
+	if strings.HasPrefix(raw, "http://") && w.externalChecks {
+		secure := "https://" + strings.TrimPrefix(raw, "http://")
+		if result := ping(w, secure); result != nil && w.isSuccess(result) {
+			fix.New = secure
+			fix.Reason = "the link is available over https"
+			return fix, true
+		}
+		return Fix{}, false
+	}
+
+	if strings.Contains(raw, ":") {
+		// Leave other schemes, like https and mailto, alone.
+		return Fix{}, false
+	}
+
+	href := raw
+	fragment := ""
+	if i := strings.Index(href, "#"); i >= 0 {
+		href, fragment = href[:i], href[i:]
+	}
+	if len(href) == 0 {
+		return Fix{}, false
+	}
+
+	if to, redirected := w.redirect(entity, href); redirected {
+		return w.fixRedirect(entity, fix, href, fragment, to)
+	}
+
+	base := entity.parent
+	if strings.HasPrefix(href, "/") {
+		base = w.root
+	}
+	components := splitPath(href)
+	target := isPathValid(base, components)
+
+	if target == nil {
+		name, ok := linkedPath(entity, href)
+		if !ok {
+			return Fix{}, false
+		}
+		rename, ok := w.followRename(name)
+		if !ok || len(rename.To) == 0 || isPathValid(w.root, splitPath(rename.To)) == nil {
+			return Fix{}, false
+		}
+		if strings.HasPrefix(href, "/") {
+			fix.New = "/" + rename.To + fragment
+		} else {
+			fix.New = relativeLink(path.Dir(entity.fullname), rename.To) + fragment
+		}
+		fix.Reason = fmt.Sprintf("the target was renamed in commit %s", rename.Commit)
+		return fix, true
+	}
+
+	// A link whose last component is not the name of the target was resolved to a directory index.
+	directory := len(components) == 0 || components[len(components)-1] != target.name
+	if directory && !strings.HasSuffix(href, "/") {
+		fix.New = href + "/" + fragment
+		fix.Reason = "links to directories end with a slash"
+		return fix, true
+	}
+	if !directory && strings.HasSuffix(href, "/") {
+		fix.New = strings.TrimRight(href, "/") + fragment
+		fix.Reason = "links to files do not end with a slash"
+		return fix, true
+	}
+	return Fix{}, false
+}
+
+// fixRedirect suggests the final target of a link to a redirected path, unless the redirects loop or lead to a missing file.
+// The fragment of the link is kept unless the final target has its own.
+func (w *Website) fixRedirect(entity *fsEntity, fix Fix, href, fragment, to string) (Fix, bool) {
+	from, _ := linkedPath(entity, href)
+	chain, loop := w.redirectChain(from, to)
+	if loop {
+		return Fix{}, false
+	}
+	to = chain[len(chain)-1]
+	if strings.Contains(to, "#") {
+		fragment = ""
+	}
+
+	if w.isExternal(to) {
+		fix.New = to + fragment
+	} else {
+		to = strings.TrimPrefix(to, "/")
+		name, query := to, ""
+		if i := strings.IndexAny(name, "?#"); i >= 0 {
+			name, query = name[:i], name[i:]
+		}
+		if w.resolvePath(w.root, name) == nil {
+			return Fix{}, false
+		}
+		if strings.HasPrefix(href, "/") || len(splitPath(name)) == 0 {
+			fix.New = "/" + to + fragment
+		} else {
+			fix.New = relativeLink(path.Dir(entity.fullname), name)
+			if strings.HasSuffix(name, "/") {
+				fix.New += "/"
+			}
+			fix.New += query + fragment
+		}
+	}
+	fix.Reason = fmt.Sprintf("the link redirects to '%s'", strings.Join(chain, "' -> '"))
+	return fix, true
+}
+
+// relativeLink returns a link from the directory to the named file where both are relative to the root of the domain.
+func relativeLink(dir, name string) string {
+	from := splitPath(dir)
+	if dir == "." {
+		from = nil
+	}
+	to := splitPath(name)
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	var components []string
+	for range from[common:] {
+		components = append(components, "..")
+	}
+	components = append(components, to[common:]...)
+	return strings.Join(components, "/")
+}
+
+// ApplyFixes rewrites the href and src attributes of an HTML document according to the fixes.
+// Fixes whose old link does not appear as a quoted attribute value are ignored.
+func ApplyFixes(source []byte, fixes []Fix) []byte {
+	for _, fix := range fixes {
+		replacement := "${1}" + strings.Replace(fix.New, "$", "$$", -1)
+		for _, quote := range []string{`"`, `'`} {
+			pattern := regexp.MustCompile(`(?i)(\s(?:href|src)\s*=\s*` + quote + `)` + regexp.QuoteMeta(fix.Old) + quote)
+			source = pattern.ReplaceAll(source, []byte(replacement+quote))
+		}
+	}
+	return source
+}
+
+// Patch returns a unified diff turning the old contents of the named document into the new contents.
+// The contents must have the same number of lines, as is the case for documents rewritten by ApplyFixes.
+// An empty string is returned if the contents are identical.
+func Patch(name string, old, new []byte) string {
+	const context = 3
+	oldLines := strings.Split(strings.TrimSuffix(string(old), "\n"), "\n")
+	newLines := strings.Split(strings.TrimSuffix(string(new), "\n"), "\n")
+	if len(oldLines) != len(newLines) {
+		return ""
+	}
+
+	var changed []int
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(changed); {
+		// Merge changes whose context overlaps into a single hunk.
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*context {
+			j++
+		}
+		start := changed[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changed[j] + context + 1
+		if end > len(oldLines) {
+			end = len(oldLines)
+		}
+		fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start)
+		for k := start; k < end; k++ {
+			if oldLines[k] == newLines[k] {
+				fmt.Fprintf(&diff, " %s\n", oldLines[k])
+			} else {
+				fmt.Fprintf(&diff, "-%s\n", oldLines[k])
+				fmt.Fprintf(&diff, "+%s\n", newLines[k])
+			}
+		}
+		i = j + 1
+	}
+	return diff.String()
+}
@@ -0,0 +1,108 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithFrameworkAttributes extracts links from the attributes of JavaScript frameworks
+// so the static exports of framework-rendered sites are covered more fully.
+// The following attributes are recognized:
+//
+//	ng-href, ng-src         AngularJS links without {{ }} interpolation
+//	routerLink              Angular router links
+//	[routerLink]            Angular router links bound to a string literal, or to an array holding one
+//	:href, v-bind:href      Vue links bound to a string literal
+//	href={"..."}            Svelte links bound to a string literal
+//
+// Only links whose value is known statically are extracted; bindings to variables are ignored.
+// Framework attributes are not recognized by default.
+func WithFrameworkAttributes(enabled bool) Option {
+	return func(w *Website) {
+		w.frameworkAttributes = enabled
+	}
+}
+
+// frameworkLinks returns the statically known links found in the framework attributes of the element.
+func frameworkLinks(s *goquery.Selection) []string {
+	var links []string
+	for _, attr := range s.Nodes[0].Attr {
+		value := strings.TrimSpace(attr.Val)
+		switch strings.ToLower(attr.Key) {
+		case "ng-href", "ng-src":
+			if !strings.Contains(value, "{{") {
+				links = append(links, value)
+			}
+		case "routerlink":
+			links = append(links, value)
+		case "[routerlink]":
+			if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				value = strings.TrimSpace(value[1 : len(value)-1])
+			}
+			if link, ok := literalValue(value); ok {
+				links = append(links, link)
+			}
+		case ":href", "v-bind:href":
+			if link, ok := literalValue(value); ok {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// svelteLink unwraps an attribute value written as a Svelte expression, as in {"/about"}.
+// The second result is false if the value is an expression whose result is not known statically.
+func svelteLink(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "{") {
+		return value, true
+	}
+	if !strings.HasSuffix(trimmed, "}") {
+		// Unquoted expressions containing spaces are split across several attributes by the HTML parser.
+		return "", false
+	}
+	return literalValue(strings.TrimSpace(trimmed[1 : len(trimmed)-1]))
+}
+
+// literalValue returns the value of a JavaScript string literal.
+// The second result is false if the expression is not a single string literal
+// or is a template literal with substitutions.
+func literalValue(expression string) (string, bool) {
+	if len(expression) < 2 {
+		return "", false
+	}
+	quote := expression[0]
+	if quote != '\'' && quote != '"' && quote != '`' {
+		return "", false
+	}
+	if expression[len(expression)-1] != quote {
+		return "", false
+	}
+	value := expression[1 : len(expression)-1]
+	if strings.IndexByte(value, quote) >= 0 || strings.Contains(value, "\\") {
+		// Concatenations and escape sequences are not static literals worth interpreting.
+		return "", false
+	}
+	if quote == '`' && strings.Contains(value, "${") {
+		return "", false
+	}
+	return value, true
+}
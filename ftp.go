@@ -0,0 +1,208 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FTPChecker verifies ftp:// and ftps:// links by logging in and checking the remote path exists.
+// Files are checked with the SIZE command and directories with the CWD command.
+// The ftps scheme uses implicit TLS, which listens on port 990 by default.
+//
+// Since FTP has no HTTP status codes, the result reports 200 if the path exists and 404 if the server
+// reports it does not. Other failures are reported as errors.
+type FTPChecker struct {
+	// TLSConfig configures the TLS connection for ftps links.
+	// If nil, the default configuration is used.
+	TLSConfig *tls.Config
+}
+
+// Check connects to the server and verifies the path of the URL exists.
+// Credentials in the URL are used to log in; otherwise the login is anonymous.
+func (c *FTPChecker) Check(ctx context.Context, link string) (result ExternalResult) {
+	result = ExternalResult{URL: link, FinalURL: link, ContentLength: -1}
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	u, err := url.Parse(link)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	conn, err := c.dial(ctx, u)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	if state, ok := conn.(*tls.Conn); ok {
+		if certs := state.ConnectionState().PeerCertificates; len(certs) > 0 {
+			result.CertExpiry = certs[0].NotAfter
+		}
+	}
+
+	text := textproto.NewConn(conn)
+	defer text.Close()
+	if _, _, err := text.ReadResponse(220); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := ftpLogin(text, u); err != nil {
+		result.Err = err
+		return result
+	}
+
+	exists, size, err := ftpStat(text, u.Path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if exists {
+		result.StatusCode = 200
+		result.ContentLength = size
+	} else {
+		result.StatusCode = 404
+	}
+	ftpCommand(text, 221, "QUIT")
+	return result
+}
+
+// dial connects to the server, negotiating TLS for ftps links.
+func (c *FTPChecker) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	secure := strings.EqualFold(u.Scheme, "ftps")
+	address := u.Host
+	if len(u.Port()) == 0 {
+		port := "21"
+		if secure {
+			port = "990"
+		}
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if !secure {
+		return conn, nil
+	}
+
+	config := &tls.Config{}
+	if c.TLSConfig != nil {
+		config = c.TLSConfig.Clone()
+	}
+	if len(config.ServerName) == 0 {
+		config.ServerName = u.Hostname()
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// ftpLogin logs in with the credentials of the URL or anonymously.
+func ftpLogin(text *textproto.Conn, u *url.URL) error {
+	user, password := "anonymous", "anonymous@"
+	if u.User != nil {
+		user = u.User.Username()
+		if p, set := u.User.Password(); set {
+			password = p
+		}
+	}
+
+	code, err := ftpCommand(text, 331, "USER %s", user)
+	if code == 230 {
+		// The server does not require a password.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ftpCommand(text, 230, "PASS %s", password)
+	return err
+}
+
+// ftpStat reports whether the path exists and, for files, its size or -1 if it is unknown.
+func ftpStat(text *textproto.Conn, path string) (bool, int64, error) {
+	if len(path) == 0 {
+		path = "/"
+	}
+	if !strings.HasSuffix(path, "/") {
+		// SIZE is only reliable in binary mode.
+		if _, err := ftpCommand(text, 200, "TYPE I"); err != nil {
+			return false, -1, err
+		}
+		code, message, err := ftpReply(text, 213, "SIZE %s", path)
+		if err == nil {
+			size, err := strconv.ParseInt(strings.TrimSpace(message), 10, 64)
+			if err != nil {
+				size = -1
+			}
+			return true, size, nil
+		}
+		if code != 550 {
+			return false, -1, err
+		}
+		// The path may name a directory.
+	}
+
+	code, err := ftpCommand(text, 250, "CWD %s", path)
+	if err == nil {
+		return true, -1, nil
+	}
+	if code == 550 {
+		return false, -1, nil
+	}
+	return false, -1, err
+}
+
+// ftpCommand sends a command and reads its reply, which must have the expected code.
+func ftpCommand(text *textproto.Conn, expectCode int, format string, args ...interface{}) (int, error) {
+	code, _, err := ftpReply(text, expectCode, format, args...)
+	return code, err
+}
+
+func ftpReply(text *textproto.Conn, expectCode int, format string, args ...interface{}) (int, string, error) {
+	id, err := text.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	text.StartResponse(id)
+	defer text.EndResponse(id)
+	code, message, err := text.ReadResponse(expectCode)
+	var protoErr *textproto.Error
+	if err != nil && !errors.As(err, &protoErr) {
+		return 0, "", err
+	}
+	return code, message, err
+}
@@ -0,0 +1,115 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// githubRemote matches the owner and repository of a GitHub remote URL, such as git@github.com:owner/repo.git.
+var githubRemote = regexp.MustCompile(`github\.com[:/]([^/\s]+)/([^/\s]+?)(?:\.git)?/?$`)
+
+// GitHubPagesPreset configures LinkUp for a website published with GitHub Pages from the directory.
+// A CNAME file publishes the website at the root of the custom domain.
+// Otherwise the owner and repository are read from the GITHUB_REPOSITORY environment variable, as set by GitHub Actions,
+// or the origin remote of the git repository, and a project site is served beneath the "/repo/" base path.
+// The 404.html page is served for missing pages and, unless a .nojekyll file disables Jekyll,
+// files and directories beginning with an underscore are not published.
+func GitHubPagesPreset(dir string) (*Preset, error) {
+	preset := &Preset{
+		Dir:     dir,
+		Options: []Option{WithNotFoundPage("404.html")},
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "CNAME")); err == nil {
+		if domain := strings.TrimSpace(string(data)); len(domain) > 0 {
+			preset.Options = append(preset.Options, WithSiteURL("https://"+domain+"/"))
+		}
+	} else {
+		owner, repo, err := githubRepository(dir)
+		if err != nil {
+			return nil, err
+		}
+		site := "https://" + strings.ToLower(owner) + ".github.io/"
+		if !strings.EqualFold(repo, owner+".github.io") {
+			// Project sites are served beneath the repository name.
+			site += repo + "/"
+		}
+		preset.Options = append(preset.Options, siteOptions(site)...)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".nojekyll")); os.IsNotExist(err) {
+		preset.Options = append(preset.Options, WithUnpublished("_*", "*/_*"))
+	}
+	return preset, nil
+}
+
+// githubRepository returns the owner and name of the GitHub repository the directory belongs to.
+func githubRepository(dir string) (string, string, error) {
+	if repository := os.Getenv("GITHUB_REPOSITORY"); strings.Count(repository, "/") == 1 {
+		parts := strings.Split(repository, "/")
+		return parts[0], parts[1], nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for current := abs; ; current = filepath.Dir(current) {
+		if owner, repo, ok := githubOrigin(filepath.Join(current, ".git", "config")); ok {
+			return owner, repo, nil
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	return "", "", fmt.Errorf("cannot determine the GitHub repository of '%s' (add a CNAME file or set GITHUB_REPOSITORY)", dir)
+}
+
+// githubOrigin reads the GitHub repository of the origin remote from a git configuration file.
+func githubOrigin(config string) (string, string, bool) {
+	file, err := os.Open(config)
+	if err != nil {
+		return "", "", false
+	}
+	defer file.Close()
+
+	origin := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			origin = line == `[remote "origin"]`
+			continue
+		}
+		if !origin || !strings.HasPrefix(line, "url") {
+			continue
+		}
+		if i := strings.Index(line, "="); i >= 0 {
+			if match := githubRemote.FindStringSubmatch(strings.TrimSpace(line[i+1:])); match != nil {
+				return match[1], match[2], true
+			}
+		}
+	}
+	return "", "", false
+}
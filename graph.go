@@ -0,0 +1,145 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"sort"
+	"strings"
+)
+
+// PageMetrics describes how a document is linked.
+type PageMetrics struct {
+	// Document is the name of the document.
+	Document string
+
+	// InternalLinks is the number of links to other files of the website, whether or not they exist.
+	// Links to fragments of the same page are not counted.
+	InternalLinks int
+
+	// ExternalLinks is the number of links to other websites.
+	ExternalLinks int
+
+	// InboundLinks is the number of other documents linking to the document.
+	InboundLinks int
+
+	// Depth is the minimum number of clicks needed to reach the document from the home page, which has a depth of zero.
+	// It is -1 if the document cannot be reached from the home page.
+	Depth int
+}
+
+// WithMaxClickDepth warns about documents that take more than the given number of clicks to reach from the home page.
+// Deeply buried pages are harder for visitors and search engines to find. Documents that cannot be reached at all are not reported.
+func WithMaxClickDepth(clicks int) Option {
+	return func(w *Website) {
+		w.maxClickDepth = clicks
+	}
+}
+
+// linkGraph maps every document to the distinct documents it links to.
+type linkGraph map[*fsEntity][]*fsEntity
+
+// isInternalLink reports whether the link refers to another file of the website rather than another website,
+// a fragment of the same page, or a special scheme such as mailto:.
+func (w *Website) isInternalLink(href string) bool {
+	href = sanitizeHref(href)
+	return len(href) > 0 && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "?") &&
+		!w.isExternal(href) && !strings.Contains(href, ":")
+}
+
+// linkGraph resolves the internal links of every document.
+func (w *Website) linkGraph() linkGraph {
+	graph := make(linkGraph)
+	forEachDocument(w.root, func(entity *fsEntity) {
+		seen := make(map[*fsEntity]bool)
+		targets := []*fsEntity{}
+		for _, href := range entity.hrefs {
+			if !w.isInternalLink(href) {
+				continue
+			}
+			if target := w.resolveInternal(entity, href); target != nil && target.document && target != entity && !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+		graph[entity] = targets
+	})
+	return graph
+}
+
+// depths returns the click depth of every document reachable from the home page.
+func (g linkGraph) depths(home *fsEntity) map[*fsEntity]int {
+	depths := make(map[*fsEntity]int)
+	if home == nil {
+		return depths
+	}
+	depths[home] = 0
+	queue := []*fsEntity{home}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, target := range g[current] {
+			if _, visited := depths[target]; !visited {
+				depths[target] = depths[current] + 1
+				queue = append(queue, target)
+			}
+		}
+	}
+	return depths
+}
+
+// pageMetrics computes the metrics of every document, sorted by document name.
+func (w *Website) pageMetrics() []PageMetrics {
+	graph := w.linkGraph()
+	depths := graph.depths(isPathValid(w.root, nil))
+	inbound := make(map[*fsEntity]int)
+	for _, targets := range graph {
+		for _, target := range targets {
+			inbound[target]++
+		}
+	}
+
+	metrics := make([]PageMetrics, 0, len(graph))
+	for entity := range graph {
+		page := PageMetrics{Document: entity.fullname, InboundLinks: inbound[entity], Depth: -1}
+		if depth, reachable := depths[entity]; reachable {
+			page.Depth = depth
+		}
+		for _, href := range entity.hrefs {
+			switch {
+			case w.isExternal(sanitizeHref(href)):
+				page.ExternalLinks++
+			case w.isInternalLink(href):
+				page.InternalLinks++
+			}
+		}
+		metrics = append(metrics, page)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Document < metrics[j].Document })
+	return metrics
+}
+
+// validateClickDepth warns about a document that is buried deeper than the maximum click depth.
+func validateClickDepth(website *Website, entity *fsEntity) []error {
+	if website.maxClickDepth <= 0 {
+		return nil
+	}
+	if depth, reachable := website.clickDepths[entity]; reachable && depth > website.maxClickDepth {
+		return []error{website.newFinding(entity, "", SeverityWarning, KindDocument,
+			"page is %d clicks from the home page (the maximum is %d)", depth, website.maxClickDepth)}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FindingGroup aggregates the findings for links to a single target, such as a missing page linked from many documents.
+type FindingGroup struct {
+	// Target is the URL of an external link or the root-relative path of an internal link, including its fragment.
+	Target string
+
+	// Severity is the most serious severity among the findings.
+	Severity Severity
+
+	// Messages are the distinct messages of the findings, sorted alphabetically.
+	Messages []string
+
+	// Documents are the names of the documents linking to the target, sorted by name.
+	Documents []string
+}
+
+// GroupByTarget aggregates the findings for links by the target they link to,
+// so a link that is both malformed and unreachable, or broken on many pages, is reported once.
+// The groups are sorted so the most serious come first, then by the number of documents and the target.
+// Findings that are not about a specific link are ignored.
+func GroupByTarget(errs []error) []FindingGroup {
+	byTarget := make(map[string]*FindingGroup)
+	var targets []string
+	for _, err := range errs {
+		var linkErr *LinkError
+		if !errors.As(err, &linkErr) || len(linkErr.Href) == 0 {
+			continue
+		}
+		target := findingTarget(linkErr)
+		group, exists := byTarget[target]
+		if !exists {
+			group = &FindingGroup{Target: target, Severity: linkErr.Severity}
+			byTarget[target] = group
+			targets = append(targets, target)
+		}
+		if linkErr.Severity < group.Severity {
+			group.Severity = linkErr.Severity
+		}
+		if !containsString(group.Messages, linkErr.Message) {
+			group.Messages = append(group.Messages, linkErr.Message)
+		}
+		if !containsString(group.Documents, linkErr.Document) {
+			group.Documents = append(group.Documents, linkErr.Document)
+		}
+	}
+
+	groups := make([]FindingGroup, 0, len(targets))
+	for _, target := range targets {
+		group := byTarget[target]
+		sort.Strings(group.Messages)
+		sort.Strings(group.Documents)
+		groups = append(groups, *group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Severity != groups[j].Severity {
+			return groups[i].Severity < groups[j].Severity
+		}
+		if len(groups[i].Documents) != len(groups[j].Documents) {
+			return len(groups[i].Documents) > len(groups[j].Documents)
+		}
+		return groups[i].Target < groups[j].Target
+	})
+	return groups
+}
+
+// findingTarget returns what the link of the finding refers to, resolving internal links against the document they appear on.
+func findingTarget(err *LinkError) string {
+	if err.External != nil {
+		return err.External.URL
+	}
+	href := strings.TrimSpace(err.Href)
+	if strings.Contains(href, ":") {
+		return href
+	}
+	fragment := ""
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href, fragment = href[:i], href[i:]
+	}
+	directory := strings.HasSuffix(href, "/")
+	if len(href) == 0 {
+		href = err.Document
+	} else if !strings.HasPrefix(href, "/") {
+		href = path.Join(path.Dir(err.Document), href)
+	}
+	target := path.Clean("/" + href)
+	if directory && target != "/" {
+		target += "/"
+	}
+	return target + fragment
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
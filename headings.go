@@ -0,0 +1,65 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// heading records a heading of a document in document order.
+type heading struct {
+	level int
+	id    string
+	text  string
+}
+
+// WithHeadingHierarchy warns about headings that skip a level, such as an h4 directly following an h2.
+// Fragment links from in-page tables of contents are validated like any other same page link.
+// The heading hierarchy is not checked by default.
+func WithHeadingHierarchy(enabled bool) Option {
+	return func(w *Website) {
+		w.headingHierarchy = enabled
+	}
+}
+
+// newHeading describes the heading element, which is identified by id.
+func newHeading(s *goquery.Selection, id string) heading {
+	name := goquery.NodeName(s)
+	return heading{
+		level: int(name[1] - '0'),
+		id:    id,
+		text:  strings.Join(strings.Fields(s.Text()), " "),
+	}
+}
+
+// validateHeadingHierarchy warns about headings nested more than one level below the preceding heading.
+func validateHeadingHierarchy(website *Website, entity *fsEntity) []error {
+	if !website.headingHierarchy {
+		return nil
+	}
+	var errors []error
+	for i := 1; i < len(entity.headings); i++ {
+		previous, current := entity.headings[i-1], entity.headings[i]
+		if current.level > previous.level+1 {
+			errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument,
+				"heading '%s' skips from <h%d> to <h%d>", current.text, previous.level, current.level))
+		}
+	}
+	return errors
+}
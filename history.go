@@ -0,0 +1,114 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// historyLength is the number of runs remembered for every external link.
+const historyLength = 10
+
+// History remembers whether external links passed or failed across runs so flaky links can be recognized.
+// A nil History remembers nothing.
+type History struct {
+	// Links maps every external link to its outcomes, oldest first, where true means the link passed.
+	Links map[string][]bool `json:"links"`
+
+	// Content maps external links to the hexadecimal hash of their content from the latest run (see WithContentDrift).
+	Content map[string]string `json:"content,omitempty"`
+}
+
+// LoadHistory reads the history from the named file.
+// An empty history is returned if the file does not exist.
+func LoadHistory(name string) (*History, error) {
+	history := &History{Links: make(map[string][]bool)}
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, err
+	}
+	if history.Links == nil {
+		history.Links = make(map[string][]bool)
+	}
+	return history, nil
+}
+
+// Save writes the history to the named file.
+func (h *History) Save(name string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+// WithHistory records the outcome of every external link check in the history.
+// Broken links that alternated between passing and failing in previous runs are considered flaky
+// and reported as warnings rather than errors.
+func WithHistory(history *History) Option {
+	return func(w *Website) {
+		w.history = history
+	}
+}
+
+// isFlaky reports whether the link flipped between passing and failing at least twice in previous runs.
+func (h *History) isFlaky(link string) bool {
+	if h == nil {
+		return false
+	}
+	outcomes := h.Links[link]
+	flips := 0
+	for i := 1; i < len(outcomes); i++ {
+		if outcomes[i] != outcomes[i-1] {
+			flips++
+		}
+	}
+	return flips >= 2
+}
+
+// record appends the outcome of the external link checks of a run.
+func (h *History) record(w *Website) {
+	if h == nil {
+		return
+	}
+	if h.Links == nil {
+		h.Links = make(map[string][]bool)
+	}
+	for link, result := range w.pingResults {
+		outcomes := append(h.Links[link], w.isSuccess(result))
+		if len(outcomes) > historyLength {
+			outcomes = outcomes[len(outcomes)-historyLength:]
+		}
+		h.Links[link] = outcomes
+
+		if result.ContentHash != 0 {
+			if h.Content == nil {
+				h.Content = make(map[string]string)
+			}
+			h.Content[link] = strconv.FormatUint(result.ContentHash, 16)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// HostPolicy overrides how external links to a host are checked.
+type HostPolicy struct {
+	// Timeout bounds the time spent on a single attempt to check a link.
+	// If zero, the timeout set with WithRequestTimeout is used.
+	Timeout time.Duration
+
+	// Retries is the number of times a check is repeated when it fails with an error,
+	// a 429 Too Many Requests status, or a 5xx status.
+	Retries int
+
+	// Interval is the minimum time between the start of two requests to the host,
+	// for hosts that rate limit their clients.
+	Interval time.Duration
+
+	// Checker replaces the checker for links to the host, for example with a headless browser
+	// for hosts that serve bot-blocking challenges or render their content client-side.
+	// If nil, the checker for the scheme of the link is used.
+	Checker ExternalChecker
+}
+
+type hostPolicyRule struct {
+	pattern string
+	policy  HostPolicy
+}
+
+// WithHostPolicy overrides the timeout, retries, and request rate for hosts matching the glob pattern,
+// as in "api.github.com" or "*.wikipedia.org". Host names are case-insensitive and include the port if the link has one.
+// Patterns are tried in the order they are given and the first match wins.
+func WithHostPolicy(pattern string, policy HostPolicy) Option {
+	return func(w *Website) {
+		w.hostPolicies = append(w.hostPolicies, hostPolicyRule{strings.ToLower(pattern), policy})
+	}
+}
+
+// hostPolicy returns the policy for the host, with the timeout defaulted.
+func (w *Website) hostPolicy(host string) HostPolicy {
+	policy := HostPolicy{}
+	for _, rule := range w.hostPolicies {
+		if globMatch(rule.pattern, host) {
+			policy = rule.policy
+			break
+		}
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = w.requestTimeout
+	}
+	return policy
+}
+
+// check checks the link according to the policy of its host, retrying failed attempts.
+func (w *Website) check(link, host string) ExternalResult {
+	policy := w.hostPolicy(host)
+	level := w.checkLevel(link)
+
+	var result ExternalResult
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if !w.throttle(host, policy.Interval) {
+			break
+		}
+		ctx, cancel := context.WithTimeout(w.ctx, policy.Timeout)
+		if level == CheckDNS {
+			result = lookupHost(ctx, link)
+		} else if policy.Checker != nil {
+			result = policy.Checker.Check(withCheckLevel(ctx, level), link)
+		} else {
+			result = w.checkerFor(link).Check(withCheckLevel(ctx, level), link)
+		}
+		cancel()
+		if !retryable(result) {
+			break
+		}
+	}
+	return result
+}
+
+// throttle waits until a request to the host is allowed by the interval.
+// It returns false if the overall deadline was exceeded while waiting.
+func (w *Website) throttle(host string, interval time.Duration) bool {
+	if interval > 0 {
+		if last, exists := w.lastRequest[host]; exists {
+			if wait := interval - time.Since(last); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-w.ctx.Done():
+					timer.Stop()
+					return false
+				}
+			}
+		}
+	}
+	w.lastRequest[host] = time.Now()
+	return true
+}
+
+// retryable reports whether the outcome of a check may be different if it is repeated.
+func retryable(result ExternalResult) bool {
+	return result.Err != nil || result.StatusCode == 429 || result.StatusCode >= 500
+}
@@ -0,0 +1,118 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hugoConfigs are the names of Hugo's configuration file in order of precedence.
+var hugoConfigs = []string{"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json", "config.toml", "config.yaml", "config.yml", "config.json"}
+
+// HugoPreset configures LinkUp for a website built with Hugo.
+// The publishDir, which defaults to public/, is validated and the baseURL sets the site URL and base path.
+// The aliases in the front matter of the content become redirects to the pages' URLs,
+// which are derived from the content paths according to the uglyURLs setting, or taken from the url front matter.
+// Findings are attributed to the content files the pages were generated from.
+// Heading ids are inferred with HugoSlugs.
+func HugoPreset(dir string) (*Preset, error) {
+	project, config := findProject(dir, hugoConfigs...)
+	if len(config) == 0 {
+		return nil, fmt.Errorf("no Hugo configuration found in '%s' or its parent directory", dir)
+	}
+	settings, err := readSettings(config)
+	if err != nil {
+		return nil, err
+	}
+
+	preset := &Preset{
+		Dir:     outputDir(dir, project, setting(settings, "publishDir", "public")),
+		Options: []Option{WithHeadingSlugs(HugoSlugs)},
+	}
+	preset.Options = append(preset.Options, siteOptions(setting(settings, "baseURL", ""))...)
+
+	redirects, sources, err := hugoContent(project, filepath.Join(project, setting(settings, "contentDir", "content")),
+		setting(settings, "uglyURLs", "false") == "true",
+		setting(settings, "disablePathToLower", "false") != "true")
+	if err != nil {
+		return nil, err
+	}
+	preset.Options = append(preset.Options, WithRedirects(redirects), WithSourceMap(sources))
+	return preset, nil
+}
+
+// hugoContent maps the aliases of every content file to the URL of the page
+// and the URL of every page to its content file, relative to the project.
+func hugoContent(project, contentDir string, ugly, lower bool) (map[string]string, map[string]string, error) {
+	redirects := make(map[string]string)
+	sources := make(map[string]string)
+	err := filepath.Walk(contentDir, func(name string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && name == contentDir {
+			return filepath.SkipDir
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch filepath.Ext(name) {
+		case ".md", ".markdown", ".html", ".adoc", ".org", ".rst":
+		default:
+			return nil
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		front := frontMatter(data)
+
+		rel, err := filepath.Rel(contentDir, name)
+		if err != nil {
+			return err
+		}
+		section, file := path.Split(filepath.ToSlash(rel))
+		page := setting(front, "url", "")
+		if len(page) == 0 {
+			base := strings.TrimSuffix(file, path.Ext(file))
+			switch {
+			case base == "_index" || base == "index":
+				page = "/" + section
+			case ugly:
+				page = "/" + section + setting(front, "slug", base) + ".html"
+			default:
+				page = "/" + section + setting(front, "slug", base) + "/"
+			}
+			if lower {
+				page = strings.ToLower(page)
+			}
+		}
+		if source, err := filepath.Rel(project, name); err == nil {
+			sources[page] = filepath.ToSlash(source)
+		}
+		for _, alias := range front["aliases"] {
+			if !strings.HasPrefix(alias, "/") {
+				alias = "/" + section + alias
+			}
+			redirects[alias] = page
+		}
+		return nil
+	})
+	return redirects, sources, err
+}
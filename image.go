@@ -0,0 +1,285 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Web roots of common web server container images.
+const (
+	NginxWebRoot  = "/usr/share/nginx/html"
+	CaddyWebRoot  = "/usr/share/caddy"
+	ApacheWebRoot = "/usr/local/apache2/htdocs"
+)
+
+// Caps on the entries of image archives, so a malformed or hostile image cannot exhaust memory.
+const (
+	maxImageManifestBytes = 1 << 20
+	maxImageDocumentBytes = 32 << 20
+)
+
+// AddImage registers the files beneath the web root of a container image, such as NginxWebRoot,
+// so exactly what will be deployed is validated.
+// The image must be a tar archive as written by "docker save" or "podman save", optionally gzip-compressed.
+// Layers are applied in order, including the whiteout files that delete files of lower layers.
+// They are streamed from the archive, which is decompressed to a temporary file first if it is compressed.
+func (w *Website) AddImage(name, webRoot string) error {
+	file, cleanup, err := openImage(name)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	entries, manifestData, err := indexImage(file)
+	if err != nil {
+		return fmt.Errorf("image archive '%s': %v", name, err)
+	}
+	if manifestData == nil {
+		return fmt.Errorf("image archive '%s' has no 'manifest.json'", name)
+	}
+	var manifest []struct {
+		Layers []string
+	}
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return fmt.Errorf("invalid image manifest: %v", err)
+	}
+	if len(manifest) != 1 {
+		return fmt.Errorf("image archive '%s' must hold exactly one image, not %d", name, len(manifest))
+	}
+
+	root := strings.Trim(path.Clean("/"+webRoot), "/") + "/"
+	if root == "/" {
+		root = ""
+	}
+	files := make(map[string][]byte)
+	for _, layer := range manifest[0].Layers {
+		entry, exists := entries[path.Clean(layer)]
+		if !exists {
+			return fmt.Errorf("image archive '%s' has no '%s'", name, layer)
+		}
+		if err := applyLayer(files, io.NewSectionReader(file, entry.offset, entry.size), root); err != nil {
+			return fmt.Errorf("layer '%s': %v", layer, err)
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+	for _, file := range names {
+		if isDocumentName(file) {
+			err = w.AddDocumentFromReader(file, bytes.NewReader(files[file]))
+		} else {
+			err = w.AddFile(file)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageEntry locates the contents of an entry within an uncompressed image archive.
+type imageEntry struct {
+	offset int64
+	size   int64
+}
+
+// openImage opens an image archive so its entries can be read in place.
+// A gzip-compressed archive is decompressed to a temporary file, which the returned cleanup function removes.
+func openImage(name string) (*os.File, func(), error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if _, compressed := reader.(*gzip.Reader); !compressed {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return file, func() { file.Close() }, nil
+	}
+	defer file.Close()
+
+	temp, err := ioutil.TempFile("", "linkup-image")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		temp.Close()
+		os.Remove(temp.Name())
+	}
+	if _, err := io.Copy(temp, reader); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return temp, cleanup, nil
+}
+
+// indexImage reads the image archive once, locating every entry and reading the manifest.
+// The manifest is nil if the archive has none.
+func indexImage(file *os.File) (map[string]imageEntry, []byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	entries := make(map[string]imageEntry)
+	var manifest []byte
+	archive := tar.NewReader(file)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return entries, manifest, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		// The tar reader consumes whole headers, so the file is positioned at the contents of the entry.
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, nil, err
+		}
+		name := path.Clean(header.Name)
+		entries[name] = imageEntry{offset: offset, size: header.Size}
+		if name == "manifest.json" {
+			if manifest, err = readCapped(archive, maxImageManifestBytes); err != nil {
+				return nil, nil, fmt.Errorf("'%s': %v", name, err)
+			}
+		}
+	}
+}
+
+// readCapped reads everything from the reader, failing if there are more than max bytes.
+func readCapped(reader io.Reader, max int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(reader, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("entry exceeds %d bytes", max)
+	}
+	return data, nil
+}
+
+// applyLayer applies a layer to the files beneath the web root, keyed by their names relative to the root.
+// Only the contents of HTML documents are kept since no other contents are parsed, and they are capped at maxImageDocumentBytes.
+func applyLayer(files map[string][]byte, layer io.Reader, root string) error {
+	reader, err := decompress(layer)
+	if err != nil {
+		return err
+	}
+
+	var deleted []string
+	var opaque []string
+	added := make(map[string][]byte)
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		dir, base := path.Split(name)
+		if base == ".wh..wh..opq" {
+			opaque = append(opaque, dir)
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			deleted = append(deleted, dir+strings.TrimPrefix(base, ".wh."))
+			continue
+		}
+		if !strings.HasPrefix(name, root) {
+			continue
+		}
+		name = strings.TrimPrefix(name, root)
+
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			var contents []byte
+			if isDocumentName(name) {
+				if contents, err = readCapped(archive, maxImageDocumentBytes); err != nil {
+					return fmt.Errorf("'%s': %v", header.Name, err)
+				}
+			}
+			added[name] = contents
+		case tar.TypeLink:
+			target := strings.TrimPrefix(strings.TrimPrefix(path.Clean("/"+header.Linkname), "/"), root)
+			if contents, exists := added[target]; exists {
+				added[name] = contents
+			} else {
+				added[name] = files[target]
+			}
+		}
+	}
+
+	// Whiteouts only hide the files of lower layers, so they are applied before the layer's own files.
+	for _, dir := range opaque {
+		deleted = append(deleted, strings.TrimSuffix(dir, "/"))
+	}
+	for _, name := range deleted {
+		if name+"/" == root || strings.HasPrefix(root, name+"/") {
+			for file := range files {
+				delete(files, file)
+			}
+			continue
+		}
+		if !strings.HasPrefix(name, root) {
+			continue
+		}
+		name = strings.TrimPrefix(name, root)
+		for file := range files {
+			if file == name || strings.HasPrefix(file, name+"/") {
+				delete(files, file)
+			}
+		}
+	}
+	for name, contents := range added {
+		files[name] = contents
+	}
+	return nil
+}
+
+// decompress transparently decompresses gzip streams.
+func decompress(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
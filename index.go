@@ -0,0 +1,147 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// pathIndex indexes the registered files by their full names so links resolve with a single map lookup
+// instead of a walk down the tree, and so misspelled links can be matched to the files they most likely meant.
+type pathIndex struct {
+	entities map[string]*fsEntity // Files and directories by full name; the root is "".
+	folded   map[string][]string  // Lowercased file names to the names of the files.
+	names    []string             // Sorted file names for nearest-match queries.
+}
+
+// WithSuggestions explains broken internal links by naming the registered file they most likely meant,
+// such as a file whose name differs only in case or shares the longest prefix with the link.
+func WithSuggestions() Option {
+	return func(w *Website) {
+		w.suggestions = true
+	}
+}
+
+func newPathIndex(root *fsEntity) *pathIndex {
+	x := &pathIndex{
+		entities: make(map[string]*fsEntity),
+		folded:   make(map[string][]string),
+	}
+	var visit func(entity *fsEntity)
+	visit = func(entity *fsEntity) {
+		x.entities[entity.fullname] = entity
+		if !entity.directory {
+			folded := strings.ToLower(entity.fullname)
+			x.folded[folded] = append(x.folded[folded], entity.fullname)
+			x.names = append(x.names, entity.fullname)
+			return
+		}
+		for _, child := range entity.children {
+			visit(child)
+		}
+	}
+	visit(root)
+	sort.Strings(x.names)
+	return x
+}
+
+// lookup resolves the path relative to the base directory like isPathValid.
+// Paths with dot segments fall back to walking the tree since every intermediate directory must exist.
+func (x *pathIndex) lookup(base *fsEntity, href string) *fsEntity {
+	components := splitPath(href)
+	for _, component := range components {
+		if component == "." || component == ".." {
+			return isPathValid(base, components)
+		}
+	}
+	name := strings.Join(components, "/")
+	if len(base.fullname) > 0 && len(name) > 0 {
+		name = base.fullname + "/" + name
+	} else if len(name) == 0 {
+		name = base.fullname
+	}
+	entity, exists := x.entities[name]
+	if !exists {
+		return nil
+	}
+	return isPathValid(entity, nil)
+}
+
+// suggest returns the file most likely meant by a link to the missing name, or an empty string if nothing is close.
+// A file whose name only differs in case is preferred, followed by the file in the same directory sharing the longest prefix.
+func (x *pathIndex) suggest(name string) string {
+	if matches := x.folded[strings.ToLower(name)]; len(matches) == 1 {
+		return matches[0]
+	}
+
+	dir, base := path.Split(name)
+	best, bestLength := "", len(base)/3
+	i := sort.SearchStrings(x.names, name)
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(x.names) {
+			continue
+		}
+		candidateDir, candidate := path.Split(x.names[j])
+		if candidateDir != dir {
+			continue
+		}
+		length := 0
+		for length < len(base) && length < len(candidate) && base[length] == candidate[length] {
+			length++
+		}
+		if length > bestLength {
+			best, bestLength = x.names[j], length
+		}
+	}
+	return best
+}
+
+// resolvePath returns the file the path refers to relative to the base directory, or nil if it does not exist.
+// Paths naming fingerprinted assets by their logical name are resolved through the asset manifest.
+func (w *Website) resolvePath(base *fsEntity, href string) *fsEntity {
+	entity := w.lookupPath(base, href)
+	if entity == nil && len(w.assetManifest) > 0 {
+		entity = w.resolveAsset(base, href)
+	}
+	return entity
+}
+
+// lookupPath finds the registered file the path names, relative to the base directory.
+func (w *Website) lookupPath(base *fsEntity, href string) *fsEntity {
+	if w.paths != nil {
+		return w.paths.lookup(base, href)
+	}
+	return isPathValid(base, splitPath(href))
+}
+
+// brokenHint explains why an internal link is broken, if possible.
+func (w *Website) brokenHint(entity *fsEntity, href string) string {
+	if hint := w.renameHint(entity, href); len(hint) > 0 || !w.suggestions || w.paths == nil {
+		return hint
+	}
+	name, ok := linkedPath(entity, href)
+	if !ok {
+		return ""
+	}
+	if suggestion := w.paths.suggest(name); len(suggestion) > 0 {
+		return fmt.Sprintf(" (did you mean '/%s'?)", suggestion)
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import "sort"
+
+// OutboundURL is an external URL referenced by the website.
+type OutboundURL struct {
+	// URL is the link, normalized like it is before being checked.
+	URL string
+
+	// Documents are the names of the documents referencing the URL, sorted by name.
+	Documents []string
+}
+
+// OutboundHost aggregates the external URLs referenced on a single host.
+type OutboundHost struct {
+	// Host is the lowercase host name, including the port if one was given.
+	Host string
+
+	// Pages is the number of documents referencing the host.
+	Pages int
+
+	// URLs are the URLs referenced on the host, sorted by URL.
+	URLs []OutboundURL
+}
+
+// Outbound returns every external host and URL referenced by the registered documents with the documents referencing them,
+// for auditing third-party dependencies. No links are checked.
+// Hosts are sorted so those referenced by the most documents come first.
+func (w *Website) Outbound() []OutboundHost {
+	byURL := make(map[string]map[string]bool)
+	forEachDocument(w.root, func(entity *fsEntity) {
+		for _, href := range entity.hrefs {
+			link := sanitizeHref(href)
+			if !w.isExternal(link) {
+				continue
+			}
+			link = w.normalization.Normalize(link)
+			if byURL[link] == nil {
+				byURL[link] = make(map[string]bool)
+			}
+			byURL[link][entity.fullname] = true
+		}
+	})
+
+	byHost := make(map[string]*OutboundHost)
+	pages := make(map[string]map[string]bool)
+	for link, documents := range byURL {
+		host := linkHost(link)
+		if byHost[host] == nil {
+			byHost[host] = &OutboundHost{Host: host}
+			pages[host] = make(map[string]bool)
+		}
+		outbound := OutboundURL{URL: link}
+		for document := range documents {
+			outbound.Documents = append(outbound.Documents, document)
+			pages[host][document] = true
+		}
+		sort.Strings(outbound.Documents)
+		byHost[host].URLs = append(byHost[host].URLs, outbound)
+	}
+
+	hosts := make([]OutboundHost, 0, len(byHost))
+	for host, outbound := range byHost {
+		outbound.Pages = len(pages[host])
+		sort.Slice(outbound.URLs, func(i, j int) bool { return outbound.URLs[i].URL < outbound.URLs[j].URL })
+		hosts = append(hosts, *outbound)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Pages != hosts[j].Pages {
+			return hosts[i].Pages > hosts[j].Pages
+		}
+		return hosts[i].Host < hosts[j].Host
+	})
+	return hosts
+}
@@ -0,0 +1,172 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jekyllPermalinks are Jekyll's built-in permalink styles for posts.
+var jekyllPermalinks = map[string]string{
+	"date":    "/:categories/:year/:month/:day/:title:output_ext",
+	"pretty":  "/:categories/:year/:month/:day/:title/",
+	"ordinal": "/:categories/:year/:y_day/:title:output_ext",
+	"none":    "/:categories/:title:output_ext",
+}
+
+// JekyllPreset configures LinkUp for a website built with Jekyll.
+// The destination, which defaults to _site/, is validated and the url and baseurl settings set the site URL and base path.
+// The redirect_from and redirect_to front matter of the jekyll-redirect-from plugin become redirects,
+// with the URLs of pages and posts derived from the permalink setting or their permalink front matter.
+// Heading ids are inferred with JekyllSlugs.
+func JekyllPreset(dir string) (*Preset, error) {
+	project, config := findProject(dir, "_config.yml", "_config.yaml", "_config.toml")
+	if len(config) == 0 {
+		return nil, fmt.Errorf("no Jekyll configuration found in '%s' or its parent directory", dir)
+	}
+	settings, err := readSettings(config)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := setting(settings, "destination", "_site")
+	preset := &Preset{
+		Dir:     outputDir(dir, project, destination),
+		Options: []Option{WithHeadingSlugs(JekyllSlugs)},
+	}
+	baseURL := strings.TrimSuffix(setting(settings, "baseurl", ""), "/")
+	if site := setting(settings, "url", ""); len(site) > 0 {
+		preset.Options = append(preset.Options, WithSiteURL(strings.TrimSuffix(site, "/")+baseURL+"/"))
+	}
+	if len(baseURL) > 0 {
+		preset.Options = append(preset.Options, WithBasePath(baseURL))
+	}
+
+	permalink := setting(settings, "permalink", "date")
+	if style, exists := jekyllPermalinks[permalink]; exists {
+		permalink = style
+	}
+	redirects, err := jekyllRedirects(project, filepath.Join(project, destination), permalink)
+	if err != nil {
+		return nil, err
+	}
+	preset.Options = append(preset.Options, WithRedirects(redirects))
+	return preset, nil
+}
+
+// jekyllRedirects collects the redirect_from and redirect_to front matter of the pages and posts of the project.
+func jekyllRedirects(project, destination, permalink string) (map[string]string, error) {
+	redirects := make(map[string]string)
+	err := filepath.Walk(project, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		base := info.Name()
+		if info.IsDir() {
+			if name != project && (name == destination || strings.HasPrefix(base, ".") || base == "node_modules" || base == "vendor" ||
+				(strings.HasPrefix(base, "_") && base != "_posts")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(name) {
+		case ".md", ".markdown", ".html":
+		default:
+			return nil
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		front := frontMatter(data)
+		if len(front["redirect_from"]) == 0 && len(front["redirect_to"]) == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(project, name)
+		if err != nil {
+			return err
+		}
+		page := jekyllURL(filepath.ToSlash(rel), front, permalink)
+		for _, from := range front["redirect_from"] {
+			redirects[from] = page
+		}
+		if to := setting(front, "redirect_to", ""); len(to) > 0 {
+			redirects[page] = to
+		}
+		return nil
+	})
+	return redirects, err
+}
+
+// jekyllURL derives the URL of a page or post from its path relative to the project.
+func jekyllURL(rel string, front map[string][]string, permalink string) string {
+	if url := setting(front, "permalink", ""); len(url) > 0 {
+		return url
+	}
+
+	dir, file := path.Split(rel)
+	name := strings.TrimSuffix(file, path.Ext(file))
+	if path.Base(dir) != "_posts" {
+		// Pages keep their path, with pretty permalinks dropping the extension.
+		switch {
+		case name == "index":
+			return "/" + dir
+		case strings.HasSuffix(permalink, "/"):
+			return "/" + dir + name + "/"
+		default:
+			return "/" + dir + name + ".html"
+		}
+	}
+
+	date, title := time.Time{}, name
+	if len(name) > 11 {
+		if parsed, err := time.Parse("2006-01-02", name[:10]); err == nil {
+			date, title = parsed, name[11:]
+		}
+	}
+	categories := front["categories"]
+	if len(categories) == 1 {
+		categories = strings.Fields(categories[0])
+	}
+	if category := setting(front, "category", ""); len(category) > 0 {
+		categories = []string{category}
+	}
+	if parent := strings.TrimSuffix(strings.TrimSuffix(dir, "_posts/"), "/"); len(parent) > 0 {
+		categories = append(strings.Split(parent, "/"), categories...)
+	}
+
+	url := strings.NewReplacer(
+		":categories", strings.ToLower(strings.Join(categories, "/")),
+		":year", date.Format("2006"),
+		":month", date.Format("01"),
+		":day", date.Format("02"),
+		":y_day", fmt.Sprintf("%03d", date.YearDay()),
+		":title", setting(front, "slug", title),
+		":output_ext", ".html",
+	).Replace(permalink)
+	for strings.Contains(url, "//") {
+		url = strings.Replace(url, "//", "/", -1)
+	}
+	return url
+}
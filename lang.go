@@ -0,0 +1,151 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// languageTag matches the syntax of BCP 47 language tags, such as "en", "fr-CA", or "zh-Hant-TW".
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// WithLocales declares the top-level directories holding the translations of a multilingual website,
+// such as "en", "fr", and "pt-br", which are named after the language of the documents they contain.
+func WithLocales(locales ...string) Option {
+	return func(w *Website) {
+		for _, locale := range locales {
+			w.locales = append(w.locales, strings.Trim(locale, "/"))
+		}
+	}
+}
+
+// WithLocaleParity verifies the locale directories declared with WithLocales contain the same documents,
+// so every page is translated into every language, and warns about links that leave the locale of their document
+// for a page that exists in the locale too. Documents matching the glob patterns, given relative to the locale directory
+// such as "blog/*", are exempt from translation.
+func WithLocaleParity(exceptions ...string) Option {
+	return func(w *Website) {
+		w.localeParity = true
+		for _, exception := range exceptions {
+			w.localeExceptions = append(w.localeExceptions, prepareFileName(exception))
+		}
+	}
+}
+
+// WithLangCheck warns about documents whose <html> element has no lang attribute or a malformed one,
+// which screen readers, translation tools, and search engines rely on.
+// Documents within a locale directory declared with WithLocales must also declare the language of the directory.
+func WithLangCheck(enabled bool) Option {
+	return func(w *Website) {
+		w.langCheck = enabled
+	}
+}
+
+// locale returns the locale directory the entity is in, or an empty string if it is not in one.
+func (w *Website) locale(entity *fsEntity) string {
+	for _, locale := range w.locales {
+		if strings.HasPrefix(entity.fullname, locale+"/") {
+			return locale
+		}
+	}
+	return ""
+}
+
+// translated reports whether the locale has a document with the name, relative to the locale directory.
+func (w *Website) translated(locale, name string) bool {
+	entity := w.resolvePath(w.root, "/"+locale+"/"+name)
+	return entity != nil && entity.document
+}
+
+// validateLocaleParity reports the translations missing for a document and its links into other locales.
+func validateLocaleParity(website *Website, entity *fsEntity) []error {
+	if !website.localeParity {
+		return nil
+	}
+	locale := website.locale(entity)
+	if len(locale) == 0 {
+		return nil
+	}
+	name := strings.TrimPrefix(entity.fullname, locale+"/")
+
+	var errors []error
+	exempt := false
+	for _, exception := range website.localeExceptions {
+		exempt = exempt || globMatch(exception, name)
+	}
+	// Missing translations are reported once, on the document of the first locale that has one.
+	for _, other := range website.locales {
+		if other == locale {
+			break
+		}
+		exempt = exempt || website.translated(other, name)
+	}
+	if !exempt {
+		for _, other := range website.locales {
+			if other != locale && !website.translated(other, name) {
+				errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "document has no '%s' translation (expected '%s/%s')", other, other, name))
+			}
+		}
+	}
+
+	for _, href := range entity.hrefs {
+		if !website.isInternalLink(href) {
+			continue
+		}
+		target := website.resolveInternal(entity, href)
+		if target == nil || !target.document {
+			continue
+		}
+		targetLocale := website.locale(target)
+		if targetLocale == locale {
+			continue
+		}
+		counterpart := strings.TrimPrefix(target.fullname, targetLocale+"/")
+		if website.translated(locale, counterpart) {
+			errors = append(errors, website.newFinding(entity, href, SeverityWarning, KindPolicy, "link '%s' leaves the '%s' locale (link to '/%s/%s' instead)",
+				href, locale, locale, counterpart))
+		}
+	}
+	return errors
+}
+
+// sameLanguage reports whether the language tags match, where a tag matches the more specific tags it is a prefix of,
+// so "fr" matches "fr-CA". Tags are compared case-insensitively and underscores are treated as hyphens.
+func sameLanguage(a, b string) bool {
+	a = strings.ToLower(strings.Replace(a, "_", "-", -1))
+	b = strings.ToLower(strings.Replace(b, "_", "-", -1))
+	return a == b || strings.HasPrefix(a, b+"-") || strings.HasPrefix(b, a+"-")
+}
+
+// validateLang warns about a document whose language is missing, malformed, or does not match its locale directory.
+func validateLang(website *Website, entity *fsEntity) []error {
+	if !website.langCheck {
+		return nil
+	}
+	lang := strings.TrimSpace(entity.lang)
+	switch {
+	case len(lang) == 0:
+		return []error{website.newFinding(entity, "", SeverityWarning, KindDocument, "document has no lang attribute on <html>")}
+	case !languageTag.MatchString(lang):
+		return []error{website.newFinding(entity, "", SeverityWarning, KindDocument, "document declares the malformed language '%s'", lang)}
+	}
+	if locale := website.locale(entity); len(locale) > 0 && !sameLanguage(lang, locale) {
+		return []error{website.newFinding(entity, "", SeverityWarning, KindDocument, "document declares the language '%s' but is in the '%s' locale directory", lang, locale)}
+	}
+	return nil
+}
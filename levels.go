@@ -0,0 +1,183 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// CheckLevel selects how much effort is spent checking an external link.
+type CheckLevel int
+
+const (
+	// CheckHead sends a HEAD request. It is the default level.
+	CheckHead CheckLevel = iota
+
+	// CheckSkip does not check the link at all.
+	CheckSkip
+
+	// CheckDNS only verifies the host name of the link resolves.
+	CheckDNS
+
+	// CheckGet sends a GET request, for servers that answer HEAD requests incorrectly.
+	CheckGet
+
+	// CheckAnchor sends a GET request and verifies the fragment of the link names an element of the page.
+	CheckAnchor
+)
+
+func (l CheckLevel) String() string {
+	switch l {
+	case CheckSkip:
+		return "skip"
+	case CheckDNS:
+		return "dns-only"
+	case CheckGet:
+		return "get"
+	case CheckAnchor:
+		return "get+anchor"
+	default:
+		return "head"
+	}
+}
+
+type checkLevelRule struct {
+	pattern string
+	level   CheckLevel
+}
+
+type checkLevelKey struct{}
+
+// WithCheckLevel checks external links matching the glob pattern at the given level,
+// for example full anchor checking for partner documentation and cheap checks for news sites.
+// In the pattern "*" matches any run of characters, including slashes, and "?" matches a single character,
+// as in "https://docs.example.com/*" or "*://*.news.example/*".
+// Patterns are tried in the order they are given and the first match wins.
+// Links that match no pattern are checked with CheckHead, or CheckGet with WithPageTitles or WithContentDrift.
+func WithCheckLevel(pattern string, level CheckLevel) Option {
+	return func(w *Website) {
+		w.checkLevels = append(w.checkLevels, checkLevelRule{pattern, level})
+	}
+}
+
+// CheckLevelFromContext returns the check level requested for the link being checked.
+// Custom ExternalChecker implementations can use it to honor WithCheckLevel.
+func CheckLevelFromContext(ctx context.Context) CheckLevel {
+	level, _ := ctx.Value(checkLevelKey{}).(CheckLevel)
+	return level
+}
+
+func withCheckLevel(ctx context.Context, level CheckLevel) context.Context {
+	return context.WithValue(ctx, checkLevelKey{}, level)
+}
+
+// checkLevel returns the level the link should be checked at.
+func (w *Website) checkLevel(link string) CheckLevel {
+	for _, rule := range w.checkLevels {
+		if globMatch(rule.pattern, link) {
+			return rule.level
+		}
+	}
+	if w.pageTitles || w.contentDrift {
+		return CheckGet
+	}
+	return CheckHead
+}
+
+// globMatch reports whether s matches the pattern, where "*" matches any run of characters and "?" matches one.
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// lookupHost checks the link by resolving its host name.
+// The result reports 200 if the name resolves.
+func lookupHost(ctx context.Context, link string) ExternalResult {
+	result := ExternalResult{URL: link, ContentLength: -1}
+	u, err := url.Parse(link)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	start := time.Now()
+	_, err = net.DefaultResolver.LookupHost(ctx, u.Hostname())
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.StatusCode = 200
+	return result
+}
+
+// linkFragment returns the unescaped fragment of the link or an empty string if it has none.
+func linkFragment(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		return u.Fragment
+	}
+	return ""
+}
+
+// pageAnchors returns the names of the elements of the page that fragments can target,
+// which are the ids of all elements and the names of anchors.
+func pageAnchors(page []byte) map[string]bool {
+	anchors := make(map[string]bool)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return anchors
+	}
+	doc.Find("[id], a[name]").Each(func(i int, s *goquery.Selection) {
+		if id, exists := s.Attr("id"); exists {
+			anchors[id] = true
+		}
+		if name, exists := s.Attr("name"); exists && goquery.NodeName(s) == "a" {
+			anchors[name] = true
+		}
+	})
+	return anchors
+}
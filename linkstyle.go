@@ -0,0 +1,167 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"net/url"
+	"strings"
+)
+
+// LinkStyle is the preferred way of writing links between the pages of a website.
+type LinkStyle int
+
+const (
+	// AnyLinkStyle accepts every style of link. It is the default.
+	AnyLinkStyle LinkStyle = iota
+
+	// PreferRelative expects links relative to the linking document, such as "../guide/intro.html".
+	PreferRelative
+
+	// PreferRootRelative expects links relative to the root of the domain, such as "/guide/intro.html".
+	PreferRootRelative
+
+	// PreferAbsolute expects links with a scheme and host, such as "https://example.com/guide/intro.html".
+	PreferAbsolute
+)
+
+// WithLinkStyle reports internal links not written in the preferred style as warnings,
+// for teams standardizing links ahead of a domain or base path migration.
+// Absolute links are recognized as internal only if the URL of the website is set with WithSiteURL.
+func WithLinkStyle(style LinkStyle) Option {
+	return func(w *Website) {
+		w.linkStyle = style
+	}
+}
+
+// WithSiteURL sets the URL the website is published at, such as "https://example.com/".
+// Absolute links to this URL are then recognized as links to the website itself and resolved against the registered files
+// instead of being checked over the network, and absolute links to its host with another scheme or an explicit default port
+// are reported as warnings. Unless a base path is set with WithBasePath, the path of the URL is the root of the domain.
+func WithSiteURL(site string) Option {
+	return func(w *Website) {
+		if u, err := url.Parse(site); err == nil && len(u.Host) > 0 {
+			w.siteURL = u
+		}
+	}
+}
+
+// WithBasePath serves the website beneath a path prefix, such as "/repo/" for a GitHub Pages project site.
+// Root-relative links must begin with the prefix, which is removed before they are resolved against the registered files.
+func WithBasePath(prefix string) Option {
+	return func(w *Website) {
+		prefix = strings.Trim(prefix, "/")
+		if len(prefix) > 0 {
+			w.basePath = "/" + prefix + "/"
+		} else {
+			w.basePath = ""
+		}
+	}
+}
+
+// stripBasePath removes the base path from a root-relative link.
+// The second result is false if the link is outside of the base path.
+func (w *Website) stripBasePath(href string) (string, bool) {
+	if len(w.basePath) == 0 || !strings.HasPrefix(href, "/") {
+		return href, true
+	}
+	if href+"/" == w.basePath {
+		return "/", true
+	}
+	if strings.HasPrefix(href, w.basePath) {
+		return "/" + strings.TrimPrefix(href, w.basePath), true
+	}
+	return href, false
+}
+
+// isSiteLink reports whether the absolute link refers to the website itself.
+func (w *Website) isSiteLink(href string) bool {
+	if w.siteURL == nil {
+		return false
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	if !strings.EqualFold(u.Host, w.siteURL.Host) {
+		return false
+	}
+	// Compare whole path segments, so https://host/repository is not part of a website at https://host/repo.
+	base := strings.TrimSuffix(w.siteURL.Path, "/")
+	return len(base) == 0 || u.Path == base || strings.HasPrefix(u.Path, base+"/")
+}
+
+// sitePath converts an absolute link to the website itself into the equivalent root-relative link.
+// The second result is false if the link is not an http or https link to the website.
+func (w *Website) sitePath(href string) (string, bool) {
+	scheme := strings.ToLower(linkScheme(href))
+	if (scheme != "http" && scheme != "https") || !w.isSiteLink(href) {
+		return "", false
+	}
+	rest := strings.TrimPrefix(href[len(scheme)+1:], "//")
+	i := strings.IndexAny(rest, "/?#")
+	if i < 0 {
+		return "/", true
+	}
+	rest = rest[i:]
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	if base := strings.TrimSuffix(w.siteURL.Path, "/"); len(w.basePath) == 0 && len(base) > 0 {
+		// The path of the site URL is the root of the domain, so it is removed like a base path.
+		rest = "/" + strings.TrimPrefix(strings.TrimPrefix(rest, base), "/")
+	}
+	return rest, true
+}
+
+// linkStyleOf classifies an internal link.
+// The second result is false if the link is not an internal link whose style matters.
+func (w *Website) linkStyleOf(href string) (LinkStyle, bool) {
+	switch {
+	case len(href) == 0 || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "?"):
+		return AnyLinkStyle, false
+	case strings.HasPrefix(href, "//"):
+		return PreferAbsolute, w.isSiteLink("https:" + href)
+	case strings.HasPrefix(href, "/"):
+		return PreferRootRelative, true
+	case strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://"):
+		return PreferAbsolute, w.isSiteLink(href)
+	case len(linkScheme(href)) > 0:
+		return AnyLinkStyle, false
+	default:
+		return PreferRelative, true
+	}
+}
+
+// validateLinkStyle warns about internal links not written in the preferred style.
+func validateLinkStyle(website *Website, entity *fsEntity) []error {
+	if website.linkStyle == AnyLinkStyle {
+		return nil
+	}
+	names := map[LinkStyle]string{
+		PreferRelative:     "relative",
+		PreferRootRelative: "root-relative",
+		PreferAbsolute:     "absolute",
+	}
+	var errors []error
+	for _, raw := range entity.hrefs {
+		style, internal := website.linkStyleOf(strings.TrimSpace(raw))
+		if internal && style != website.linkStyle {
+			errors = append(errors, website.newFinding(entity, raw, SeverityWarning, KindPolicy, "link '%s' should be %s", raw, names[website.linkStyle]))
+		}
+	}
+	return errors
+}
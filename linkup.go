@@ -17,43 +17,213 @@
 package linkup
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
-	"net/http"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
+// browserSchemes are the schemes of URLs generated by the browser at run time, which cannot be verified statically.
+var browserSchemes = []string{"blob:", "filesystem:"}
+
+// indexFiles are the names of the files served when a directory is requested, in order of precedence.
+var indexFiles = []string{"index.html", "index.htm", "index.tmpl"}
+
 type fsEntity struct {
 	name      string
 	fullname  string
 	directory bool
+	document  bool
 	children  map[string]*fsEntity
 	parent    *fsEntity
 	ids       map[string]int
+	hidden    map[string]string
 	hrefs     []string
+
+	slugCollisions []slugCollision
+	unarchived     []string
+	metadata       metadata
+	headings       []heading
+	toc            []string
+	next           string
+	prev           string
+	breadcrumbs    []breadcrumb
+	relativeAssets []string
+	openerLinks    []string
+	relLinks       []relLink
+	parseIssues    []parseIssue
+	lang           string
+	contentHash    string
+	refresh        bool
+	positions      map[string]position
+	amp            bool
+	ampHTML        string
+	canonical      string
+	ampResources   []ampResource
+	pixels         []string
 }
 
 // Website represents a set of related web pages located under a single domain.
 // Each web page can cantain zero or more links.
 type Website struct {
-	root        *fsEntity
-	pingResults map[string]int
+	root         *fsEntity
+	pingResults  map[string]*ExternalResult
+	hostFailures map[string]int
+	tracer       Tracer
+	checker      ExternalChecker
+	schemes      map[string]ExternalChecker
+	events       *eventLog
+	renames      map[string]Rename
+	history      *History
+	slugifier    Slugifier
+
+	normalization  Normalization
+	responseCache  *ResponseCache
+	trackingParams []string
+	clientRoutes   []*regexp.Regexp
+
+	frameworkAttributes bool
+	linkAttributes      map[string][]string
+	eventHandlerLinks   bool
+	scriptLinks         *regexp.Regexp
+	dataURILimit        int
+	checkLevels         []checkLevelRule
+	hostPolicies        []hostPolicyRule
+	citationSelector    string
+	metadataAudit       bool
+	headingHierarchy    bool
+	tocCheck            bool
+	paginationCheck     bool
+	breadcrumbs         BreadcrumbPolicy
+	notFoundPage        string
+	linkStyle           LinkStyle
+	siteURL             *url.URL
+	unpublished         []string
+	deployed            map[string]bool
+	concurrency         int
+	suggestions         bool
+	paths               *pathIndex
+	arena               *entityArena
+	redirects           map[string]string
+	basePath            string
+	targetBlankCheck    bool
+	relPolicies         []relPolicyRule
+	maxClickDepth       int
+	strictParsing       bool
+	emptyLinks          EmptyLinkPolicy
+	severityOverrides   []SeverityOverride
+	catalog             Catalog
+	docPatterns         []string
+	docVersions         []DocVersionPolicy
+	locales             []string
+	langCheck           bool
+	localeParity        bool
+	localeExceptions    []string
+	assetManifest       map[string]string
+	sources             map[string]string
+	sourceDirs          []string
+	pageTitles          bool
+	contentDrift        bool
+	duplicateCheck      bool
+	duplicates          map[*fsEntity]*fsEntity
+	linePositions       bool
+	canonicalHost       bool
+	hostAliases         []string
+	ampCheck            bool
+	emailMode           bool
+	strictRedirects     bool
+	attachments         []string
+	epubs               []*epubPackage
+	acceptedStatus      map[int]bool
+	clickDepths         map[*fsEntity]int
+	externalMu          sync.Mutex
+	lastRequest         map[string]time.Time
+
+	ctx              context.Context
+	requestTimeout   time.Duration
+	overallDeadline  time.Duration
+	externalChecks   bool
+	internalChecks   bool
+	reportUnchecked  bool
+	breakerThreshold int
 }
 
 // New allocates and initializes a new instance of the Website structure.
-func New() *Website {
+// Options are applied in the order they are given.
+func New(options ...Option) *Website {
 	ent := allocateFSEntity("/")
 	ent.directory = true
-	return &Website{
-		root:        ent,
-		pingResults: make(map[string]int),
+	ent.children = make(map[string]*fsEntity)
+	w := &Website{
+		root:         ent,
+		pingResults:  make(map[string]*ExternalResult),
+		hostFailures: make(map[string]int),
+		lastRequest:  make(map[string]time.Time),
+		tracer:       noopTracer{},
+		checker:      &HTTPChecker{},
+		schemes:      registeredSchemes(),
+		arena:        newEntityArena(),
+
+		notFoundPage:   "404.html",
+		ctx:            context.Background(),
+		requestTimeout: 2 * time.Second,
+		externalChecks: true,
+		internalChecks: true,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// AddDirectory registers every file beneath the directory, which is treated as the root of the domain.
+// Files with an .html, .htm, or .tmpl extension are registered as HTML documents and all other files are registered with AddFile.
+func (w *Website) AddDirectory(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if !isDocumentName(name) {
+			return w.AddFile(name)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return w.AddDocumentFromReader(name, file)
+	})
+}
+
+// isDocumentName reports whether the file should be registered as an HTML document based on its extension.
+func isDocumentName(name string) bool {
+	switch filepath.Ext(name) {
+	case ".html", ".htm", ".tmpl":
+		return true
 	}
+	return false
 }
 
 // AddFile registers a non-HTML file.
@@ -61,9 +231,10 @@ func New() *Website {
 // Its name must be relative to the root of the domain.
 func (w *Website) AddFile(name string) error {
 	name = prepareFileName(name)
-	if newFSEntity(w.root, name) == nil {
+	if w.newFSEntity(name) == nil {
 		return fmt.Errorf("file already registered with name '%s'", name)
 	}
+	w.events.file(name)
 	return nil
 }
 
@@ -81,25 +252,65 @@ func (w *Website) AddDocument(name string) error {
 
 // AddDocumentFromReader registers the specified web page for link verification.
 // The file name must be relative to the root of the domain.
-func (w *Website) AddDocumentFromReader(name string, reader io.Reader) error {
+func (w *Website) AddDocumentFromReader(name string, reader io.Reader) (err error) {
 	name = prepareFileName(name)
-	entity := newFSEntity(w.root, name)
+	span := w.tracer.StartSpan("linkup.parse", map[string]string{"linkup.document": name})
+	defer func() { span.End(err) }()
+
+	entity := w.newFSEntity(name)
 	if entity == nil {
 		return fmt.Errorf("file already registered with name '%s'", name)
 	}
 
+	entity.document = true
+	entity.ids = make(map[string]int)
+	entity.hidden = make(map[string]string)
+
+	var content hash.Hash
+	if w.duplicateCheck {
+		content = sha256.New()
+		reader = io.TeeReader(reader, content)
+	}
+
+	if w.strictParsing || w.linePositions {
+		// Both need another pass over the document.
+		document, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		if w.strictParsing {
+			entity.parseIssues = strictParse(bytes.NewReader(document))
+		}
+		if w.linePositions {
+			entity.positions = linkPositions(document)
+		}
+		reader = bytes.NewReader(document)
+	}
+
 	doc, err := goquery.NewDocumentFromReader(reader)
 	if err != nil {
 		return err
 	}
+	if content != nil {
+		entity.contentHash = hex.EncodeToString(content.Sum(nil))
+	}
 
 	// Recursively collect all links.
 	var visitNode func(i int, s *goquery.Selection)
+	var hiddenBy []string
+	tocDepth := 0
 
 	visitNode = func(i int, s *goquery.Selection) {
-		switch strings.ToLower(goquery.NodeName(s)) {
+		element := strings.ToLower(goquery.NodeName(s))
+		switch element {
 		case "a", "link":
 			if href, exists := s.Attr("href"); exists {
+				if w.frameworkAttributes {
+					var static bool
+					if href, static = svelteLink(href); !static {
+						break
+					}
+				}
 				entity.hrefs = append(entity.hrefs, href)
 			}
 			break
@@ -107,36 +318,177 @@ func (w *Website) AddDocumentFromReader(name string, reader io.Reader) error {
 		case "script", "img", "source":
 			if src, exists := s.Attr("src"); exists {
 				entity.hrefs = append(entity.hrefs, src)
+			} else if element == "script" && w.scriptLinks != nil {
+				entity.hrefs = append(entity.hrefs, scriptLinks(w.scriptLinks, s.Text())...)
 			}
 			if srcsets, exists := s.Attr("srcset"); exists {
-				images := strings.Split(srcsets, ",")
-				for _, image := range images {
-					index := strings.LastIndex(image, " ")
-					if index < 0 {
-						entity.hrefs = append(entity.hrefs, image)
-					} else {
-						entity.hrefs = append(entity.hrefs, image[:index])
-					}
-				}
+				entity.hrefs = append(entity.hrefs, srcsetURLs(srcsets)...)
 			}
 			break
 		}
 
-		if id, exists := s.Attr("id"); exists {
+		if w.frameworkAttributes {
+			entity.hrefs = append(entity.hrefs, frameworkLinks(s)...)
+		}
+
+		if w.metadataAudit {
+			entity.metadata.count(element, s)
+		}
+
+		if w.duplicateCheck && element == "meta" && strings.EqualFold(s.AttrOr("http-equiv", ""), "refresh") {
+			entity.refresh = true
+		}
+
+		if w.langCheck && element == "html" {
+			entity.lang = s.AttrOr("lang", "")
+		}
+
+		if w.ampCheck {
+			recordAMP(entity, element, s)
+		}
+
+		if w.emailMode && element == "img" {
+			recordPixel(entity, s)
+		}
+
+		if w.paginationCheck && (element == "a" || element == "link") {
+			recordPagination(entity, s)
+		}
+
+		if w.targetBlankCheck && (element == "a" || element == "area") {
+			w.recordTargetBlank(entity, s)
+		}
+
+		if len(w.relPolicies) > 0 && (element == "a" || element == "area") {
+			w.recordRelPolicy(entity, s)
+		}
+
+		if w.tocCheck {
+			if isTOC(s) {
+				tocDepth++
+				defer func() { tocDepth-- }()
+			} else if element == "a" && tocDepth > 0 {
+				if href := strings.TrimSpace(s.AttrOr("href", "")); strings.HasPrefix(href, "#") && len(href) > 1 {
+					entity.toc = append(entity.toc, href)
+				}
+			}
+		}
+
+		if w.eventHandlerLinks {
+			entity.hrefs = append(entity.hrefs, eventHandlerLinks(s)...)
+		}
+
+		for _, attrs := range [][]string{w.linkAttributes[element], w.linkAttributes["*"]} {
+			for _, attr := range attrs {
+				if value, exists := s.Attr(attr); exists {
+					entity.hrefs = append(entity.hrefs, value)
+				}
+			}
+		}
+
+		if reason := hiddenReason(s); len(reason) > 0 {
+			hiddenBy = append(hiddenBy, reason)
+			defer func() { hiddenBy = hiddenBy[:len(hiddenBy)-1] }()
+		}
+
+		id, exists := s.Attr("id")
+		if exists {
 			entity.ids[id]++
+			if len(hiddenBy) > 0 {
+				entity.hidden[id] = hiddenBy[0]
+			}
+		} else if w.slugifier != nil && isHeading(s) {
+			id = inferHeadingID(entity, w.slugifier, s)
+		}
+
+		if (w.headingHierarchy || w.tocCheck) && isHeading(s) {
+			entity.headings = append(entity.headings, newHeading(s, id))
 		}
 
 		s.Children().Each(visitNode)
 	}
 
 	doc.Each(visitNode)
+	for i, href := range entity.hrefs {
+		entity.hrefs[i] = w.arena.intern(href)
+	}
+	if name == w.notFoundPage {
+		entity.relativeAssets = findRelativeAssets(doc)
+	}
+	if w.breadcrumbs != BreadcrumbsOff {
+		entity.breadcrumbs = findBreadcrumbs(doc)
+	}
+	if len(w.citationSelector) > 0 {
+		entity.unarchived = findUnarchivedCitations(doc, w.citationSelector)
+	}
+	w.events.document(name)
 	return nil
 }
 
 // Validate detects broken website links.
 // All files must be registered before calling this method.
+// Every returned error is a *LinkError. The signature deliberately stays []error rather than []LinkError,
+// so existing callers keep compiling; use Findings for the typed findings.
 func (w *Website) Validate() []error {
-	return validate(w, w.root)
+	return w.run(nil, func() []error {
+		if w.concurrency > 1 {
+			return validateDocuments(w, collectFiles(w.root, nil))
+		}
+		return validate(w, w.root)
+	})
+}
+
+// Findings detects broken website links like Validate, but returns the findings typed so their fields
+// can be used without type assertions. It is the typed counterpart of Validate, which was not changed to
+// return []LinkError so callers treating findings as errors, such as the linkuptest and linkupissues packages, keep working.
+func (w *Website) Findings() []*LinkError {
+	return LinkErrors(w.Validate())
+}
+
+// run wraps a validation pass with tracing, lifecycle events, and the overall deadline.
+func (w *Website) run(attributes map[string]string, pass func() []error) []error {
+	span := w.tracer.StartSpan("linkup.validate", attributes)
+	defer span.End(nil)
+
+	if w.overallDeadline > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), w.overallDeadline)
+		defer cancel()
+		w.ctx = ctx
+	}
+	defer func() { w.ctx = context.Background() }()
+
+	w.paths = newPathIndex(w.root)
+	if len(w.sourceDirs) > 0 {
+		w.guessSources()
+	}
+	if w.duplicateCheck {
+		w.duplicates = w.findDuplicates()
+	}
+	if w.maxClickDepth > 0 {
+		w.clickDepths = w.linkGraph().depths(isPathValid(w.root, nil))
+	}
+	w.events.validateStart()
+	errors := pass()
+	w.history.record(w)
+	w.events.validateEnd(len(errors))
+	return errors
+}
+
+// hiddenReason describes why the element and its descendants are not displayed, or returns an empty string if they are.
+func hiddenReason(s *goquery.Selection) string {
+	if strings.ToLower(goquery.NodeName(s)) == "template" {
+		return "a <template> element"
+	}
+	if _, exists := s.Attr("hidden"); exists {
+		return "a hidden attribute"
+	}
+	if style, exists := s.Attr("style"); exists {
+		style = strings.ToLower(strings.Join(strings.Fields(style), ""))
+		if strings.Contains(style, "display:none") {
+			return "a display:none style"
+		}
+	}
+	return ""
 }
 
 func isPathValid(entity *fsEntity, components []string) *fsEntity {
@@ -147,7 +499,7 @@ func isPathValid(entity *fsEntity, components []string) *fsEntity {
 	if len(components) == 0 {
 		if entity.directory {
 			// A directory can be linked to if it contains an index file.
-			for _, index := range []string{"index.html", "index.htm", "index.tmpl"} {
+			for _, index := range indexFiles {
 				if ent, exists := entity.children[index]; exists {
 					return ent
 				}
@@ -189,37 +541,129 @@ func validate(website *Website, entity *fsEntity) []error {
 		return errors
 	}
 
+	return validateDocument(website, entity)
+}
+
+func validateDocument(website *Website, entity *fsEntity) []error {
+	var errors []error
+
+	span := website.tracer.StartSpan("linkup.resolve", map[string]string{"linkup.document": entity.fullname})
+	defer span.End(nil)
+
+	if website.internalChecks {
+		errors = append(errors, validateSlugCollisions(website, entity)...)
+	}
+	errors = append(errors, validateCitations(website, entity)...)
+	errors = append(errors, validateMetadata(website, entity)...)
+	errors = append(errors, validateHeadingHierarchy(website, entity)...)
+	errors = append(errors, validateTOC(website, entity)...)
+	errors = append(errors, validatePagination(website, entity)...)
+	errors = append(errors, validateBreadcrumbs(website, entity)...)
+	errors = append(errors, validateNotFoundPage(website, entity)...)
+	errors = append(errors, validateLinkStyle(website, entity)...)
+	errors = append(errors, validateTargetBlank(website, entity)...)
+	errors = append(errors, validateRelPolicies(website, entity)...)
+	errors = append(errors, validateClickDepth(website, entity)...)
+	errors = append(errors, validateParseIssues(website, entity)...)
+	errors = append(errors, validateLang(website, entity)...)
+	errors = append(errors, validateLocaleParity(website, entity)...)
+	errors = append(errors, validateDuplicate(website, entity)...)
+	errors = append(errors, validateAMP(website, entity)...)
+	errors = append(errors, validatePixels(website, entity)...)
+	errors = append(errors, validateEPUB(website, entity)...)
+
 	for name, count := range entity.ids {
-		if count > 1 {
-			errors = append(errors, fmt.Errorf("%s: id '%s' appears %d times on the page (it should only appear once)", entity.fullname, name, count))
+		if count > 1 && website.internalChecks {
+			errors = append(errors, website.newLinkError(entity, "", KindDocument, "id '%s' appears %d times on the page (it should only appear once)", name, count))
 		}
 	}
 
-	for _, href := range entity.hrefs {
-		// Perform some sanitization on the string.
-		href = strings.TrimSpace(href)
-		href = strings.Replace(href, "\\", "/", -1)
-		if uhref, err := url.QueryUnescape(href); err == nil {
-			href = uhref
+	for _, raw := range entity.hrefs {
+		href := sanitizeHref(raw)
+
+		if err := checkMalformed(website, entity, raw); err != nil {
+			errors = append(errors, err)
+		}
+
+		if website.emailMode {
+			if err, handled := checkEmailLink(website, entity, raw, href); handled {
+				if err != nil {
+					errors = append(errors, err)
+				}
+				continue
+			}
 		}
 
 		// Check if this is a website URL.
-		if strings.HasPrefix(href, "http") {
-			// Ping the URL and make sure it's active.
-			status, err := ping(website, href)
-			if err != nil {
-				errors = append(errors, fmt.Errorf("%s: encountered error when pinging '%s'", entity.fullname, href))
-			} else if status != 200 {
-				errors = append(errors, fmt.Errorf("%s: encountered status code %d when pinging '%s'", entity.fullname, status, href))
+		if website.isExternal(href) {
+			if err := checkCanonicalHost(website, entity, raw, href); err != nil {
+				errors = append(errors, err)
+			}
+			if err := checkSchemeAndPort(website, entity, raw, href); err != nil {
+				errors = append(errors, err)
+			}
+
+			// Links to the website itself are resolved against the registered files below.
+			if path, site := website.sitePath(href); site {
+				href = path
+			} else {
+				if err := checkTracking(website, entity, raw, href); err != nil {
+					errors = append(errors, err)
+				}
+				if err := checkDocVersion(website, entity, raw, href); err != nil {
+					errors = append(errors, err)
+				}
+
+				if !website.externalChecks {
+					if website.reportUnchecked {
+						errors = append(errors, website.newFinding(entity, raw, SeverityInfo, KindUnchecked, "unchecked external link '%s'", href))
+					}
+					continue
+				}
+
+				// Ping the URL and make sure it's active.
+				if err := checkExternal(website, entity, raw, href); err != nil {
+					errors = append(errors, err)
+				}
+				continue
+			}
+		}
+
+		if !website.internalChecks {
+			continue
+		}
+
+		if len(href) == 0 && len(strings.TrimSpace(raw)) == 0 {
+			if err := checkEmpty(website, entity, raw); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		if isBrowserURL(href) {
+			errors = append(errors, website.newFinding(entity, raw, SeverityInfo, KindUnchecked, "skipped browser-generated link '%s'", href))
+			continue
+		}
+
+		if isDataURI(href) {
+			if err := checkDataURI(website, entity, raw); err != nil {
+				errors = append(errors, err)
 			}
 			continue
 		}
 
 		if href == "#" {
-			errors = append(errors, fmt.Errorf("%s: incomplete target '#'", entity.fullname))
+			errors = append(errors, website.newLinkError(entity, raw, KindAnchor, "incomplete target '#'"))
 			continue
 		}
 
+		stripped, inside := website.stripBasePath(href)
+		if !inside {
+			errors = append(errors, website.newLinkError(entity, raw, KindBroken, "link '%s' is outside the base path '%s'", href, website.basePath))
+			continue
+		}
+		href = stripped
+
 		if href == "/" {
 			continue
 		}
@@ -228,8 +672,16 @@ func validate(website *Website, entity *fsEntity) []error {
 		if hashIndex == 0 {
 			_, i := utf8.DecodeRuneInString(href)
 			target := href[i:]
+			if website.isClientRoute(target) {
+				if !website.matchesClientRoute(target) {
+					errors = append(errors, website.newLinkError(entity, raw, KindBroken, "broken client route '%s'", href))
+				}
+				continue
+			}
 			if _, exists := entity.ids[target]; !exists {
-				errors = append(errors, fmt.Errorf("%s: broken same page link '%s'", entity.fullname, href))
+				errors = append(errors, website.newLinkError(entity, raw, KindAnchor, "broken same page link '%s'", href))
+			} else if reason, hidden := entity.hidden[target]; hidden {
+				errors = append(errors, website.newFinding(entity, raw, SeverityWarning, KindPolicy, "same page link '%s' targets an element hidden by %s", href, reason))
 			}
 			continue
 		}
@@ -241,21 +693,42 @@ func validate(website *Website, entity *fsEntity) []error {
 			href = strings.TrimSpace(href[:hashIndex])
 		}
 
+		if to, redirected := website.redirect(entity, href); redirected {
+			errors = append(errors, website.checkRedirect(entity, raw, href, to))
+			continue
+		}
+
 		if strings.HasPrefix(href, "/") {
-			if targetEnt = isPathValid(website.root, splitPath(href)); targetEnt == nil {
-				errors = append(errors, fmt.Errorf("%s: broken link '%s'", entity.fullname, href))
+			if targetEnt = website.resolvePath(website.root, href); targetEnt == nil {
+				errors = append(errors, website.newLinkError(entity, raw, KindBroken, "broken link '%s'%s", href, website.brokenHint(entity, href)))
 				continue
 			}
 		} else {
-			if targetEnt = isPathValid(entity.parent, splitPath(href)); targetEnt == nil {
-				errors = append(errors, fmt.Errorf("%s: broken relative link '%s'", entity.fullname, href))
+			if targetEnt = website.resolvePath(entity.parent, href); targetEnt == nil {
+				errors = append(errors, website.newLinkError(entity, raw, KindBroken, "broken relative link '%s'%s", href, website.brokenHint(entity, href)))
 				continue
 			}
 		}
 
-		if hashIndex > 0 {
+		if website.isUnpublished(targetEnt) && !website.isUnpublished(entity) {
+			errors = append(errors, website.newLinkError(entity, raw, KindBroken, "link '%s' targets unpublished content", href))
+			continue
+		}
+
+		if !website.isDeployed(targetEnt) {
+			errors = append(errors, website.newLinkError(entity, raw, KindBroken, "link '%s' targets '%s' which is missing from the deployment", href, targetEnt.fullname))
+			continue
+		}
+
+		if hashIndex > 0 && website.isClientRoute(target) {
+			if !website.matchesClientRoute(target) {
+				errors = append(errors, website.newLinkError(entity, raw, KindBroken, "broken client route '%s#%s'", href, target))
+			}
+		} else if hashIndex > 0 {
 			if _, exists := targetEnt.ids[target]; !exists {
-				errors = append(errors, fmt.Errorf("%s: broken target link '%s#%s'", entity.fullname, href, target))
+				errors = append(errors, website.newLinkError(entity, raw, KindAnchor, "broken target link '%s#%s'", href, target))
+			} else if reason, hidden := targetEnt.hidden[target]; hidden {
+				errors = append(errors, website.newFinding(entity, raw, SeverityWarning, KindPolicy, "target link '%s#%s' targets an element hidden by %s", href, target, reason))
 			}
 		}
 	}
@@ -263,6 +736,25 @@ func validate(website *Website, entity *fsEntity) []error {
 	return errors
 }
 
+// isBrowserURL reports whether the link uses a scheme generated by the browser, such as blob:.
+func isBrowserURL(href string) bool {
+	for _, scheme := range browserSchemes {
+		if len(href) >= len(scheme) && strings.EqualFold(href[:len(scheme)], scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeHref(href string) string {
+	href = strings.TrimSpace(href)
+	href = strings.Replace(href, "\\", "/", -1)
+	if uhref, err := url.QueryUnescape(href); err == nil {
+		href = uhref
+	}
+	return href
+}
+
 func prepareFileName(name string) string {
 	// Strip away any leading slash since all files should be relative to the root.
 	if strings.HasPrefix(name, "/") {
@@ -272,12 +764,10 @@ func prepareFileName(name string) string {
 	return name
 }
 
+// allocateFSEntity allocates an entity outside of the arena, which is only done for the root.
+// Maps are allocated by the caller once it is known whether the entity is a directory or a document.
 func allocateFSEntity(name string) *fsEntity {
-	return &fsEntity{
-		name:     name,
-		ids:      make(map[string]int),
-		children: make(map[string]*fsEntity),
-	}
+	return &fsEntity{name: name}
 }
 
 func calcFullName(entity *fsEntity) string {
@@ -294,24 +784,25 @@ func calcFullName(entity *fsEntity) string {
 	return fullname
 }
 
-func createFSEntity(parent *fsEntity, components []string) *fsEntity {
+func createFSEntity(arena *entityArena, parent *fsEntity, components []string) *fsEntity {
 	if len(components) == 0 {
 		return parent
 	}
 
 	if parent.directory {
 		if child, exists := parent.children[components[0]]; exists {
-			return createFSEntity(child, components[1:])
+			return createFSEntity(arena, child, components[1:])
 		}
 
-		child := allocateFSEntity(components[0])
+		child := arena.allocate(components[0])
 		child.parent = parent
 		child.fullname = calcFullName(child)
-		parent.children[components[0]] = child
+		parent.children[child.name] = child
 
 		if len(components) > 1 {
 			child.directory = true
-			return createFSEntity(child, components[1:])
+			child.children = make(map[string]*fsEntity)
+			return createFSEntity(arena, child, components[1:])
 		}
 		return child
 	}
@@ -321,29 +812,6 @@ func createFSEntity(parent *fsEntity, components []string) *fsEntity {
 	return nil
 }
 
-func newFSEntity(root *fsEntity, path string) *fsEntity {
-	return createFSEntity(root, strings.Split(path, "/"))
-}
-
-func ping(website *Website, url string) (int, error) {
-	if code, exists := website.pingResults[url]; exists {
-		return code, nil
-	}
-	var client = http.Client{
-		Timeout:   2 * time.Second,
-		Transport: &http.Transport{},
-	}
-	req, err := http.NewRequest("HEAD", url, nil)
-	if err != nil {
-		website.pingResults[url] = 0
-		return 0, err
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		website.pingResults[url] = 0
-		return 0, err
-	}
-	resp.Body.Close()
-	website.pingResults[url] = resp.StatusCode
-	return resp.StatusCode, nil
+func (w *Website) newFSEntity(path string) *fsEntity {
+	return createFSEntity(w.arena, w.root, strings.Split(path, "/"))
 }
@@ -17,9 +17,37 @@
 package linkup
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLinksFromRoot(t *testing.T) {
@@ -59,19 +87,2389 @@ func TestInvalidRelativeLinks(t *testing.T) {
 }
 
 func TestExternalLinks(t *testing.T) {
+	w := New(WithExternalChecker(fakeChecker{}))
+	addWebsite("testdata/external", w)
+	errs := w.Validate()
+	verifyErrors(t, errs, []string{})
+}
+
+func TestInvalidExternalLinks(t *testing.T) {
+	w := New(WithExternalChecker(fakeChecker{
+		"https://www.google.com/does_not_exist":   {StatusCode: 404},
+		"https://fake12371ivnd985Vkf8K98Qnm.com/": {Err: errors.New("no such host")},
+	}))
+	addWebsite("testdata/external_error", w)
+	errs := w.Validate()
+	verifyErrors(t, errs, []string{
+		"index.html: encountered status code 404 when pinging 'https://www.google.com/does_not_exist'",
+		"index.html: encountered error when pinging 'https://fake12371ivnd985Vkf8K98Qnm.com/'",
+	})
+}
+
+func TestExternalResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "13")
+	}))
+	defer server.Close()
+
+	w := New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="`+server.URL+`/old">Old</a>`))
+	verifyErrors(t, w.Validate(), []string{})
+
+	results := w.ExternalResults()
+	if len(results) != 1 {
+		t.Fatal("Unexpected result count", len(results))
+	}
+	result := results[0]
+	if result.URL != server.URL+"/old" || result.FinalURL != server.URL+"/new" {
+		t.Error("Unexpected URLs", result.URL, result.FinalURL)
+	}
+	if result.StatusCode != 200 || result.ContentType != "text/plain" || result.ContentLength != 13 || result.Err != nil {
+		t.Error("Unexpected result", result)
+	}
+	if !result.CertExpiry.IsZero() {
+		t.Error("Unexpected certificate expiry", result.CertExpiry)
+	}
+}
+
+func TestGetFallbackLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write(bytes.Repeat([]byte("a"), 100))
+	}))
+	defer server.Close()
+
+	checker := &HTTPChecker{GetFallback: true, MaxResponseBytes: 10, MaxTotalBytes: 15}
+	for i := 0; i < 2; i++ {
+		if result := checker.Check(context.Background(), server.URL); result.Err != nil || result.StatusCode != 200 {
+			t.Error("Unexpected result", result)
+		}
+	}
+	if result := checker.Check(context.Background(), server.URL); result.Err != errBandwidthExceeded {
+		t.Error("Expected the bandwidth limit to be exceeded", result)
+	}
+	if checker.spent != 15 {
+		t.Error("Unexpected bytes read", checker.spent)
+	}
+
+	checker = &HTTPChecker{}
+	if result := checker.Check(context.Background(), server.URL); result.StatusCode != http.StatusMethodNotAllowed {
+		t.Error("Unexpected fallback", result)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	w := New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="`+server.URL+`/a">A</a><a href="`+server.URL+`/b">B</a><a href="`+server.URL+`/c">C</a>`))
+	verifyErrors(t, w.Validate(), []string{})
+	if n := atomic.LoadInt32(&connections); n != 1 {
+		t.Error("Connections were not reused", n)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	checker := &countingChecker{err: errors.New("connection refused")}
+	w := New(WithExternalChecker(checker), WithCircuitBreaker(2))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://down.example.com/a">A</a>
+		<a href="https://down.example.com/b">B</a>
+		<a href="https://down.example.com/c">C</a>
+		<a href="https://down.example.com/d">D</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered error when pinging 'https://down.example.com/a'",
+		"index.html: encountered error when pinging 'https://down.example.com/b'",
+		"index.html: host unreachable when pinging 'https://down.example.com/c'",
+		"index.html: host unreachable when pinging 'https://down.example.com/d'",
+	})
+	if checker.checks != 2 {
+		t.Error("Unexpected check count", checker.checks)
+	}
+}
+
+// countingChecker fails every check with the same error and counts them.
+type countingChecker struct {
+	err    error
+	checks int
+}
+
+func (c *countingChecker) Check(ctx context.Context, url string) ExternalResult {
+	c.checks++
+	return ExternalResult{URL: url, Err: c.err}
+}
+
+// serveFTP answers FTP commands for a server holding the file /pub/file.zip.
+func serveFTP(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				text := textproto.NewConn(conn)
+				text.PrintfLine("220 ready")
+				for {
+					line, err := text.ReadLine()
+					if err != nil {
+						return
+					}
+					switch line {
+					case "USER anonymous":
+						text.PrintfLine("331 password required")
+					case "PASS anonymous@":
+						text.PrintfLine("230 logged in")
+					case "TYPE I":
+						text.PrintfLine("200 binary mode")
+					case "SIZE /pub/file.zip":
+						text.PrintfLine("213 1024")
+					case "CWD /pub", "CWD /pub/":
+						text.PrintfLine("250 directory changed")
+					case "QUIT":
+						text.PrintfLine("221 bye")
+						return
+					default:
+						text.PrintfLine("550 not found")
+					}
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestFTPLinks(t *testing.T) {
+	listener := serveFTP(t)
+	defer listener.Close()
+	host := listener.Addr().String()
+
+	w := New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="ftp://`+host+`/pub/file.zip">File</a>
+		<a href="ftp://`+host+`/pub/">Directory</a>
+		<a href="ftp://`+host+`/pub">Directory</a>
+		<a href="ftp://`+host+`/pub/missing.zip">Missing</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 404 when pinging 'ftp://" + host + "/pub/missing.zip'",
+	})
+
+	for _, result := range w.ExternalResults() {
+		if strings.HasSuffix(result.URL, "file.zip") && result.ContentLength != 1024 {
+			t.Error("Unexpected file size", result.ContentLength)
+		}
+		if result.Latency <= 0 {
+			t.Error("Latency was not measured", result.URL)
+		}
+	}
+}
+
+func TestWebSocketLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/socket" || r.Header.Get("Upgrade") != "websocket" {
+			http.NotFound(w, r)
+			return
+		}
+		digest := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		w.Header().Set("Upgrade", "websocket")
+		w.Header().Set("Connection", "Upgrade")
+		w.Header().Set("Sec-WebSocket-Accept", base64.StdEncoding.EncodeToString(digest[:]))
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	document := `
+		<a href="ws://` + host + `/socket">Socket</a>
+		<a href="ws://` + host + `/missing">Missing</a>`
+
+	w := New(WithWebSocketChecker(&WebSocketChecker{}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 404 when pinging 'ws://" + host + "/missing'",
+	})
+	for _, result := range w.ExternalResults() {
+		if result.Latency <= 0 {
+			t.Error("Latency was not measured", result.URL)
+		}
+	}
+
+	w = New(WithWebSocketChecker(&WebSocketChecker{ConnectOnly: true}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{})
+}
+
+// serveLines answers each connection with the response for the first line the client sends.
+func serveLines(t *testing.T, listener net.Listener, responses map[string]string) {
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, err := textproto.NewReader(bufio.NewReader(conn)).ReadLine()
+				if err != nil {
+					return
+				}
+				io.WriteString(conn, responses[line])
+			}()
+		}
+	}()
+}
+
+func TestSmallWebLinks(t *testing.T) {
+	gopher, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gopher.Close()
+	gopherHost := gopher.Addr().String()
+	serveLines(t, gopher, map[string]string{
+		"":        "1Welcome\t/welcome\t" + gopherHost + "\r\n.\r\n",
+		"/about":  "About this server\r\n",
+		"/absent": "3'/absent' does not exist\terror.host\t1\r\n.\r\n",
+	})
+
+	// Borrow the self-signed certificate of an HTTPS test server.
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	gemini, err := tls.Listen("tcp", "127.0.0.1:0", server.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gemini.Close()
+	geminiHost := gemini.Addr().String()
+	serveLines(t, gemini, map[string]string{
+		"gemini://" + geminiHost + "/":      "20 text/gemini\r\n# Hello\r\n",
+		"gemini://" + geminiHost + "/old":   "31 /\r\n",
+		"gemini://" + geminiHost + "/gone":  "51 Not found\r\n",
+		"gemini://" + geminiHost + "/login": "60 Certificate required\r\n",
+	})
+
+	document := `
+		<a href="gopher://` + gopherHost + `/">Gopher</a>
+		<a href="gopher://` + gopherHost + `/0/about">About</a>
+		<a href="gopher://` + gopherHost + `/1/absent">Absent</a>
+		<a href="gemini://` + geminiHost + `/">Gemini</a>
+		<a href="gemini://` + geminiHost + `/old">Moved</a>
+		<a href="gemini://` + geminiHost + `/gone">Gone</a>
+		<a href="gemini://` + geminiHost + `/login">Login</a>`
+
+	w := New(WithSmallWebChecks(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 404 when pinging 'gopher://" + gopherHost + "/1/absent'",
+		"index.html: encountered status code 51 when pinging 'gemini://" + geminiHost + "/gone'",
+	})
+	for _, result := range w.ExternalResults() {
+		if result.Latency <= 0 {
+			t.Error("Latency was not measured", result.URL)
+		}
+	}
+}
+
+var registerIPFS sync.Once
+
+func TestSchemeHandlers(t *testing.T) {
+	registerIPFS.Do(func() {
+		RegisterSchemeHandler("IPFS", fakeChecker{
+			"ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/missing": {StatusCode: 404},
+		})
+	})
+	found := false
+	for _, scheme := range SchemeHandlers() {
+		found = found || scheme == "ipfs"
+	}
+	if !found {
+		t.Error("Registered scheme was not listed", SchemeHandlers())
+	}
+
+	w := New(WithSchemeHandler("magnet", fakeChecker{"magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a": {StatusCode: 404}}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/">Site</a>
+		<a href="ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/missing">Missing</a>
+		<a href="magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a">Torrent</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 404 when pinging 'ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/missing'",
+		"index.html: encountered status code 404 when pinging 'magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a'",
+	})
+
+	// Unregistering a scheme for a website treats its links as internal again.
+	w = New(WithSchemeHandler("ipfs", nil))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/">Site</a>`))
+	if errs := w.Validate(); len(errs) != 1 || len(w.ExternalResults()) != 0 {
+		t.Error("Unregistered scheme was checked", errs)
+	}
+}
+
+func TestCheckLevels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte(`<h2 id="install">Install</h2><a name="legacy"></a>`))
+	}))
+	defer server.Close()
+
+	w := New(
+		WithCheckLevel("https://news.example/*", CheckSkip),
+		WithCheckLevel("http://localhost:1/*", CheckDNS),
+		WithCheckLevel(server.URL+"/docs/*", CheckAnchor),
+		WithCheckLevel(server.URL+"/*", CheckGet),
+	)
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://news.example/today">News</a>
+		<a href="http://localhost:1/closed">DNS</a>
+		<a href="`+server.URL+`/docs/guide#install">Install</a>
+		<a href="`+server.URL+`/docs/guide#legacy">Legacy</a>
+		<a href="`+server.URL+`/docs/guide#missing">Missing</a>
+		<a href="`+server.URL+`/blog/#missing">Blog</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken external target link '" + server.URL + "/docs/guide#missing' (the anchor does not exist)",
+	})
+	if len(w.ExternalResults()) != 5 {
+		t.Error("Skipped link was checked", w.ExternalResults())
+	}
+
+	if !globMatch("*://*.example.com/*", "https://docs.example.com/a/b") || globMatch("https://?.com", "https://ab.com") {
+		t.Error("Unexpected glob match")
+	}
+}
+
+func TestPageTitles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/parked", http.StatusFound)
+		case "/parked":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><head><title>\n  This domain\n  is for sale </title></head></html>"))
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"title": "<title>Not a page</title>"}`))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<title>Guide</title>"))
+		}
+	}))
+	defer server.Close()
+
+	w := New(WithPageTitles(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="`+server.URL+`/guide">Guide</a>
+		<a href="`+server.URL+`/old">Old</a>
+		<a href="`+server.URL+`/data.json">Data</a>`))
+	verifyErrors(t, w.Validate(), []string{})
+	titles := make(map[string]string)
+	for _, result := range w.ExternalResults() {
+		titles[strings.TrimPrefix(result.URL, server.URL)] = result.Title + " " + strings.TrimPrefix(result.FinalURL, server.URL)
+	}
+	expected := map[string]string{"/guide": "Guide /guide", "/old": "This domain is for sale /parked", "/data.json": " /data.json"}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Error("Unexpected titles", titles)
+	}
+}
+
+func TestDocAnchors(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`<h3 id="NewRequest">func NewRequest</h3><h3 id="Client.Do">func (c *Client) Do</h3>`))
+	}))
+	defer server.Close()
+
+	w := New(WithDocAnchors(server.URL + "/pkg/*#*"))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="`+server.URL+`/pkg/net/http#NewRequest">NewRequest</a>
+		<a href="`+server.URL+`/pkg/net/http#Client.Do">Do</a>
+		<a href="`+server.URL+`/pkg/net/http#NewRequestWithContext2">Renamed</a>
+		<a href="`+server.URL+`/pkg/net/http">Package</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken documentation link '" + server.URL + "/pkg/net/http#NewRequestWithContext2' (the symbol 'NewRequestWithContext2' is no longer documented)",
+	})
+	// The anchored links share a single request and the unanchored link is checked with HEAD.
+	if requests != 2 {
+		t.Error("Unexpected number of requests", requests)
+	}
+}
+
+func TestDocVersionPolicy(t *testing.T) {
+	page := `
+		<a href="https://docs.example.com/en/latest/guide.html#setup">Latest</a>
+		<a href="https://docs.example.com/en/v2.3/guide.html">Pinned</a>
+		<a href="https://docs.python.org/3/library/os.html">Python</a>
+		<a href="https://docs.python.org/3.11/library/os.html">Python 3.11</a>`
+	validate := func(options ...Option) []error {
+		w := New(append(options, WithExternalChecks(false))...)
+		w.AddDocumentFromReader("index.html", strings.NewReader(page))
+		return w.Validate()
+	}
+
+	verifyErrors(t, validate(
+		WithDocVersionPolicy(DocVersionPolicy{Pattern: "https://docs.example.com/*", Latest: "latest", Pinned: "v2.4"}),
+		WithDocVersionPolicy(DocVersionPolicy{Pattern: "https://docs.python.org/*", Latest: "3"})), []string{
+		"index.html: link 'https://docs.example.com/en/latest/guide.html#setup' follows the latest documentation (pin it as 'https://docs.example.com/en/v2.4/guide.html#setup')",
+		"index.html: link 'https://docs.python.org/3/library/os.html' follows the latest documentation (pin it to a version)",
+	})
+	verifyErrors(t, validate(
+		WithDocVersionPolicy(DocVersionPolicy{Pattern: "https://docs.example.com/*", Latest: "latest", PreferLatest: true}),
+		WithDocVersionPolicy(DocVersionPolicy{Pattern: "https://docs.python.org/3.*", Latest: "3", PreferLatest: true})), []string{
+		"index.html: link 'https://docs.example.com/en/v2.3/guide.html' is pinned to version 'v2.3' (follow the latest as 'https://docs.example.com/en/latest/guide.html')",
+		"index.html: link 'https://docs.python.org/3.11/library/os.html' is pinned to version '3.11' (follow the latest as 'https://docs.python.org/3/library/os.html')",
+	})
+}
+
+func TestGroupByHost(t *testing.T) {
+	w := New(WithExternalChecker(fakeChecker{
+		"https://docs.example.com/a": {StatusCode: 404},
+		"https://docs.example.com/b": {StatusCode: 410},
+		"https://docs.example.com/c": {Err: errors.New("timeout")},
+		"https://Other.example.com/": {StatusCode: 500},
+	}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://docs.example.com/a">A</a>
+		<a href="https://docs.example.com/b">B</a>
+		<a href="https://docs.example.com/c">C</a>
+		<a href="https://Other.example.com/">Other</a>
+		<a href="https://fine.example.com/">Fine</a>
+		<a href="missing.html">Missing</a>`))
+
+	summaries := GroupByHost(w.Validate())
+	expected := []HostSummary{
+		{Host: "docs.example.com", Findings: 3, Failures: 1, WorstStatus: 410},
+		{Host: "other.example.com", Findings: 1, WorstStatus: 500},
+	}
+	if len(summaries) != len(expected) {
+		t.Fatal("Unexpected summaries", summaries)
+	}
+	for i := range summaries {
+		if summaries[i] != expected[i] {
+			t.Error("Unexpected summary", summaries[i], expected[i])
+		}
+	}
+}
+
+func TestGroupByTarget(t *testing.T) {
+	w := New(WithExternalChecker(fakeChecker{"https://example.com/gone": {StatusCode: 404}}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="docs/missing.html">Missing</a>
+		<a href="https://example.com/gone">Gone</a>
+		<a href="docs/a b/">Space</a>
+		<a href="#top">Top</a>`))
+	w.AddDocumentFromReader("docs/index.html", strings.NewReader(`<a href="missing.html">Missing</a><a href="/docs/missing.html">Missing</a>`))
+	w.AddDocumentFromReader("blog/index.html", strings.NewReader(`<a href="https://example.com/gone">Gone</a>`))
+
+	expected := []FindingGroup{
+		{Target: "/docs/missing.html", Severity: SeverityError, Messages: []string{
+			"broken link '/docs/missing.html'", "broken relative link 'docs/missing.html'", "broken relative link 'missing.html'",
+		}, Documents: []string{"docs/index.html", "index.html"}},
+		{Target: "https://example.com/gone", Severity: SeverityError, Messages: []string{
+			"encountered status code 404 when pinging 'https://example.com/gone'",
+		}, Documents: []string{"blog/index.html", "index.html"}},
+		{Target: "/docs/a b/", Severity: SeverityError, Messages: []string{
+			"broken relative link 'docs/a b/'", "malformed URL 'docs/a b/' (a space at offset 6 should be percent-encoded)",
+		}, Documents: []string{"index.html"}},
+		{Target: "/index.html#top", Severity: SeverityError, Messages: []string{
+			"broken same page link '#top'",
+		}, Documents: []string{"index.html"}},
+	}
+	if actual := GroupByTarget(w.Validate()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected groups %q", actual)
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	fingerprints := func(page string) []string {
+		w := New()
+		w.AddDocumentFromReader("index.html", strings.NewReader(page))
+		var prints []string
+		for _, err := range w.Validate() {
+			prints = append(prints, err.(*LinkError).Fingerprint)
+		}
+		return prints
+	}
+
+	before := fingerprints(`<a href="missing.html">A</a><a href="#gone">B</a>`)
+	after := fingerprints(`<p>Moved</p><a href="#gone">B</a><a href=" missing.html">A</a>`)
+	if len(before) != 2 || len(after) != 2 || before[0] == before[1] {
+		t.Fatal("Unexpected fingerprints", before, after)
+	}
+	if before[0] != after[1] || before[1] != after[0] {
+		t.Error("Fingerprints are not stable", before, after)
+	}
+	if other := fingerprints(`<a href="other.html">A</a>`); other[0] == before[0] {
+		t.Error("Fingerprints of different links collide")
+	}
+
+	// Translating messages must not invalidate baselines.
+	translated := New(WithCatalog(GermanCatalog))
+	translated.AddDocumentFromReader("index.html", strings.NewReader(`<a href="missing.html">A</a><a href="#gone">B</a>`))
+	errs := translated.Validate()
+	if len(errs) != 2 || errs[0].(*LinkError).Fingerprint != before[0] || errs[1].(*LinkError).Fingerprint != before[1] {
+		t.Error("Fingerprints depend on the translation", errs)
+	}
+
+	// Distinct findings on the same page, or for the same link, must not collide.
+	w := New(WithStrictParsing(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<!doctype html><html lang="en"><head><title>T</title></head><body>`+
+		`<p id="a">A</p><p id="a">A</p><p id="b">B</p><p id="b">B</p><div><span></div></body></html>`))
+	errs = w.Validate()
+	if len(errs) < 3 {
+		t.Fatal("Unexpected findings", errs)
+	}
+	seen := make(map[string]string)
+	for _, err := range errs {
+		finding := err.(*LinkError)
+		if other, exists := seen[finding.Fingerprint]; exists {
+			t.Errorf("Findings '%s' and '%s' share a fingerprint", other, finding.Message)
+		}
+		seen[finding.Fingerprint] = finding.Message
+	}
+	entity := &fsEntity{fullname: "index.html"}
+	if w.finding(entity, "a.html", SeverityWarning, KindPolicy, "link '%s' has tracking parameters", "a.html").Fingerprint ==
+		w.finding(entity, "a.html", SeverityWarning, KindPolicy, "link '%s' opens a new window without rel=noopener", "a.html").Fingerprint {
+		t.Error("Fingerprints of different rules for the same link collide")
+	}
+}
+
+func TestSeverityOverrides(t *testing.T) {
+	w := New(WithExternalChecker(fakeChecker{
+		"https://www.linkedin.com/in/someone": {StatusCode: 403},
+		"https://example.com/private":         {StatusCode: 403},
+		"https://example.com/gone":            {StatusCode: 404},
+	}),
+		WithSeverityOverride(SeverityOverride{Link: "*://*.linkedin.com/*", Status: 403, Severity: SeverityInfo}),
+		WithSeverityOverride(SeverityOverride{Message: "broken same page link*", Severity: SeverityWarning}),
+		WithSeverityOverride(SeverityOverride{Link: "*://*.linkedin.com/*", Severity: SeverityError}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://www.linkedin.com/in/someone">Profile</a>
+		<a href="https://example.com/private">Private</a>
+		<a href="https://example.com/gone">Gone</a>
+		<a href="#missing">Missing</a>`))
+
+	expected := map[string]Severity{
+		"index.html: encountered status code 403 when pinging 'https://www.linkedin.com/in/someone'": SeverityInfo,
+		"index.html: encountered status code 403 when pinging 'https://example.com/private'":         SeverityError,
+		"index.html: encountered status code 404 when pinging 'https://example.com/gone'":            SeverityError,
+		"index.html: broken same page link '#missing'":                                               SeverityWarning,
+	}
+	errs := w.Validate()
+	if len(errs) != len(expected) {
+		t.Fatal("Unexpected findings", errs)
+	}
+	for _, err := range errs {
+		if severity, exists := expected[err.Error()]; !exists || err.(*LinkError).Severity != severity {
+			t.Error("Unexpected finding", err, err.(*LinkError).Severity)
+		}
+	}
+
+	// Kinds select findings regardless of the language of their messages.
+	w = New(WithCatalog(GermanCatalog), WithSeverityOverride(SeverityOverride{Kinds: []Kind{KindAnchor, KindRedirect}, Severity: SeverityInfo}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="#missing">Missing</a><a href="missing.html">Missing</a>`))
+	errs = w.Validate()
+	if len(errs) != 2 {
+		t.Fatal("Unexpected findings", errs)
+	}
+	for _, err := range errs {
+		finding := err.(*LinkError)
+		if (finding.Kind == KindAnchor) != (finding.Severity == SeverityInfo) {
+			t.Error("Unexpected finding", finding, finding.Severity)
+		}
+	}
+	if kind, ok := ParseKind("redirect"); !ok || kind != KindRedirect {
+		t.Error("Unexpected kind", kind)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	validate := func(options ...Option) []error {
+		w := New(append(options, WithExternalChecker(fakeChecker{"https://example.com/gone": {StatusCode: 404}}))...)
+		w.AddDocumentFromReader("index.html", strings.NewReader(`
+			<a href="missing.html">Missing</a>
+			<a href="https://example.com/gone">Gone</a>
+			<a href="#">Incomplete</a>`))
+		return w.Validate()
+	}
+
+	english := validate()
+	german := validate(WithCatalog(GermanCatalog))
+	verifyErrors(t, german, []string{
+		"index.html: defekter relativer Link 'missing.html'",
+		"index.html: Statuscode 404 beim Abruf von 'https://example.com/gone'",
+		"index.html: unvollständiges Ziel '#'",
+	})
+	verifyErrors(t, validate(WithCatalog(Catalog{"incomplete target '#'": "Ziel '#' fehlt"})), []string{
+		"index.html: broken relative link 'missing.html'",
+		"index.html: encountered status code 404 when pinging 'https://example.com/gone'",
+		"index.html: Ziel '#' fehlt",
+	})
+	for i := range english {
+		if english[i].(*LinkError).Fingerprint != german[i].(*LinkError).Fingerprint {
+			t.Error("Fingerprint depends on the catalog", english[i])
+		}
+	}
+}
+
+func TestFlakyLinks(t *testing.T) {
+	history := &History{Links: map[string][]bool{
+		"https://www.google.com/does_not_exist":   {true, false, true},
+		"https://fake12371ivnd985Vkf8K98Qnm.com/": {true, true, false},
+	}}
+	w := New(WithHistory(history), WithExternalChecker(fakeChecker{
+		"https://www.google.com/does_not_exist":   {StatusCode: 404},
+		"https://fake12371ivnd985Vkf8K98Qnm.com/": {Err: errors.New("no such host")},
+	}))
+	addWebsite("testdata/external_error", w)
+
+	errs := w.Validate()
+	verifyErrors(t, errs, []string{
+		"index.html: encountered status code 404 when pinging 'https://www.google.com/does_not_exist' (flaky)",
+		"index.html: encountered error when pinging 'https://fake12371ivnd985Vkf8K98Qnm.com/'",
+	})
+	for _, err := range errs {
+		flaky := strings.HasSuffix(err.Error(), "(flaky)")
+		if severity := err.(*LinkError).Severity; (severity == SeverityWarning) != flaky {
+			t.Error("Unexpected severity", err, severity)
+		}
+	}
+
+	if outcomes := history.Links["https://www.google.com/does_not_exist"]; len(outcomes) != 4 || outcomes[3] {
+		t.Error("Unexpected history", outcomes)
+	}
+}
+
+func TestFlakyNormalizedLinks(t *testing.T) {
+	// The history remembers links as they were checked, after normalization.
+	history := &History{Links: map[string][]bool{
+		"https://example.com/page": {true, false, true},
+	}}
+	w := New(WithHistory(history), WithNormalization(DefaultNormalization), WithExternalChecker(fakeChecker{
+		"https://example.com/page": {Err: errors.New("connection reset")},
+	}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="https://Example.com/page?utm_source=feed">Page</a>`))
+
+	errs := w.Validate()
+	verifyErrors(t, errs, []string{
+		"index.html: encountered error when pinging 'https://Example.com/page?utm_source=feed' (flaky)",
+	})
+	for _, err := range errs {
+		if severity := err.(*LinkError).Severity; severity != SeverityWarning {
+			t.Error("Unexpected severity", err, severity)
+		}
+	}
+}
+
+func TestContentDrift(t *testing.T) {
+	article := "<title>Configuring the widget</title><body><h1>Configuring the widget</h1>" +
+		"<p>The widget reads its settings from a file named widget.toml in the working directory. " +
+		"Every setting can be overridden with an environment variable of the same name in upper case. " +
+		"Restart the widget after changing its settings so they take effect, and check the log for errors.</p></body>"
+	page := article
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	history := &History{}
+	validate := func() []error {
+		w := New(WithHistory(history), WithContentDrift(true))
+		w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="`+server.URL+`/widget">Widget</a>`))
+		return w.Validate()
+	}
+	verifyErrors(t, validate(), []string{})
+	if len(history.Content) != 1 {
+		t.Fatal("Content hash was not remembered", history.Content)
+	}
+
+	page = strings.Replace(article, "check the log for errors", "check the widget log for warnings", 1)
+	verifyErrors(t, validate(), []string{})
+
+	page = "<title>This domain is for sale</title><body><p>Buy this domain today! Contact our brokers for pricing.</p>" +
+		"<script>var tracking = 'a b c d e f g h i j k l m n o p';</script></body>"
+	verifyErrors(t, validate(), []string{
+		"index.html: the content of '" + server.URL + "/widget' changed substantially since the last run (check it is still the intended page)",
+	})
+	verifyErrors(t, validate(), []string{})
+}
+
+func TestNormalization(t *testing.T) {
+	tests := []struct {
+		link     string
+		expected string
+	}{
+		{"https://WWW.Example.COM:443/a//b/./c/../d/?utm_source=x&id=1", "https://www.example.com/a/b/d/?id=1"},
+		{"http://example.com:80", "http://example.com"},
+		{"http://example.com:8080/", "http://example.com:8080/"},
+		{"https://example.com/?utm_medium=y", "https://example.com/"},
+		{"https://example.com/a?b=1&a=2", "https://example.com/a?b=1&a=2"},
+	}
+	for _, test := range tests {
+		if normalized := DefaultNormalization.Normalize(test.link); normalized != test.expected {
+			t.Error("Unexpected normalization", test.link, normalized, test.expected)
+		}
+	}
+
+	if normalized := (Normalization{}).Normalize("https://EXAMPLE.com//a"); normalized != "https://EXAMPLE.com//a" {
+		t.Error("Unexpected normalization", normalized)
+	}
+
+	checker := &countingChecker{}
+	w := New(WithNormalization(DefaultNormalization), WithExternalChecker(checker))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://example.com/docs/">A</a>
+		<a href="https://EXAMPLE.com:443/docs/?utm_source=newsletter">B</a>
+		<a href="https://example.com//docs/./">C</a>`))
+	w.Validate()
+	if checker.checks != 1 {
+		t.Error("Equivalent links were checked more than once", checker.checks)
+	}
+}
+
+func TestTrackingParams(t *testing.T) {
+	w := New(WithTrackingParams(DefaultTrackingParams), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://example.com/?utm_source=newsletter&utm_medium=email">A</a>
+		<a href="https://example.com/?FBCLID=abc&page=2">B</a>
+		<a href="https://example.com/app;jsessionid=1234">C</a>
+		<a href="https://example.com/?page=2">D</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: external link 'https://example.com/?utm_source=newsletter&utm_medium=email' carries tracking parameters (utm_medium, utm_source)",
+		"index.html: external link 'https://example.com/?FBCLID=abc&page=2' carries tracking parameters (FBCLID)",
+		"index.html: external link 'https://example.com/app;jsessionid=1234' carries tracking parameters (jsessionid)",
+	})
+}
+
+func TestArchivedCitations(t *testing.T) {
+	w := New(WithArchivedCitations(DefaultCitationSelector), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<ol class="references">
+			<li><a href="https://example.com/paper">Paper</a> (<a href="https://web.archive.org/web/2020/https://example.com/paper">archived</a>)</li>
+			<li><a href="https://example.com/report" data-archive-url="https://archive.ph/abc12">Report</a></li>
+			<li><a href="https://example.com/post">Post</a></li>
+		</ol>
+		<p><cite><a href="https://example.com/book">Book</a></cite></p>
+		<p><a href="https://example.com/unrelated">Not a citation</a></p>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: citation 'https://example.com/post' has no archived copy",
+		"index.html: citation 'https://example.com/book' has no archived copy",
+	})
+}
+
+func TestMetadataAudit(t *testing.T) {
+	w := New(WithMetadataAudit(true))
+	w.AddDocumentFromReader("complete.html", strings.NewReader(`<html><head>
+		<title>Complete</title>
+		<meta name="description" content="A complete page.">
+		<link rel="canonical" href="complete.html">
+		</head><body><h1>Complete</h1></body></html>`))
+	w.AddDocumentFromReader("incomplete.html", strings.NewReader(`<html><head>
+		<meta name="description" content="">
+		</head><body><h1>One</h1><h1>Two</h1></body></html>`))
+	verifyErrors(t, w.Validate(), []string{
+		"incomplete.html: document has no <title>",
+		"incomplete.html: document has 2 <h1> elements (it should only have one)",
+		"incomplete.html: document has no meta description",
+		"incomplete.html: document has no canonical link",
+	})
+}
+
+func TestHeadingHierarchy(t *testing.T) {
+	w := New(WithHeadingHierarchy(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<h1>Guide</h1>
+		<nav><a href="#install">Install</a> <a href="#usage">Usage</a></nav>
+		<h2 id="install">Install</h2>
+		<h4>Linux</h4>
+		<h3>Windows</h3>
+		<h2 id="configure">Configure</h2>
+		<h6>Advanced   options</h6>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: heading 'Linux' skips from <h2> to <h4>",
+		"index.html: heading 'Advanced options' skips from <h2> to <h6>",
+		"index.html: broken same page link '#usage'",
+	})
+}
+
+func TestTOCCheck(t *testing.T) {
+	w := New(WithTOCCheck(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<h1 id="guide">Guide</h1>
+		<nav id="TableOfContents"><ul>
+			<li><a href="#install">Install</a><ul><li><a href="#linux">Linux</a></li></ul></li>
+			<li><a href="#intro">Introduction</a></li>
+			<li><a href="#removed">Removed</a></li>
+		</ul></nav>
+		<p id="intro">Welcome.</p>
+		<h2 id="install">Install</h2>
+		<h3 id="linux">Linux</h3>
+		<h3 id="windows">Windows</h3>
+		<h2 id="usage">Usage</h2>
+		<h4 id="flags">Flags</h4>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: table of contents entry '#intro' does not link to a heading",
+		"index.html: broken same page link '#removed'",
+		"index.html: heading 'Windows' is missing from the table of contents",
+		"index.html: heading 'Usage' is missing from the table of contents",
+	})
+}
+
+func TestPaginationCheck(t *testing.T) {
+	w := New(WithPaginationCheck(true))
+	w.AddDocumentFromReader("blog/page1.html", strings.NewReader(`<link rel="next" href="page2.html">`))
+	w.AddDocumentFromReader("blog/page2.html", strings.NewReader(`<link rel="prev" href="page1.html"><link rel="next" href="/blog/page3.html">`))
+	w.AddDocumentFromReader("blog/page3.html", strings.NewReader(`<a rel="prev" href="page1.html">Newer</a>`))
+	w.AddDocumentFromReader("loop/a.html", strings.NewReader(`<link rel="next" href="b.html"><link rel="prev" href="b.html">`))
+	w.AddDocumentFromReader("loop/b.html", strings.NewReader(`<link rel="next" href="a.html"><link rel="prev" href="a.html">`))
+	verifyErrors(t, w.Validate(), []string{
+		"blog/page2.html: rel=next target '/blog/page3.html' does not link back with rel=prev",
+		"blog/page3.html: rel=prev target 'page1.html' does not link back with rel=next",
+		"loop/a.html: rel=next chain loops back to this page",
+	})
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	pages := map[string]string{
+		"index.html":      ``,
+		"docs/index.html": ``,
+		"docs/guide/index.html": `
+			<nav aria-label="Breadcrumb"><a href="/">Home</a> <a href="/docs/">Docs</a></nav>`,
+		"docs/guide/install.html": `
+			<ol itemscope itemtype="https://schema.org/BreadcrumbList">
+				<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><a itemprop="item" href="/"><span itemprop="name">Home</span></a></li>
+				<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><a itemprop="item" href="/docs/guide/"><span itemprop="name">Guide</span></a></li>
+				<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><a itemprop="item" href="/docs/"><span itemprop="name">Docs</span></a></li>
+			</ol>`,
+		"docs/guide/usage.html": `
+			<script type="application/ld+json">
+			{"@context": "https://schema.org", "@type": "BreadcrumbList", "itemListElement": [
+				{"@type": "ListItem", "position": 1, "item": "https://example.com/"},
+				{"@type": "ListItem", "position": 2, "item": {"@id": "https://example.com/documentation/"}},
+				{"@type": "ListItem", "position": 3, "item": "https://example.com/index.html"}
+			]}
+			</script>`,
+	}
+
+	newWebsite := func(policy BreadcrumbPolicy) *Website {
+		w := New(WithBreadcrumbs(policy))
+		for name, document := range pages {
+			w.AddDocumentFromReader(name, strings.NewReader(document))
+		}
+		return w
+	}
+
+	verifyErrors(t, newWebsite(BreadcrumbsResolve).Validate(), []string{
+		"docs/guide/usage.html: broken breadcrumb link 'https://example.com/documentation/'",
+	})
+
+	verifyErrors(t, newWebsite(BreadcrumbsAncestors).Validate(), []string{
+		"docs/guide/install.html: breadcrumb '/docs/' is out of order",
+		"docs/guide/usage.html: broken breadcrumb link 'https://example.com/documentation/'",
+		"docs/guide/usage.html: breadcrumb 'https://example.com/index.html' is out of order",
+	})
+
+	verifyErrors(t, newWebsite(BreadcrumbsExact).Validate(), []string{
+		"docs/guide/install.html: breadcrumb '/docs/' is out of order",
+		"docs/guide/usage.html: broken breadcrumb link 'https://example.com/documentation/'",
+		"docs/guide/usage.html: breadcrumb 'https://example.com/index.html' is out of order",
+		"docs/guide/usage.html: breadcrumb trail is missing '/docs/'",
+		"docs/guide/usage.html: breadcrumb trail is missing '/docs/guide/'",
+	})
+}
+
+func TestNotFoundPage(t *testing.T) {
+	document := `<html><head>
+		<link rel="stylesheet" href="css/site.css">
+		<link rel="icon" href="/favicon.ico">
+		<link rel="canonical" href="index.html">
+		</head><body>
+		<img src="img/lost.png" srcset="/img/lost.png 1x, img/lost@2x.png 2x">
+		<script src="https://cdn.example.com/app.js"></script>
+		</body></html>`
+
+	w := New(WithExternalChecks(false))
+	for _, name := range []string{"index.html", "css/site.css", "favicon.ico", "img/lost.png", "img/lost@2x.png"} {
+		w.AddFile(name)
+	}
+	w.AddDocumentFromReader("404.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"404.html: 404 page uses the relative asset URL 'css/site.css' (it breaks when served for deep URLs)",
+		"404.html: 404 page uses the relative asset URL 'img/lost.png' (it breaks when served for deep URLs)",
+		"404.html: 404 page uses the relative asset URL 'img/lost@2x.png' (it breaks when served for deep URLs)",
+	})
+
+	w = New(WithExternalChecks(false), WithNotFoundPage(""))
+	for _, name := range []string{"index.html", "css/site.css", "favicon.ico", "img/lost.png", "img/lost@2x.png"} {
+		w.AddFile(name)
+	}
+	w.AddDocumentFromReader("404.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{})
+}
+
+func TestLinkStyle(t *testing.T) {
+	document := `
+		<a href="guide.html">Relative</a>
+		<a href="/guide.html">Root-relative</a>
+		<a href="https://example.com/docs/guide.html">Absolute</a>
+		<a href="https://other.example/">External</a>
+		<a href="#top">Fragment</a>`
+
+	tests := []struct {
+		style    LinkStyle
+		expected []string
+	}{
+		{AnyLinkStyle, []string{}},
+		{PreferRelative, []string{
+			"docs/index.html: link '/guide.html' should be relative",
+			"docs/index.html: link 'https://example.com/docs/guide.html' should be relative",
+		}},
+		{PreferRootRelative, []string{
+			"docs/index.html: link 'guide.html' should be root-relative",
+			"docs/index.html: link 'https://example.com/docs/guide.html' should be root-relative",
+		}},
+		{PreferAbsolute, []string{
+			"docs/index.html: link 'guide.html' should be absolute",
+			"docs/index.html: link '/guide.html' should be absolute",
+		}},
+	}
+	for _, test := range tests {
+		w := New(WithLinkStyle(test.style), WithSiteURL("https://example.com/docs/"), WithExternalChecks(false))
+		w.AddFile("guide.html")
+		w.AddFile("docs/guide.html")
+		w.AddDocumentFromReader("docs/index.html", strings.NewReader(`<h1 id="top">Top</h1>`+document))
+		verifyErrors(t, w.Validate(), test.expected)
+	}
+}
+
+func TestUnpublished(t *testing.T) {
+	w := New(WithUnpublished("drafts", "/blog/2031-*"))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="blog/2020-launch.html">Launch</a>
+		<a href="blog/2031-roadmap.html">Roadmap</a>
+		<a href="/drafts/secret.html#intro">Secret</a>`))
+	w.AddDocumentFromReader("blog/2020-launch.html", strings.NewReader(``))
+	w.AddDocumentFromReader("blog/2031-roadmap.html", strings.NewReader(`<a href="../drafts/secret.html">Secret</a>`))
+	w.AddDocumentFromReader("drafts/secret.html", strings.NewReader(`<h2 id="intro">Intro</h2>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'blog/2031-roadmap.html' targets unpublished content",
+		"index.html: link '/drafts/secret.html' targets unpublished content",
+	})
+}
+
+func TestDeployment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "deployed.txt")
+	manifest := "./index.html\n" +
+		"2021-03-01 10:00:00       1024 docs/index.html\n" +
+		"drwxr-xr-x          4,096 2021/03/01 10:00:00 images\n" +
+		"-rw-r--r--         52,311 2021/03/01 10:00:00 images/logo one.png\n"
+	if err := ioutil.WriteFile(name, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := LoadDeployment(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(files, ",") != "index.html,docs/index.html,images/logo one.png" {
+		t.Fatal("Unexpected deployment", files)
+	}
+
+	w := New(WithDeployment(files))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="docs/">Docs</a>
+		<a href="docs/guide.html">Guide</a>
+		<img src="images/logo%20one.png">
+		<img src="/images/banner.png">`))
+	w.AddDocumentFromReader("docs/index.html", strings.NewReader(``))
+	w.AddDocumentFromReader("docs/guide.html", strings.NewReader(``))
+	w.AddFile("images/logo one.png")
+	w.AddFile("images/banner.png")
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'docs/guide.html' targets 'docs/guide.html' which is missing from the deployment",
+		"index.html: link '/images/banner.png' targets 'images/banner.png' which is missing from the deployment",
+	})
+}
+
+func TestAddArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []struct{ name, body string }{
+		{"./index.html", `<a href="docs/">Docs</a><img src="logo.png"><a href="missing.html">Missing</a>`},
+		{"docs/index.html", `<a href="../index.html">Home</a>`},
+		{"logo.png", ""},
+	}
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	zw.Create("docs/")
+	for _, file := range files {
+		fw, _ := zw.Create(file.name)
+		io.WriteString(fw, file.body)
+	}
+	zw.Close()
+
+	var tarred bytes.Buffer
+	gw := gzip.NewWriter(&tarred)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "docs/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, file := range files {
+		tw.WriteHeader(&tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(file.body))})
+		io.WriteString(tw, file.body)
+	}
+	tw.Close()
+	gw.Close()
+
+	for name, data := range map[string][]byte{"site.zip": zipped.Bytes(), "site.tar.gz": tarred.Bytes()} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		w := New()
+		if err := w.AddArchive(path); err != nil {
+			t.Fatal(name, err)
+		}
+		verifyErrors(t, w.Validate(), []string{
+			"index.html: broken relative link 'missing.html'",
+		})
+	}
+
+	if err := New().AddArchive(filepath.Join(dir, "site.rar")); err == nil {
+		t.Error("Expected an unsupported archive format")
+	}
+}
+
+func TestAddEPUB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []struct{ name, body string }{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"OEBPS/content.opf", `<package>
+			<manifest>
+				<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+				<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+				<item id="ch1" href="text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+				<item id="ch2" href="text/chapter2.xhtml" media-type="application/xhtml+xml"/>
+				<item id="notes" href="text/notes.xhtml" media-type="application/xhtml+xml"/>
+				<item id="cover" href="images/cover.jpg" media-type="image/jpeg"/>
+			</manifest>
+			<spine toc="ncx"><itemref idref="ch1"/><itemref idref="ch2"/><itemref idref="ch3"/></spine>
+		</package>`},
+		{"OEBPS/toc.ncx", `<ncx><navMap>
+			<navPoint><content src="text/chapter%201.xhtml#start"/><navPoint><content src="text/chapter2.xhtml#end"/></navPoint></navPoint>
+			<navPoint><content src="text/chapter3.xhtml"/></navPoint>
+		</navMap></ncx>`},
+		{"OEBPS/nav.xhtml", `<nav epub:type="toc"><ol><li><a href="text/chapter%201.xhtml">One</a></li><li><a href="text/notes.xhtml">Notes</a></li></ol></nav>`},
+		{"OEBPS/text/chapter 1.xhtml", `<h1 id="start">One</h1><a href="chapter2.xhtml#middle">Next</a>`},
+		{"OEBPS/text/chapter2.xhtml", `<h1 id="middle">Two</h1><a href="notes.xhtml">Notes</a>`},
+		{"OEBPS/text/notes.xhtml", `<p>Notes</p>`},
+		{"OEBPS/styles.css", ``},
+	}
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for _, file := range files {
+		fw, _ := zw.Create(file.name)
+		io.WriteString(fw, file.body)
+	}
+	zw.Close()
+
+	path := filepath.Join(dir, "book.epub")
+	if err := ioutil.WriteFile(path, zipped.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
 	w := New()
-	addWebsite("testdata/external", w)
+	if err := w.AddArchive(path); err != nil {
+		t.Fatal(err)
+	}
+	verifyErrors(t, w.Validate(), []string{
+		"OEBPS/content.opf: broken manifest item 'images/cover.jpg' (the file does not exist)",
+		"OEBPS/content.opf: file 'OEBPS/styles.css' is missing from the package manifest",
+		"OEBPS/content.opf: broken spine item 'ch3' (no manifest item has that id)",
+		"OEBPS/content.opf: content document 'text/notes.xhtml' is not in the spine",
+		"OEBPS/toc.ncx: broken target link 'text/chapter2.xhtml#end'",
+		"OEBPS/toc.ncx: broken table of contents link 'text/chapter3.xhtml'",
+		"OEBPS/nav.xhtml: table of contents link 'text/notes.xhtml' leads outside the spine",
+	})
+
+	if err := ioutil.WriteFile(path, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := New().AddEPUB(path); err == nil {
+		t.Error("Expected an invalid EPUB")
+	}
+}
+
+func TestAddBucket(t *testing.T) {
+	objects := map[string]string{
+		"site/index.html":       `<a href="about%20us.html">About</a><img src="img/logo.png"><a href="blog/">Blog</a>`,
+		"site/about us.html":    ``,
+		"site/img/":             ``,
+		"site/img/logo.png":     ``,
+		"other/blog/index.html": ``,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path == "/web" && query.Get("continuation-token") == "":
+			io.WriteString(w, `<ListBucketResult><Contents><Key>site/index.html</Key></Contents><Contents><Key>site/img/</Key></Contents>`+
+				`<IsTruncated>true</IsTruncated><NextContinuationToken>page2</NextContinuationToken></ListBucketResult>`)
+		case r.URL.Path == "/web":
+			io.WriteString(w, `<ListBucketResult><Contents><Key>site/about us.html</Key></Contents><Contents><Key>site/img/logo.png</Key></Contents>`+
+				`<IsTruncated>false</IsTruncated></ListBucketResult>`)
+		case strings.HasPrefix(r.URL.Path, "/web/"):
+			io.WriteString(w, objects[strings.TrimPrefix(r.URL.Path, "/web/")])
+		case r.URL.Path == "/storage/v1/b/web/o" && query.Get("pageToken") == "":
+			io.WriteString(w, `{"items": [{"name": "site/index.html"}, {"name": "site/about us.html"}], "nextPageToken": "page2"}`)
+		case r.URL.Path == "/storage/v1/b/web/o":
+			io.WriteString(w, `{"items": [{"name": "site/img/logo.png"}]}`)
+		case strings.HasPrefix(r.URL.Path, "/storage/v1/b/web/o/") && query.Get("alt") == "media":
+			io.WriteString(w, objects[strings.TrimPrefix(r.URL.Path, "/storage/v1/b/web/o/")])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, bucket := range []Bucket{
+		&S3Bucket{Endpoint: server.URL, Bucket: "web", Prefix: "site/"},
+		&GCSBucket{Endpoint: server.URL, Bucket: "web", Prefix: "site/"},
+	} {
+		w := New()
+		if err := w.AddBucket(context.Background(), bucket); err != nil {
+			t.Fatal(err)
+		}
+		verifyErrors(t, w.Validate(), []string{
+			"index.html: broken relative link 'blog/'",
+		})
+	}
+
+	if bucket, ok := ParseBucket("gs://web/site"); !ok || bucket.(*GCSBucket).Prefix != "site/" {
+		t.Error("Unexpected bucket", bucket)
+	}
+	if _, ok := ParseBucket("public"); ok {
+		t.Error("Expected a directory")
+	}
+}
+
+func TestAddImage(t *testing.T) {
+	type entry struct{ name, body, link string }
+	layer := func(compress bool, entries ...entry) []byte {
+		var buffer bytes.Buffer
+		var writer io.Writer = &buffer
+		var gw *gzip.Writer
+		if compress {
+			gw = gzip.NewWriter(&buffer)
+			writer = gw
+		}
+		tw := tar.NewWriter(writer)
+		for _, e := range entries {
+			header := &tar.Header{Name: e.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(e.body))}
+			if len(e.link) > 0 {
+				header = &tar.Header{Name: e.name, Typeflag: tar.TypeLink, Linkname: e.link}
+			}
+			tw.WriteHeader(header)
+			io.WriteString(tw, e.body)
+		}
+		tw.Close()
+		if gw != nil {
+			gw.Close()
+		}
+		return buffer.Bytes()
+	}
+
+	base := layer(false,
+		entry{name: "etc/nginx/nginx.conf"},
+		entry{name: "usr/share/nginx/html/index.html", body: `<a href="old.html">Old</a><a href="docs/a.html">A</a><a href="docs/b.html">B</a><a href="copy.html#top">Copy</a>`},
+		entry{name: "usr/share/nginx/html/old.html"},
+		entry{name: "usr/share/nginx/html/docs/a.html"})
+	top := layer(true,
+		entry{name: "usr/share/nginx/html/.wh.old.html"},
+		entry{name: "usr/share/nginx/html/docs/.wh..wh..opq"},
+		entry{name: "usr/share/nginx/html/docs/b.html", body: `<h1 id="top">B</h1>`},
+		entry{name: "usr/share/nginx/html/copy.html", link: "usr/share/nginx/html/docs/b.html"})
+
+	var image bytes.Buffer
+	tw := tar.NewWriter(&image)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"manifest.json", []byte(`[{"Config": "config.json", "Layers": ["base/layer.tar", "top/layer.tar"]}]`)},
+		{"base/layer.tar", base},
+		{"top/layer.tar", top},
+	} {
+		tw.WriteHeader(&tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(file.data))})
+		tw.Write(file.data)
+	}
+	tw.Close()
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	gw.Write(image.Bytes())
+	gw.Close()
+
+	for file, data := range map[string][]byte{"image.tar": image.Bytes(), "image.tar.gz": compressed.Bytes()} {
+		name := filepath.Join(dir, file)
+		if err := ioutil.WriteFile(name, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		w := New()
+		if err := w.AddImage(name, NginxWebRoot); err != nil {
+			t.Fatal(err)
+		}
+		verifyErrors(t, w.Validate(), []string{
+			"index.html: broken relative link 'old.html'",
+			"index.html: broken relative link 'docs/a.html'",
+		})
+	}
+
+	var oversized bytes.Buffer
+	tw = tar.NewWriter(&oversized)
+	manifest := `[{"Layers": []}]` + strings.Repeat(" ", maxImageManifestBytes)
+	tw.WriteHeader(&tar.Header{Name: "manifest.json", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(manifest))})
+	io.WriteString(tw, manifest)
+	tw.Close()
+	name := filepath.Join(dir, "oversized.tar")
+	if err := ioutil.WriteFile(name, oversized.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := New().AddImage(name, NginxWebRoot); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Error("Expected the oversized manifest to be rejected", err)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	register := func(w *Website) {
+		for i := 0; i < 50; i++ {
+			w.AddDocumentFromReader(fmt.Sprintf("page%02d.html", i), strings.NewReader(fmt.Sprintf(`
+				<h1 id="top">Page</h1>
+				<a href="page%02d.html#top">Next</a>
+				<a href="page%02d.html#bottom">Bottom</a>
+				<a href="%s/gone">Gone</a>`, (i+1)%50, (i+2)%50, server.URL)))
+		}
+	}
+
+	sequential := New()
+	register(sequential)
+	var expected []string
+	for _, err := range sequential.Validate() {
+		expected = append(expected, err.Error())
+	}
+	sort.Strings(expected)
+
+	concurrent := New(WithConcurrency(8))
+	register(concurrent)
+	errs := concurrent.Validate()
+	var actual []string
+	for _, err := range errs {
+		actual = append(actual, err.Error())
+	}
+	if !sort.StringsAreSorted(actual) {
+		t.Error("Findings were not merged in document order")
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") || len(actual) != 100 {
+		t.Error("Unexpected findings", actual)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	w := New(WithSuggestions())
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="docs/Getting-Started.html">Start</a>
+		<a href="/docs/instalation.html">Install</a>
+		<a href="docs/./faq.html">FAQ</a>
+		<a href="docs/zzz.html">Nothing</a>`))
+	w.AddDocumentFromReader("docs/getting-started.html", strings.NewReader(``))
+	w.AddDocumentFromReader("docs/installation.html", strings.NewReader(``))
+	w.AddDocumentFromReader("docs/faq.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken relative link 'docs/Getting-Started.html' (did you mean '/docs/getting-started.html'?)",
+		"index.html: broken link '/docs/instalation.html' (did you mean '/docs/installation.html'?)",
+		"index.html: broken relative link 'docs/./faq.html' (did you mean '/docs/faq.html'?)",
+		"index.html: broken relative link 'docs/zzz.html'",
+	})
+}
+
+func TestSummary(t *testing.T) {
+	w := New()
+	w.AddFile("style.css")
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<h1 id="top">Home</h1><link href="/style.css"><a href="docs/">Docs</a>`))
+	w.AddDocumentFromReader("docs/index.html", strings.NewReader(`<link href="/style.css"><a href="/#top">Home</a>`))
+
+	summary := w.Summary()
+	if summary.Documents != 2 || summary.Files != 1 || summary.Directories != 2 || summary.Links != 4 || summary.IDs != 1 {
+		t.Error("Unexpected summary", summary)
+	}
+	// The names "index.html" and the stylesheet links are shared.
+	if summary.InternedStrings != 6 {
+		t.Error("Unexpected number of interned strings", summary.InternedStrings)
+	}
+	if summary.MemoryBytes <= 0 {
+		t.Error("Expected a memory estimate")
+	}
+}
+
+// writeFiles creates the files beneath the directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// presetErrors validates the website configured by the preset.
+func presetErrors(t *testing.T, preset *Preset, err error) []error {
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := New(preset.Options...)
+	if err := w.AddDirectory(preset.Dir); err != nil {
+		t.Fatal(err)
+	}
+	return w.Validate()
+}
+
+func TestHugoPreset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"config.toml":                 "baseURL = \"https://example.com/docs/\" # The site.\ntitle = \"Docs\"\n\n[params]\nuglyURLs = true\n",
+		"content/posts/First-Post.md": "---\ntitle: First\naliases:\n  - /old/first/\n  - legacy.html\n---\nBody",
+		"content/posts/second.md":     "+++\naliases = [\"/old/second/\"]\nslug = \"gone\"\n+++\nBody",
+		"public/index.html": `
+			<a href="/docs/posts/first-post/">First</a>
+			<a href="/docs/old/first/">Old</a>
+			<a href="/docs/posts/legacy.html">Legacy</a>
+			<a href="/docs/old/second/index.html">Second</a>
+			<a href="/blog/">Blog</a>
+			<a href="/docs/posts/first-post/#heading">Heading</a>`,
+		"public/posts/first-post/index.html": `<h2>Heading</h2>`,
+		"public/old/first/index.html":        `<meta http-equiv="refresh" content="0; url=/docs/posts/first-post/">`,
+	})
+
+	preset, err := LoadPreset("hugo", dir)
+	if preset != nil && preset.Dir != filepath.Join(dir, "public") {
+		t.Error("Unexpected output directory", preset.Dir)
+	}
+	verifyErrors(t, presetErrors(t, preset, err), []string{
+		"index.html: link '/old/first/' redirects to '/posts/first-post/'",
+		"index.html: link '/posts/legacy.html' redirects to '/posts/first-post/'",
+		"index.html: broken link '/old/second/index.html' (it redirects to '/posts/gone/', which does not exist)",
+		"index.html: link '/blog/' is outside the base path '/docs/'",
+	})
+
+	// The output directory may be given instead of the project.
+	if preset, err := LoadPreset("Hugo", filepath.Join(dir, "public")); err != nil || preset.Dir != filepath.Join(dir, "public") {
+		t.Error("Unexpected preset", preset, err)
+	}
+	if _, err := LoadPreset("hugo", filepath.Join(dir, "content", "posts")); err == nil {
+		t.Error("Expected the Hugo configuration to be missing")
+	}
+}
+
+func TestJekyllPreset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"_config.yml":                      "title: Blog\nurl: \"https://example.com\"\nbaseurl: /blog\npermalink: pretty\nplugins:\n  - jekyll-redirect-from\n",
+		"_posts/2021-03-04-hello-world.md": "---\ncategories: News\nredirect_from:\n  - /hello/\n---\nBody",
+		"about.md":                         "---\nredirect_from: [\"/about-us/\"]\n---\nBody",
+		"moved.md":                         "---\nredirect_to: https://example.org/\n---\n",
+		"_drafts/draft.md":                 "---\nredirect_from: /draft/\n---\n",
+		"_site/index.html": `
+			<a href="/blog/hello/">Hello</a>
+			<a href="/blog/about-us/">About</a>
+			<a href="/blog/moved/">Moved</a>
+			<a href="/blog/draft/">Draft</a>
+			<a href="/blog/news/2021/03/04/hello-world/#hello">Post</a>`,
+		"_site/news/2021/03/04/hello-world/index.html": `<h1>Hello</h1>`,
+		"_site/about/index.html":                       ``,
+		"_site/draft/index.html":                       ``,
+	})
+
+	preset, err := LoadPreset("jekyll", filepath.Join(dir, "_site"))
+	verifyErrors(t, presetErrors(t, preset, err), []string{
+		"index.html: link '/hello/' redirects to '/news/2021/03/04/hello-world/'",
+		"index.html: link '/about-us/' redirects to '/about/'",
+		"index.html: link '/moved/' redirects to 'https://example.org/'",
+	})
+}
+
+func TestDocsPresets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"mkdocs/mkdocs.yml": "site_name: Docs\nsite_url: https://example.com/manual/\ntheme:\n  name: material\n",
+		"mkdocs/site/index.html": `
+			<h2>Hello, World!</h2>
+			<a href="#hello-world">Hello</a>
+			<a href="/manual/guide/#whats-new">Guide</a>
+			<a href="/manual/assets/style.css">Style</a>`,
+		"mkdocs/site/guide/index.html":           `<h2>What's  new?</h2>`,
+		"mkdocs/site/assets/style.css":           ``,
+		"mdbook/book.toml":                       "[book]\ntitle = \"Book\"\n\n[build]\nbuild-dir = \"out\"\n\n[output.html]\nsite-url = \"/book/\"\n",
+		"mdbook/out/index.html":                  `<a href="/book/chapter_1.html#getting-started">Start</a><a href="chapter_2.html">Next</a>`,
+		"mdbook/out/chapter_1.html":              `<h1>Getting Started</h1>`,
+		"docusaurus/docusaurus.config.js":        "module.exports = {\n  title: 'Docs',\n  url: 'https://example.com',\n  baseUrl: '/site/',\n};\n",
+		"docusaurus/build/index.html":            `<a href="/site/docs/intro#install">Intro</a><a href="/docs/intro">Outside</a>`,
+		"docusaurus/build/docs/intro/index.html": `<h2>Install</h2>`,
+	})
+
+	preset, err := LoadPreset("mkdocs", filepath.Join(dir, "mkdocs", "site"))
+	verifyErrors(t, presetErrors(t, preset, err), []string{})
+
+	preset, err = LoadPreset("mdbook", filepath.Join(dir, "mdbook"))
+	verifyErrors(t, presetErrors(t, preset, err), []string{
+		"index.html: broken relative link 'chapter_2.html'",
+	})
+
+	preset, err = LoadPreset("docusaurus", filepath.Join(dir, "docusaurus"))
+	verifyErrors(t, presetErrors(t, preset, err), []string{
+		"index.html: link '/docs/intro' is outside the base path '/site/'",
+	})
+
+	if _, err := LoadPreset("gatsby", dir); err == nil {
+		t.Error("Expected an unknown preset")
+	}
+}
+
+func TestGitHubPagesPreset(t *testing.T) {
+	repository, set := os.LookupEnv("GITHUB_REPOSITORY")
+	os.Unsetenv("GITHUB_REPOSITORY")
+	defer func() {
+		if set {
+			os.Setenv("GITHUB_REPOSITORY", repository)
+		}
+	}()
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"project/.git/config":   "[core]\n\tbare = false\n[remote \"origin\"]\n\turl = git@github.com:octo/handbook.git\n",
+		"project/docs/404.html": `<link rel="stylesheet" href="/handbook/style.css">`,
+		"project/docs/index.html": `
+			<a href="/handbook/_includes/nav.html">Nav</a>
+			<a href="/other/">Other</a>
+			<a href="https://octo.github.io/handbook/">Self</a>`,
+		"project/docs/style.css":          ``,
+		"project/docs/_includes/nav.html": ``,
+		"custom/CNAME":                    "docs.example.com\n",
+		"custom/.nojekyll":                ``,
+		"custom/index.html":               `<a href="/_static/app.js">App</a><a href="/handbook/">Handbook</a>`,
+		"custom/_static/app.js":           ``,
+	})
+
+	preset, err := LoadPreset("github-pages", filepath.Join(dir, "project", "docs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := New(append(preset.Options, WithLinkStyle(PreferRootRelative), WithExternalChecks(false))...)
+	if err := w.AddDirectory(preset.Dir); err != nil {
+		t.Fatal(err)
+	}
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link '/_includes/nav.html' targets unpublished content",
+		"index.html: link '/other/' is outside the base path '/handbook/'",
+		"index.html: link 'https://octo.github.io/handbook/' should be root-relative",
+	})
+
+	preset, err = LoadPreset("github-pages", filepath.Join(dir, "custom"))
+	verifyErrors(t, presetErrors(t, preset, err), []string{
+		"index.html: broken link '/handbook/'",
+	})
+}
+
+func TestTargetBlank(t *testing.T) {
+	w := New(WithTargetBlankCheck(true), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://example.com/a" target="_blank">A</a>
+		<a href="https://example.com/b" target="_BLANK" rel="noopener">B</a>
+		<a href="https://example.com/c" target="_blank" rel="external noreferrer">C</a>
+		<a href="https://example.com/d" target="_blank" rel="nofollow">D</a>
+		<a href="https://example.com/e" target="_self">E</a>
+		<a href="about.html" target="_blank">About</a>
+		<map><area href="https://example.com/f" target="_blank"></map>`))
+	w.AddDocumentFromReader("about.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'https://example.com/a' opens in a new tab without rel=\"noopener\"",
+		"index.html: link 'https://example.com/d' opens in a new tab without rel=\"noopener\"",
+		"index.html: link 'https://example.com/f' opens in a new tab without rel=\"noopener\"",
+	})
+}
+
+func TestRelPolicy(t *testing.T) {
+	w := New(WithExternalChecks(false),
+		WithRelPolicy("*.shop.example", RelPolicy{Require: []string{"nofollow", "sponsored"}}),
+		WithRelPolicy("partner.example", RelPolicy{Forbid: []string{"nofollow", "ugc"}}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://books.shop.example/item?ref=me" rel="Sponsored NOFOLLOW">A</a>
+		<a href="https://books.shop.example/item?ref=you" rel="sponsored">B</a>
+		<a href="https://toys.shop.example/">C</a>
+		<a href="https://partner.example/" rel="nofollow noopener">D</a>
+		<a href="https://partner.example/about">E</a>
+		<a href="https://other.example/">F</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'https://books.shop.example/item?ref=you' is missing rel=\"nofollow\"",
+		"index.html: link 'https://toys.shop.example/' is missing rel=\"nofollow sponsored\"",
+		"index.html: link 'https://partner.example/' must not have rel=\"nofollow\"",
+	})
+}
+
+func TestOutbound(t *testing.T) {
+	w := New(WithNormalization(DefaultNormalization))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://Example.com/a?utm_source=home">A</a>
+		<a href="https://example.com/b">B</a>
+		<script src="https://cdn.example.net/app.js"></script>
+		<a href="about.html">About</a>`))
+	w.AddDocumentFromReader("about.html", strings.NewReader(`<a href="https://example.com/a">A</a>`))
+
+	expected := []OutboundHost{
+		{Host: "example.com", Pages: 2, URLs: []OutboundURL{
+			{URL: "https://example.com/a", Documents: []string{"about.html", "index.html"}},
+			{URL: "https://example.com/b", Documents: []string{"index.html"}},
+		}},
+		{Host: "cdn.example.net", Pages: 1, URLs: []OutboundURL{
+			{URL: "https://cdn.example.net/app.js", Documents: []string{"index.html"}},
+		}},
+	}
+	if actual := w.Outbound(); !reflect.DeepEqual(actual, expected) {
+		t.Error("Unexpected outbound links", actual)
+	}
+}
+
+func TestPageMetrics(t *testing.T) {
+	w := New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="docs/">Docs</a>
+		<a href="#top">Top</a>
+		<a href="https://example.com/">Example</a>
+		<a href="mailto:team@example.com">Mail</a>
+		<a href="missing.html">Missing</a>`))
+	w.AddDocumentFromReader("docs/index.html", strings.NewReader(`<a href="guide.html">Guide</a><a href="/">Home</a><img src="/logo.png">`))
+	w.AddDocumentFromReader("docs/guide.html", strings.NewReader(`<a href="/docs/">Docs</a><a href="index.html#intro">Intro</a>`))
+	w.AddDocumentFromReader("orphan.html", strings.NewReader(`<a href="index.html">Home</a>`))
+	w.AddFile("logo.png")
+
+	expected := []PageMetrics{
+		{Document: "docs/guide.html", InternalLinks: 2, InboundLinks: 1, Depth: 2},
+		{Document: "docs/index.html", InternalLinks: 3, InboundLinks: 2, Depth: 1},
+		{Document: "index.html", InternalLinks: 2, ExternalLinks: 1, InboundLinks: 2, Depth: 0},
+		{Document: "orphan.html", InternalLinks: 1, Depth: -1},
+	}
+	if actual := w.Summary().Pages; !reflect.DeepEqual(actual, expected) {
+		t.Error("Unexpected page metrics", actual)
+	}
+}
+
+func TestMaxClickDepth(t *testing.T) {
+	w := New(WithMaxClickDepth(1))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="a.html">A</a>`))
+	w.AddDocumentFromReader("a.html", strings.NewReader(`<a href="b.html">B</a><a href="index.html">Home</a>`))
+	w.AddDocumentFromReader("b.html", strings.NewReader(`<a href="c.html">C</a>`))
+	w.AddDocumentFromReader("c.html", strings.NewReader(``))
+	w.AddDocumentFromReader("orphan.html", strings.NewReader(`<a href="c.html">C</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"b.html: page is 2 clicks from the home page (the maximum is 1)",
+		"c.html: page is 3 clicks from the home page (the maximum is 1)",
+	})
+}
+
+func TestRedirectChains(t *testing.T) {
+	w := New(WithRedirects(map[string]string{
+		"/old/":       "/older.html",
+		"/older.html": "/new/#top",
+		"/ping/":      "/pong/",
+		"/pong/":      "/ping/index.html",
+		"/away/":      "/moved/",
+		"/moved/":     "https://example.com/",
+		"/lost/":      "/gone/",
+		"/gone/":      "/missing.html",
+	}), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="/old/">Old</a>
+		<a href="/older.html">Older</a>
+		<a href="/ping/">Ping</a>
+		<a href="/away/">Away</a>
+		<a href="/lost/">Lost</a>`))
+	w.AddDocumentFromReader("new/index.html", strings.NewReader(`<h1 id="top">New</h1>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link '/old/' redirects through a chain: '/older.html' -> '/new/#top'",
+		"index.html: link '/older.html' redirects to '/new/#top'",
+		"index.html: broken link '/ping/' (it redirects in a loop: '/pong/' -> '/ping/index.html')",
+		"index.html: link '/away/' redirects through a chain: '/moved/' -> 'https://example.com/'",
+		"index.html: broken link '/lost/' (it redirects to '/gone/' -> '/missing.html', which does not exist)",
+	})
+}
+
+func TestStrictParsing(t *testing.T) {
+	w := New(WithStrictParsing(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<!DOCTYPE html>
+<html>
+<body>
+<p>Intro
+<a href="a.html" href="b.html">A</a>
+<a href="c.html">C <a href="d.html">D</a></a>
+<div><span>Text</div></span>
+<div/>
+<svg><path d="M0 0"/></svg>
+<ul><li>One<li>Two</ul>
+</section>
+<main>`))
+	w.AddDocumentFromReader("a.html", strings.NewReader(`<p>Fine</p>`))
+	w.AddDocumentFromReader("c.html", strings.NewReader(``))
+	w.AddDocumentFromReader("d.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: line 5: duplicate attribute 'href' on <a> (only the first is used)",
+		"index.html: line 6: <a> is nested inside another <a> (the outer link is closed early)",
+		"index.html: line 7: </div> closes <div> before its child <span>",
+		"index.html: line 7: stray end tag </span>",
+		"index.html: line 8: <div/> is not a void element (the slash is ignored and the element stays open)",
+		"index.html: line 11: stray end tag </section>",
+		"index.html: line 12: <div> is never closed",
+		"index.html: line 12: <main> is never closed",
+	})
+}
+
+func TestMalformedURLs(t *testing.T) {
+	w := New(WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader("<a href=\" my page.html \">Space</a>"+
+		"<a href=\"my%20page.html\">Encoded</a>"+
+		"<a href=\"my\npage.html\">Newline</a>"+
+		"<a href=\"https://example.com/a\tb\">Tab</a>"+
+		"<a href=\"my\x7fpage.html\">Delete</a>"+
+		"<img src=\"data:image/png;base64,iVBO\n  Rw0K\">"))
+	w.AddDocumentFromReader("my page.html", strings.NewReader(""))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: malformed URL 'my page.html' (a space at offset 2 should be percent-encoded)",
+		"index.html: malformed URL 'my\npage.html' (a line feed at offset 2 should be percent-encoded)",
+		"index.html: broken relative link 'my\npage.html'",
+		"index.html: malformed URL 'https://example.com/a\tb' (a tab at offset 21 should be percent-encoded)",
+		"index.html: malformed URL 'my\x7fpage.html' (the control character U+007F at offset 2 should be percent-encoded)",
+		"index.html: broken relative link 'my\x7fpage.html'",
+	})
+}
+
+func TestEmptyLinks(t *testing.T) {
+	validate := func(options ...Option) []error {
+		w := New(options...)
+		w.AddDocumentFromReader("docs/page.html", strings.NewReader("<a href=\"\">Empty</a><a href=\" \t\">Blank</a>"))
+		return w.Validate()
+	}
+
+	verifyErrors(t, validate(), []string{})
+	verifyErrors(t, validate(WithEmptyLinks(EmptyLinksWarn)), []string{
+		"docs/page.html: empty link (it refers to the page itself)",
+		"docs/page.html: whitespace-only link (it refers to the page itself)",
+	})
+	errs := validate(WithEmptyLinks(EmptyLinksError))
+	if len(errs) != 2 {
+		t.Error("Unexpected errors", errs)
+	}
+	for _, err := range errs {
+		if err.(*LinkError).Severity != SeverityError {
+			t.Error("Expected an error", err)
+		}
+	}
+}
+
+func TestLangCheck(t *testing.T) {
+	w := New(WithLangCheck(true), WithLocales("fr", "pt-br"))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<html lang="en"><title>Home</title></html>`))
+	w.AddDocumentFromReader("about.html", strings.NewReader(`<title>About</title>`))
+	w.AddDocumentFromReader("legal.html", strings.NewReader(`<html lang="en US"></html>`))
+	w.AddDocumentFromReader("fr/index.html", strings.NewReader(`<html lang="fr-CA"></html>`))
+	w.AddDocumentFromReader("fr/about.html", strings.NewReader(`<html lang="en"></html>`))
+	w.AddDocumentFromReader("pt-br/index.html", strings.NewReader(`<html lang="pt-BR"></html>`))
+	w.AddDocumentFromReader("pt-br/about.html", strings.NewReader(`<html lang="pt-PT"></html>`))
+	verifyErrors(t, w.Validate(), []string{
+		"about.html: document has no lang attribute on <html>",
+		"legal.html: document declares the malformed language 'en US'",
+		"fr/about.html: document declares the language 'en' but is in the 'fr' locale directory",
+		"pt-br/about.html: document declares the language 'pt-PT' but is in the 'pt-br' locale directory",
+	})
+}
+
+func TestLocaleParity(t *testing.T) {
+	w := New(WithLocales("en", "fr", "de"), WithLocaleParity("blog/*"))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="/en/">English</a>`))
+	w.AddDocumentFromReader("about.html", strings.NewReader(``))
+	w.AddDocumentFromReader("en/index.html", strings.NewReader(`<a href="about.html">About</a>`))
+	w.AddDocumentFromReader("en/about.html", strings.NewReader(``))
+	w.AddDocumentFromReader("en/blog/news.html", strings.NewReader(``))
+	w.AddDocumentFromReader("fr/index.html", strings.NewReader(`<a href="/about.html">About</a><a href="/en/about.html">About</a><a href="/en/blog/news.html">News</a><a href="/en/">English</a>`))
+	w.AddDocumentFromReader("fr/about.html", strings.NewReader(``))
+	w.AddDocumentFromReader("de/index.html", strings.NewReader(``))
+	w.AddDocumentFromReader("de/legal.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"en/about.html: document has no 'de' translation (expected 'de/about.html')",
+		"de/legal.html: document has no 'en' translation (expected 'en/legal.html')",
+		"de/legal.html: document has no 'fr' translation (expected 'fr/legal.html')",
+		"fr/index.html: link '/about.html' leaves the 'fr' locale (link to '/fr/about.html' instead)",
+		"fr/index.html: link '/en/about.html' leaves the 'fr' locale (link to '/fr/about.html' instead)",
+		"fr/index.html: link '/en/' leaves the 'fr' locale (link to '/fr/index.html' instead)",
+	})
+}
+
+func TestAssetManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"webpack.json": `{"app.css": "/static/app.3f9c2b.css", "vendor.js": "vendor.77ab01.js", "logo.svg": "/static/missing.svg"}`,
+		"vite.json":    `{"src/main.ts": {"file": "assets/main.4889e940.js", "isEntry": true, "css": ["assets/main.b82dbe22.css"]}}`,
+	})
+	webpack, err := LoadAssetManifest(filepath.Join(dir, "webpack.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vite, err := LoadAssetManifest(filepath.Join(dir, "vite.json"))
+	if err != nil || vite["src/main.ts"] != "assets/main.4889e940.js" {
+		t.Fatal("Unexpected manifest", vite, err)
+	}
+
+	w := New(WithAssetManifest(webpack), WithAssetManifest(vite))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<link rel="stylesheet" href="/static/app.css">
+		<script src="static/vendor.js"></script>
+		<script src="/src/main.ts"></script>
+		<img src="/static/logo.svg">
+		<img src="/static/other.png">`))
+	w.AddFile("static/app.3f9c2b.css")
+	w.AddFile("static/vendor.77ab01.js")
+	w.AddFile("assets/main.4889e940.js")
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken link '/static/logo.svg'",
+		"index.html: broken link '/static/other.png'",
+	})
+}
+
+func TestSourceMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"content/_index.md":      "# Home",
+		"content/docs/setup.md":  "# Setup",
+		"content/blog/post.html": "<p>post</p>",
+		"content/unrelated.md":   "# Unrelated",
+	})
+
+	w := New(WithSourceMap(map[string]string{"/about/": "layouts/about.html"}), WithSourceDir(filepath.Join(dir, "content")))
+	for _, name := range []string{"index.html", "docs/setup/index.html", "blog/post.html", "about/index.html", "orphan.html"} {
+		w.AddDocumentFromReader(name, strings.NewReader(`<a href="/missing">x</a>`))
+	}
+	expected := map[string]string{
+		"index.html":            filepath.ToSlash(filepath.Join(dir, "content/_index.md")),
+		"docs/setup/index.html": filepath.ToSlash(filepath.Join(dir, "content/docs/setup.md")),
+		"blog/post.html":        filepath.ToSlash(filepath.Join(dir, "content/blog/post.html")),
+		"about/index.html":      "layouts/about.html",
+		"orphan.html":           "",
+	}
+	errs := w.Validate()
+	if len(errs) != len(expected) {
+		t.Fatal("Unexpected findings", errs)
+	}
+	for _, err := range errs {
+		linkErr := err.(*LinkError)
+		if source, exists := expected[linkErr.Document]; !exists || linkErr.Source != source {
+			t.Errorf("Unexpected source %q for %s", linkErr.Source, linkErr.Document)
+		}
+	}
+}
+
+func TestDuplicateDetection(t *testing.T) {
+	w := New(WithDuplicateDetection(true))
+	page := `<html><body><h1>Pricing</h1><a href="/">Home</a></body></html>`
+	stub := `<meta http-equiv="Refresh" content="0; url=/">`
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="pricing/">Pricing</a>`))
+	w.AddDocumentFromReader("pricing/index.html", strings.NewReader(page))
+	w.AddDocumentFromReader("pricing-copy.html", strings.NewReader(page))
+	w.AddDocumentFromReader("old/pricing/index.html", strings.NewReader(page))
+	w.AddDocumentFromReader("old/a.html", strings.NewReader(stub))
+	w.AddDocumentFromReader("old/b.html", strings.NewReader(stub))
+	verifyErrors(t, w.Validate(), []string{
+		"pricing-copy.html: document has the same content as 'pricing/index.html' (keep one copy and redirect '/pricing-copy.html' to '/pricing/')",
+		"old/pricing/index.html: document has the same content as 'pricing/index.html' (keep one copy and redirect '/old/pricing/' to '/pricing/')",
+	})
+}
+
+func TestStructuredLinkErrors(t *testing.T) {
+	w := New(WithLinePositions(true), WithExternalChecker(fakeChecker{
+		"https://example.com/gone": {StatusCode: 410},
+	}))
+	w.AddDocumentFromReader("docs/index.html", strings.NewReader("<html lang=\"en\">\n"+
+		"<body>\n"+
+		"  <p>See <a href=\"setup/#missing\">setup</a>\n"+
+		"  and <a\n     href=\"../missing.html\">this</a>.</p>\n"+
+		"  <img src=\"https://example.com/gone\">\n"+
+		"  <a href=\"\">empty</a>\n"+
+		"</body>\n</html>"))
+	w.AddDocumentFromReader("docs/setup/index.html", strings.NewReader(`<h1 id="install">Install</h1>`))
+
+	type summary struct {
+		Kind         Kind
+		Target       string
+		Line, Column int
+		StatusCode   int
+	}
+	findings := make(map[string]summary)
+	for _, err := range w.Findings() {
+		findings[err.Href] = summary{err.Kind, err.Target, err.Line, err.Column, err.StatusCode}
+	}
+	expected := map[string]summary{
+		"setup/#missing":           {KindAnchor, "/docs/setup/#missing", 3, 19, 0},
+		"../missing.html":          {KindBroken, "/missing.html", 5, 12, 0},
+		"https://example.com/gone": {KindExternal, "https://example.com/gone", 6, 13, 410},
+	}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Error("Unexpected findings", findings)
+	}
+
+	w = New(WithEmptyLinks(EmptyLinksWarn), WithLangCheck(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="">empty</a>`))
+	kinds := make(map[Kind]bool)
+	for _, err := range w.Findings() {
+		kinds[err.Kind] = true
+	}
+	if !reflect.DeepEqual(kinds, map[Kind]bool{KindMalformed: true, KindDocument: true}) || KindRedirect.String() != "redirect" {
+		t.Error("Unexpected kinds", kinds)
+	}
+}
+
+func TestHostAliases(t *testing.T) {
+	w := New(WithSiteURL("https://example.com/"), WithHostAliases("Example.NET", "old.example.org"), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://example.com/about/">Canonical</a>
+		<a href="https://www.example.com/about/">www</a>
+		<a href="http://example.net/pricing?plan=pro#faq">Alias</a>
+		<a href="https://old.example.org/">Old</a>
+		<a href="https://blog.example.com/">Subdomain</a>`))
+	w.AddDocumentFromReader("about/index.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'https://www.example.com/about/' uses the non-canonical host 'www.example.com' (link to 'https://example.com/about/' instead)",
+		"index.html: link 'http://example.net/pricing?plan=pro#faq' uses the non-canonical host 'example.net' (link to 'https://example.com/pricing?plan=pro#faq' instead)",
+		"index.html: link 'https://old.example.org/' uses the non-canonical host 'old.example.org' (link to 'https://example.com/' instead)",
+	})
+
+	w = New(WithSiteURL("https://www.example.com/"), WithHostAliases(), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="https://example.com/">Apex</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'https://example.com/' uses the non-canonical host 'example.com' (link to 'https://www.example.com/' instead)",
+	})
+}
+
+func TestSchemeAndPort(t *testing.T) {
+	w := New(WithSiteURL("https://example.com/docs/"), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://example.com/docs/">Canonical</a>
+		<a href="https://example.com:443/docs/">Default port</a>
+		<a href="http://example.com/docs/setup/">Scheme</a>
+		<a href="http://Example.com:80/docs/?q=1">Both</a>
+		<a href="https://example.com:8443/admin/">Other server</a>
+		<a href="https://www.example.com:443/">Other host</a>`))
+	w.AddDocumentFromReader("setup/index.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: link 'https://example.com:443/docs/' uses the default port :443 (link to 'https://example.com/docs/' instead)",
+		"index.html: link 'http://example.com/docs/setup/' uses http instead of https (link to 'https://example.com/docs/setup/' instead)",
+		"index.html: link 'http://Example.com:80/docs/?q=1' uses http instead of https and the default port :80 (link to 'https://example.com/docs/?q=1' instead)",
+	})
+}
+
+func TestSiteURLLinks(t *testing.T) {
+	w := New(WithSiteURL("https://example.com/repo/"), WithExternalChecker(fakeChecker{
+		"https://example.com/repository/": {StatusCode: 404},
+	}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="https://example.com/repo/setup/#install">Setup</a>
+		<a href="https://example.com/repo/setup/#missing">Missing anchor</a>
+		<a href="https://example.com/repo/missing.html">Missing page</a>
+		<a href="https://example.com/repo">Home</a>
+		<a href="https://example.com/repository/">Other project</a>`))
+	w.AddDocumentFromReader("setup/index.html", strings.NewReader(`<h1 id="install">Install</h1>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken target link '/setup/#missing'",
+		"index.html: broken link '/missing.html'",
+		"index.html: encountered status code 404 when pinging 'https://example.com/repository/'",
+	})
+
+	// With a base path, the path of the site URL is kept and removed as the base path.
+	w = New(WithSiteURL("https://example.com/repo/"), WithBasePath("/repo/"), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="https://example.com/repo/setup/">Setup</a><a href="https://example.com/repo/gone/">Gone</a>`))
+	w.AddDocumentFromReader("setup/index.html", strings.NewReader(``))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken link '/gone/'",
+	})
+}
+
+func TestAMPCheck(t *testing.T) {
+	w := New(WithAMPCheck(true), WithSiteURL("https://example.com/"), WithExternalChecks(false))
+	w.AddDocumentFromReader("news/story.html", strings.NewReader(`<html><head>
+		<link rel="amphtml" href="/amp/news/story.html"></head></html>`))
+	w.AddDocumentFromReader("amp/news/story.html", strings.NewReader(`<html amp><head>
+		<link rel="canonical" href="https://example.com/news/story.html">
+		<script async src="https://cdn.ampproject.org/v0.js"></script>
+		<link rel="stylesheet" href="https://fonts.googleapis.com/css?family=Roboto">
+		</head><body><amp-img src="/hero.png"><noscript><img src="/hero.png"></noscript></amp-img></body></html>`))
+	w.AddDocumentFromReader("news/other.html", strings.NewReader(`<html><head>
+		<link rel="amphtml" href="/amp/news/other.html"></head></html>`))
+	w.AddDocumentFromReader("amp/news/other.html", strings.NewReader(`<html ⚡><head>
+		<link rel="canonical" href="/news/story.html">
+		<script src="/js/app.js"></script>
+		<link rel="stylesheet" href="/css/site.css">
+		</head><body><img src="/hero.png"><embed src="/movie.swf"></body></html>`))
+	w.AddDocumentFromReader("news/plain.html", strings.NewReader(`<html><head>
+		<link rel="amphtml" href="/news/story.html"></head></html>`))
+	w.AddDocumentFromReader("amp/orphan.html", strings.NewReader(`<html amp><body></body></html>`))
+	w.AddFile("hero.png")
+	w.AddFile("movie.swf")
+	w.AddFile("js/app.js")
+	w.AddFile("css/site.css")
+	verifyErrors(t, w.Validate(), []string{
+		"news/other.html: rel=amphtml target '/amp/news/other.html' does not link back with rel=canonical",
+		"amp/news/other.html: rel=canonical target '/news/story.html' does not link back with rel=amphtml",
+		"amp/news/other.html: AMP page loads the script '/js/app.js' (only scripts from https://cdn.ampproject.org/ are allowed)",
+		"amp/news/other.html: AMP page loads the stylesheet '/css/site.css' (only font providers are allowed)",
+		"amp/news/other.html: AMP page uses <img> (use <amp-img> instead)",
+		"amp/news/other.html: AMP page uses <embed>, which AMP does not allow",
+		"news/plain.html: rel=amphtml target '/news/story.html' is not an AMP page",
+		"amp/orphan.html: AMP page has no rel=canonical link",
+	})
+}
+
+func TestEmailTemplates(t *testing.T) {
+	pixel := "https://pixel.example"
+	w := New(WithEmailTemplates("<logo@example>", "banner"), WithExternalChecker(fakeChecker{
+		pixel + "/open.gif":   {StatusCode: 200, ContentType: "image/gif"},
+		pixel + "/track":      {StatusCode: 200, ContentType: "text/html"},
+		"http://example.com/": {StatusCode: 200},
+	}))
+	w.AddDocumentFromReader("welcome.html", strings.NewReader(`
+		<img src="cid:logo@example"><img src="cid:missing">
+		<a href="https://example.com/">Site</a>
+		<a href="mailto:help@example.com">Mail</a><a href="tel:+15555550100">Call</a>
+		<a href="#details">Details</a>
+		<a href="/account">Account</a>
+		<img src="`+pixel+`/open.gif" width="1" height="1">
+		<img src="`+pixel+`/track" width="1px" height="1px">
+		<img src="http://example.com/" width="0" height="0">`))
+	verifyErrors(t, w.Validate(), []string{
+		"welcome.html: broken link 'cid:missing' (no attachment has the Content-ID 'missing')",
+		"welcome.html: anchor link '#details' is ignored by most email clients",
+		"welcome.html: broken link '/account' (links in emails must be absolute URLs)",
+		"welcome.html: tracking pixel '" + pixel + "/track' responds with 'text/html' instead of an image",
+		"welcome.html: tracking pixel 'http://example.com/' is not served over https (email clients block it)",
+	})
+}
+
+func TestOfflineExternalLinks(t *testing.T) {
+	w := New(WithExternalChecks(false))
+	addWebsite("testdata/external_error", w)
+	verifyErrors(t, w.Validate(), []string{})
+	if len(w.ExternalResults()) != 0 {
+		t.Error("External links were checked")
+	}
+
+	w = New(WithExternalChecks(false), WithReportUnchecked(true))
+	addWebsite("testdata/external_error", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: unchecked external link 'https://www.google.com/does_not_exist'",
+		"index.html: unchecked external link 'https://fake12371ivnd985Vkf8K98Qnm.com/'",
+	})
+}
+
+func TestExternalOnly(t *testing.T) {
+	w := New(WithInternalChecks(false), WithExternalChecker(fakeChecker{
+		"https://www.google.com/does_not_exist": {StatusCode: 404},
+	}))
+	addWebsite("testdata/target_error", w)
+	w.AddDocumentFromReader("external.html", strings.NewReader(`<a href="https://www.google.com/does_not_exist">Bad</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"external.html: encountered status code 404 when pinging 'https://www.google.com/does_not_exist'",
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	w := New(WithExternalChecker(slowChecker{}), WithRequestTimeout(time.Millisecond))
+	addWebsite("testdata/external_error", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered error when pinging 'https://www.google.com/does_not_exist'",
+		"index.html: encountered error when pinging 'https://fake12371ivnd985Vkf8K98Qnm.com/'",
+	})
+}
+
+// unstableChecker answers with 503 Service Unavailable until it was asked the given number of times.
+type unstableChecker struct {
+	failures int
+	checks   []time.Time
+}
+
+func (c *unstableChecker) Check(ctx context.Context, url string) ExternalResult {
+	c.checks = append(c.checks, time.Now())
+	if len(c.checks) <= c.failures {
+		return ExternalResult{URL: url, StatusCode: 503}
+	}
+	return ExternalResult{URL: url, StatusCode: 200}
+}
+
+func TestHostPolicy(t *testing.T) {
+	checker := &unstableChecker{failures: 2}
+	w := New(WithExternalChecker(checker), WithHostPolicy("*.example.com", HostPolicy{Retries: 2, Interval: 20 * time.Millisecond}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="https://API.example.com/">API</a>`))
+	verifyErrors(t, w.Validate(), []string{})
+	if len(checker.checks) != 3 {
+		t.Fatal("Unexpected number of attempts", len(checker.checks))
+	}
+	for i := 1; i < len(checker.checks); i++ {
+		if checker.checks[i].Sub(checker.checks[i-1]) < 20*time.Millisecond {
+			t.Error("Requests were not throttled")
+		}
+	}
+
+	// The host policy timeout overrides the request timeout.
+	w = New(WithExternalChecker(slowChecker{}), WithRequestTimeout(time.Hour), WithHostPolicy("slow.example.com", HostPolicy{Timeout: time.Millisecond}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="https://slow.example.com/">Slow</a>`))
+	verifyErrors(t, w.Validate(), []string{"index.html: encountered error when pinging 'https://slow.example.com/'"})
+
+	// Hosts can be checked by a dedicated checker, such as a headless browser.
+	w = New(WithExternalChecker(slowChecker{}), WithHostPolicy("blocked.example.com", HostPolicy{Checker: fakeChecker{}}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="https://blocked.example.com/">Blocked</a>`))
+	verifyErrors(t, w.Validate(), []string{})
+}
+
+func TestCookieJar(t *testing.T) {
+	// The server only serves pages to clients that accepted the cookie set by its challenge.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("challenge"); err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "challenge", Value: "passed", Path: "/"})
+			http.Redirect(w, r, r.URL.String(), http.StatusFound)
+			return
+		}
+	}))
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := New(WithExternalChecker(&HTTPChecker{Jar: jar}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="`+server.URL+`/protected">Protected</a>`))
+	verifyErrors(t, w.Validate(), []string{})
+
+	w = New(WithExternalChecker(&HTTPChecker{}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="`+server.URL+`/protected">Protected</a>`))
+	verifyErrors(t, w.Validate(), []string{"index.html: encountered too many redirects when pinging '" + server.URL + "/protected'"})
+}
+
+func TestPreAuth(t *testing.T) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" && r.Method == "POST" {
+			logins++
+			w.Write([]byte("secret-token"))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	login := PreAuthFunc(func(ctx context.Context, client *http.Client) (Credentials, error) {
+		resp, err := client.Post(server.URL+"/login", "text/plain", strings.NewReader("user:password"))
+		if err != nil {
+			return Credentials{}, err
+		}
+		defer resp.Body.Close()
+		token, err := ioutil.ReadAll(resp.Body)
+		return Credentials{Header: http.Header{"Authorization": {"Bearer " + string(token)}}}, err
+	})
+
+	document := `
+		<a href="` + server.URL + `/private/a">A</a>
+		<a href="` + server.URL + `/private/b">B</a>`
+	w := New(WithExternalChecker(&HTTPChecker{PreAuth: map[string]PreAuthHook{host: login}}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{})
+	if logins != 1 {
+		t.Error("Unexpected number of logins", logins)
+	}
+
+	failing := PreAuthFunc(func(ctx context.Context, client *http.Client) (Credentials, error) {
+		return Credentials{}, errors.New("bad password")
+	})
+	w = New(WithExternalChecker(&HTTPChecker{PreAuth: map[string]PreAuthHook{host: failing}}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
 	errs := w.Validate()
-	verifyErrors(t, errs, []string{})
+	verifyErrors(t, errs, []string{
+		"index.html: encountered error when pinging '" + server.URL + "/private/a'",
+		"index.html: encountered error when pinging '" + server.URL + "/private/b'",
+	})
 }
 
-func TestInvalidExternalLinks(t *testing.T) {
+func TestResponseCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/static":
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		case "/expires":
+			w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		case "/private":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	}))
+	defer server.Close()
+
+	document := `
+		<a href="` + server.URL + `/static">Static</a>
+		<a href="` + server.URL + `/expires">Expires</a>
+		<a href="` + server.URL + `/private">Private</a>
+		<a href="` + server.URL + `/dynamic">Dynamic</a>`
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "cache.json")
+
+	for run := 0; run < 2; run++ {
+		cache, err := LoadResponseCache(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := New(WithExternalChecker(&HTTPChecker{}), WithResponseCache(cache))
+		w.AddDocumentFromReader("index.html", strings.NewReader(document))
+		verifyErrors(t, w.Validate(), []string{})
+		if err := cache.Save(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The cacheable responses are reused by the second run.
+	if requests != 6 {
+		t.Error("Unexpected number of requests", requests)
+	}
+
+	header := http.Header{"Cache-Control": {"max-age=60"}, "Age": {"30"}, "Expires": {"0"}}
+	now := time.Now()
+	if fresh := freshUntil(header, now); !fresh.Equal(now.Add(30 * time.Second)) {
+		t.Error("Unexpected freshness", fresh.Sub(now))
+	}
+	if fresh := freshUntil(http.Header{"Expires": {"0"}}, now); !fresh.IsZero() {
+		t.Error("Invalid Expires header was considered fresh")
+	}
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cache := NewResponseCache()
+	cache.TTL = time.Hour
+	validate := func() []error {
+		w := New(WithExternalChecker(&HTTPChecker{}), WithResponseCache(cache), WithConcurrency(4))
+		for i := 0; i < 50; i++ {
+			w.AddDocumentFromReader(fmt.Sprintf("page%d.html", i), strings.NewReader(`
+				<a href="`+server.URL+`/popular">Popular</a>
+				<a href="`+server.URL+`/gone">Gone</a>`))
+		}
+		return w.Validate()
+	}
+
+	// Links referenced from many pages are checked once.
+	if errs := validate(); len(errs) != 50 || requests != 2 {
+		t.Error("Unexpected checks", len(errs), requests)
+	}
+	if entry := cache.Entries[server.URL+"/gone"]; entry.StatusCode != http.StatusNotFound || time.Until(entry.Expires) < 59*time.Minute {
+		t.Error("Unexpected cache entry", entry)
+	}
+
+	// Outcomes are reused until the TTL expires.
+	if errs := validate(); len(errs) != 50 || requests != 2 {
+		t.Error("Unexpected checks", len(errs), requests)
+	}
+	for link, entry := range cache.Entries {
+		entry.Expires = time.Now().Add(-time.Second)
+		cache.Entries[link] = entry
+	}
+	validate()
+	if requests != 4 {
+		t.Error("Expired entries were not rechecked", requests)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	document := `<a href="` + server.URL + `/">Self-signed</a>`
+
 	w := New()
-	addWebsite("testdata/external_error", w)
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered error when pinging '" + server.URL + "/'",
+	})
+
+	// The test server's client trusts its certificate, like a client configured with a corporate CA.
+	w = New(WithHTTPClient(server.Client()))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{})
+}
+
+func TestRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/found", http.StatusMovedPermanently)
+		case "/found":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusPermanentRedirect)
+		}
+	}))
+	defer server.Close()
+	document := `<a href="` + server.URL + `/moved">Moved</a> <a href="` + server.URL + `/found">Found</a> <a href="` + server.URL + `/loop">Loop</a>`
+
+	w := New(WithStrictRedirects(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
 	errs := w.Validate()
 	verifyErrors(t, errs, []string{
-		"index.html: encountered status code 404 when pinging 'https://www.google.com/does_not_exist'",
-		"index.html: encountered error when pinging 'https://fake12371ivnd985Vkf8K98Qnm.com/'",
+		"index.html: link '" + server.URL + "/moved' permanently redirects to '" + server.URL + "/new' (update the link)",
+		"index.html: encountered too many redirects when pinging '" + server.URL + "/loop'",
+	})
+	for _, err := range LinkErrors(errs) {
+		if err.Href == server.URL+"/moved" && (err.Kind != KindRedirect || !reflect.DeepEqual(err.External.Redirects, []int{301, 302})) {
+			t.Error("Unexpected redirect finding", err.Kind, err.External.Redirects)
+		}
+	}
+
+	w = New(WithMaxRedirects(1))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered too many redirects when pinging '" + server.URL + "/moved'",
+		"index.html: encountered too many redirects when pinging '" + server.URL + "/loop'",
+	})
+
+	w = New(WithMaxRedirects(0))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 301 when pinging '" + server.URL + "/moved'",
+		"index.html: encountered status code 302 when pinging '" + server.URL + "/found'",
+		"index.html: encountered status code 308 when pinging '" + server.URL + "/loop'",
+	})
+}
+
+func TestAcceptedStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/moved":
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+	document := `<a href="` + server.URL + `/empty">Empty</a> <a href="` + server.URL + `/moved">Moved</a>`
+
+	w := New(WithMaxRedirects(0))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 301 when pinging '" + server.URL + "/moved'",
+	})
+
+	w = New(WithMaxRedirects(0), WithAcceptedStatusCodes(200, 301))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: encountered status code 204 when pinging '" + server.URL + "/empty'",
+	})
+}
+
+func TestOverallDeadline(t *testing.T) {
+	w := New(WithExternalChecker(slowChecker{}), WithRequestTimeout(time.Hour), WithOverallDeadline(10*time.Millisecond))
+	addWebsite("testdata/external_error", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: unchecked external link 'https://www.google.com/does_not_exist' (the overall deadline was exceeded)",
+		"index.html: unchecked external link 'https://fake12371ivnd985Vkf8K98Qnm.com/' (the overall deadline was exceeded)",
 	})
 }
 
@@ -96,6 +2494,210 @@ func TestInvalidTargetLinks(t *testing.T) {
 	})
 }
 
+func TestHeadingSlugs(t *testing.T) {
+	w := New()
+	addWebsite("testdata/slug", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken same page link '#getting-started'",
+		"index.html: broken same page link '#overview'",
+		"index.html: broken same page link '#overview-1'",
+		"index.html: broken same page link '#2-configuration--setup'",
+		"index.html: broken same page link '#configuration--setup'",
+	})
+
+	w = New(WithHeadingSlugs(GitHubSlugs))
+	addWebsite("testdata/slug", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken same page link '#configuration--setup'",
+		"index.html: heading 'Overview' collides with the id 'overview' (generators will rename it to 'overview-1')",
+	})
+
+	w = New(WithHeadingSlugs(JekyllSlugs))
+	addWebsite("testdata/slug", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken same page link '#2-configuration--setup'",
+		"index.html: heading 'Overview' collides with the id 'overview' (generators will rename it to 'overview-1')",
+	})
+}
+
+func TestSlugifiers(t *testing.T) {
+	tests := []struct {
+		slugifier Slugifier
+		text      string
+		expected  string
+	}{
+		{GitHubSlugs, "Hello, World! Ünïcode_ok", "hello-world-ünïcode_ok"},
+		{JekyllSlugs, "1. Hello, World!", "hello-world"},
+		{KramdownSlugs, "123", "section"},
+		{GoldmarkSlugs, "Hello, World! Ünïcode_ok", "hello-world-ncode-ok"},
+		{GoldmarkSlugs, "!!!", "heading"},
+		{PandocSlugs, "1.2 Version Ünïcode (v1.2)", "version-ünïcode-v1.2"},
+		{PandocSlugs, "42", "section"},
+		{SlugifierFunc(strings.ToUpper), "Hello", "HELLO"},
+	}
+	for _, test := range tests {
+		if slug := test.slugifier.Slugify(test.text); slug != test.expected {
+			t.Error("Unexpected slug", test.text, slug, test.expected)
+		}
+	}
+}
+
+func TestClientRoutes(t *testing.T) {
+	w := New(WithClientRoutes([]string{"/", "/settings/profile", "/users/:id", "/docs/*"}), WithClientRoutePatterns([]*regexp.Regexp{regexp.MustCompile(`/reports/\d+`)}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<h1 id="top">App</h1>
+		<a href="#top">Top</a>
+		<a href="#/">Home</a>
+		<a href="#/settings/profile">Profile</a>
+		<a href="/#/users/42?tab=posts">User</a>
+		<a href="index.html#/docs/guide/intro">Docs</a>
+		<a href="#/reports/7">Report</a>
+		<a href="#/reports/latest">Latest</a>
+		<a href="/#/settings/billing">Billing</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken client route '#/reports/latest'",
+		"index.html: broken client route '/#/settings/billing'",
+	})
+
+	// Without routes the fragments are treated as ids.
+	w = New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="#/settings">Settings</a>`))
+	verifyErrors(t, w.Validate(), []string{"index.html: broken same page link '#/settings'"})
+}
+
+func TestFrameworkAttributes(t *testing.T) {
+	document := `
+		<a ng-href="/missing-ng.html">AngularJS</a>
+		<a ng-href="{{base}}/ignored.html">AngularJS</a>
+		<a routerLink="/missing-router">Angular</a>
+		<a [routerLink]="['/missing-array']">Angular</a>
+		<a [routerLink]="['/users', user.id]">Angular</a>
+		<a :href="'/missing-vue.html'">Vue</a>
+		<a v-bind:href="url">Vue</a>
+		<a href={"/missing-svelte.html"}>Svelte</a>
+		<a href={base + "/ignored.html"}>Svelte</a>
+		<a :href="'/index.html'">Vue</a>`
+
+	w := New(WithFrameworkAttributes(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken link '/missing-ng.html'",
+		"index.html: broken link '/missing-router'",
+		"index.html: broken link '/missing-array'",
+		"index.html: broken link '/missing-vue.html'",
+		"index.html: broken link '/missing-svelte.html'",
+	})
+
+	// Without framework support only the raw href attributes are extracted.
+	w = New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken relative link '{\"/missing-svelte.html\"}'",
+		"index.html: broken relative link '{base'",
+	})
+}
+
+func TestLinkAttributes(t *testing.T) {
+	w := New(WithLinkAttributes(map[string][]string{
+		"video": {"poster-src"},
+		"*":     {"data-download-url"},
+	}))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<video poster-src="/poster.png"></video>
+		<button data-download-url="/files/manual.pdf">Download</button>
+		<div poster-src="/ignored.png"></div>
+		<a data-download-url="index.html">Home</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken link '/poster.png'",
+		"index.html: broken link '/files/manual.pdf'",
+	})
+}
+
+func TestEventHandlerLinks(t *testing.T) {
+	w := New(WithEventHandlerLinks(true))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<button onclick="location.href='/pricing.html'">Pricing</button>
+		<button onclick="window.location = &quot;/signup.html&quot;">Sign up</button>
+		<button onClick="location.assign('index.html')">Home</button>
+		<button onclick="window.open('/help.html', '_blank')">Help</button>
+		<button onclick="location.href = base + '/ignored.html'">Ignored</button>
+		<button onclick="track('/ignored.html')">Ignored</button>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken link '/pricing.html'",
+		"index.html: broken link '/signup.html'",
+		"index.html: broken link '/help.html'",
+	})
+}
+
+func TestScriptLinks(t *testing.T) {
+	document := `
+		<script>
+			const pages = ["/index.html", "/docs/missing.html", '/blog/', "//cdn.example.com/"];
+			const url = ` + "`/guides/${name}.html`" + `;
+			fetch("/api/data.json");
+		</script>
+		<script src="/app.js"></script>`
+
+	w := New(WithScriptLinks(DefaultScriptLinkPattern))
+	w.AddFile("app.js")
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken link '/docs/missing.html'",
+		"index.html: broken link '/blog/'",
+	})
+
+	w = New(WithScriptLinks(regexp.MustCompile(`"(/api/[^"]*)"`)))
+	w.AddFile("app.js")
+	w.AddDocumentFromReader("index.html", strings.NewReader(document))
+	verifyErrors(t, w.Validate(), []string{"index.html: broken link '/api/data.json'"})
+}
+
+func TestDataURIs(t *testing.T) {
+	w := New(WithDataURILimit(16))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<img src="data:image/png;base64,iVBORw0KGgo=">
+		<img src="data:image/gif;base64,R0lGODlhAQABAIAAAAAAAP///ywA">
+		<img src="data:image/png;base64,not*base64">
+		<img src="data:image/png;base64">
+		<img src="data:image//png,abc">
+		<a href="data:,Hello%2C%20World%21">Text</a>
+		<a href="data:text/plain;charset=utf-8,Hello">Text</a>`))
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: data URI payload is 21 bytes (the limit is 16 bytes)",
+		"index.html: data URI has an undecodable base64 payload",
+		"index.html: malformed data URI (missing comma)",
+		"index.html: data URI has an invalid media type 'image//png'",
+	})
+}
+
+func TestBrowserSchemes(t *testing.T) {
+	w := New()
+	w.AddDocumentFromReader("index.html", strings.NewReader(`
+		<a href="blob:https://example.com/550e8400-e29b-41d4-a716-446655440000">Download</a>
+		<img src="filesystem:https://example.com/temporary/image.png">`))
+	errs := w.Validate()
+	verifyErrors(t, errs, []string{
+		"index.html: skipped browser-generated link 'blob:https://example.com/550e8400-e29b-41d4-a716-446655440000'",
+		"index.html: skipped browser-generated link 'filesystem:https://example.com/temporary/image.png'",
+	})
+	for _, err := range errs {
+		if err.(*LinkError).Severity != SeverityInfo {
+			t.Error("Browser-generated link was not informational", err)
+		}
+	}
+}
+
+func TestHiddenTargets(t *testing.T) {
+	w := New()
+	addWebsite("testdata/hidden_target", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: same page link '#templated' targets an element hidden by a <template> element",
+		"index.html: same page link '#hidden' targets an element hidden by a hidden attribute",
+		"index.html: same page link '#styled' targets an element hidden by a display:none style",
+		"index.html: target link 'other.html#secret' targets an element hidden by a hidden attribute",
+	})
+}
+
 func TestDirectoryLinks(t *testing.T) {
 	w := New()
 	addWebsite("testdata/directory", w)
@@ -173,6 +2775,311 @@ func TestEscapeCharacters(t *testing.T) {
 	verifyErrors(t, w.Validate(), []string{})
 }
 
+func TestValidateChangedDocument(t *testing.T) {
+	w := New()
+	addWebsite("testdata/relative_error", w)
+	verifyErrors(t, w.ValidateChanged([]string{"index.html"}), []string{
+		"index.html: broken relative link 'download/../index.html'",
+	})
+}
+
+func TestValidateChangedTarget(t *testing.T) {
+	w := New()
+	addWebsite("testdata/relative_error", w)
+	verifyErrors(t, w.ValidateChanged([]string{"/blog/second-post.html"}), []string{
+		"blog/index.html: broken relative link '../../index.html'",
+		"blog/index.html: broken relative link '../blog/second-post.html'",
+	})
+}
+
+func TestRenameHints(t *testing.T) {
+	w := New(WithRenames([]Rename{
+		{From: "blog/2nd-post.html", To: "posts/2.html", Commit: "def5678"},
+		{From: "blog/second-post.html", To: "blog/2nd-post.html", Commit: "abc1234"},
+	}))
+	addWebsite("testdata/relative_error", w)
+	verifyErrors(t, w.Validate(), []string{
+		"blog/index.html: broken relative link '../../index.html'",
+		"blog/index.html: broken relative link '../blog/second-post.html' (this target was renamed to 'posts/2.html' in commit def5678)",
+		"index.html: broken relative link 'download/../index.html'",
+	})
+}
+
+func TestGitRenames(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "linkup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=LinkUp", "-c", "user.email=linkup@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatal(err, string(output))
+		}
+	}
+
+	os.MkdirAll(filepath.Join(dir, "site"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "site", "old.html"), []byte("<p>Hello, World!</p>"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "site", "gone.html"), []byte("<p>Goodbye, World!</p>"), 0644)
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "Initial commit")
+	git("mv", "site/old.html", "site/new.html")
+	git("rm", "-q", "site/gone.html")
+	git("commit", "-q", "-m", "Restructure")
+
+	renames, err := GitRenames(filepath.Join(dir, "site"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renames) != 2 {
+		t.Fatal("Unexpected rename count", renames)
+	}
+	for _, rename := range renames {
+		switch rename.From {
+		case "old.html":
+			if rename.To != "new.html" || len(rename.Commit) == 0 {
+				t.Error("Unexpected rename", rename)
+			}
+		case "gone.html":
+			if rename.To != "" || len(rename.Commit) == 0 {
+				t.Error("Unexpected deletion", rename)
+			}
+		default:
+			t.Error("Unexpected rename", rename)
+		}
+	}
+}
+
+func TestFixes(t *testing.T) {
+	w := New(WithRenames([]Rename{
+		{From: "blog/old-post.html", To: "blog/new-post.html", Commit: "abc1234"},
+		{From: "guide.html", To: "docs/guide.html", Commit: "def5678"},
+	}))
+	addWebsite("testdata/fix", w)
+
+	expected := []Fix{
+		{"blog/index.html", "../guide.html", "../docs/guide.html", "the target was renamed in commit def5678"},
+		{"index.html", "blog", "blog/", "links to directories end with a slash"},
+		{"index.html", "blog/first-post.html/", "blog/first-post.html", "links to files do not end with a slash"},
+		{"index.html", "blog/old-post.html#comments", "blog/new-post.html#comments", "the target was renamed in commit abc1234"},
+		{"index.html", "/guide.html", "/docs/guide.html", "the target was renamed in commit def5678"},
+	}
+	fixes := w.Fixes()
+	if len(fixes) != len(expected) {
+		t.Fatal("Unexpected fixes", fixes)
+	}
+	for i := range fixes {
+		if fixes[i] != expected[i] {
+			t.Error("Unexpected fix", fixes[i], expected[i])
+		}
+	}
+
+	source := []byte("<a href=\"blog\">Blog</a>\n<a href='/guide.html'>Guide</a>\n<img src=\"blog\">\n")
+	fixed := ApplyFixes(source, fixes[1:])
+	if string(fixed) != "<a href=\"blog/\">Blog</a>\n<a href='/docs/guide.html'>Guide</a>\n<img src=\"blog/\">\n" {
+		t.Error("Unexpected fixed document", string(fixed))
+	}
+
+	patch := Patch("index.html", []byte("<p>\n<a href=\"blog\">Blog</a>\n</p>\n"), []byte("<p>\n<a href=\"blog/\">Blog</a>\n</p>\n"))
+	if patch != "--- a/index.html\n+++ b/index.html\n@@ -1,3 +1,3 @@\n <p>\n-<a href=\"blog\">Blog</a>\n+<a href=\"blog/\">Blog</a>\n </p>\n" {
+		t.Error("Unexpected patch", patch)
+	}
+
+	w = New(WithRedirects(map[string]string{
+		"/old/":        "/middle/",
+		"/middle/":     "/new/",
+		"/moved.html":  "https://example.com/moved",
+		"/loop.html":   "/loop.html",
+		"/gone.html":   "/missing.html",
+		"/anchor.html": "/new/#section",
+	}), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="/old/#top">x</a><a href="moved.html">x</a><a href="loop.html">x</a>`))
+	w.AddDocumentFromReader("docs/index.html", strings.NewReader(`<a href="../old/">x</a><a href="../gone.html">x</a><a href="../anchor.html#top">x</a>`))
+	w.AddDocumentFromReader("new/index.html", strings.NewReader(`<p>New</p>`))
+	expected = []Fix{
+		{"docs/index.html", "../old/", "../new/", "the link redirects to '/middle/' -> '/new/'"},
+		{"docs/index.html", "../anchor.html#top", "../new/#section", "the link redirects to '/new/#section'"},
+		{"index.html", "/old/#top", "/new/#top", "the link redirects to '/middle/' -> '/new/'"},
+		{"index.html", "moved.html", "https://example.com/moved", "the link redirects to 'https://example.com/moved'"},
+	}
+	fixes = w.Fixes()
+	if len(fixes) != len(expected) {
+		t.Fatal("Unexpected fixes", fixes)
+	}
+	for i := range fixes {
+		if fixes[i] != expected[i] {
+			t.Error("Unexpected fix", fixes[i], expected[i])
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	source := []byte("<html><head><title>T</title></head><body>\n" +
+		"<a class=\"nav\" href=\"/missing\">Missing <b>page</b></a>\n" +
+		"<a href='/missing/deeper'>Deeper</a>\n" +
+		"<img src=\"logo.png\" alt=\"\">\n" +
+		"<a href=\"/fine\">Fine</a>\n</body></html>")
+	annotated := string(Annotate(source, []*LinkError{
+		{Document: "index.html", Href: "/missing", Message: "broken link '/missing'", Severity: SeverityError},
+		{Document: "index.html", Href: "/missing/deeper", Message: "link '/missing/deeper' <redirects>", Severity: SeverityWarning},
+		{Document: "index.html", Href: "logo.png", Message: "broken link 'logo.png'", Severity: SeverityError},
+		{Document: "index.html", Message: "document has no lang attribute on <html>", Severity: SeverityWarning},
+	}))
+	expected := "<html><head><title>T</title>" + annotationStyle + "</head><body>\n" +
+		"<span class=\"linkup-finding linkup-error\" title=\"broken link &#39;/missing&#39;\"><a class=\"nav\" href=\"/missing\">Missing <b>page</b></a></span>\n" +
+		"<span class=\"linkup-finding linkup-warning\" title=\"link &#39;/missing/deeper&#39; &lt;redirects&gt;\"><a href='/missing/deeper'>Deeper</a></span>\n" +
+		"<span class=\"linkup-finding linkup-error\" title=\"broken link &#39;logo.png&#39;\"><img src=\"logo.png\" alt=\"\"></span>\n" +
+		"<a href=\"/fine\">Fine</a>\n</body></html>"
+	if annotated != expected {
+		t.Error("Unexpected annotation", annotated)
+	}
+	if untouched := Annotate(source, nil); string(untouched) != string(source) {
+		t.Error("Unexpected annotation", string(untouched))
+	}
+}
+
+func TestFindingsHandler(t *testing.T) {
+	w := New(WithSiteURL("https://staging.example/docs/"), WithExternalChecks(false))
+	w.AddDocumentFromReader("index.html", strings.NewReader(`<a href="/docs/missing">x</a>`))
+	w.AddDocumentFromReader("guide/index.html", strings.NewReader(`<a href="../">home</a>`))
+	server := httptest.NewServer(w.FindingsHandler(w.Validate()))
+	defer server.Close()
+
+	tests := []struct {
+		url      string
+		status   int
+		expected string
+	}{
+		{"https://staging.example/docs/", http.StatusOK,
+			`{"url":"https://staging.example/docs/","document":"index.html","findings":[{"href":"/docs/missing","message":"broken link '/docs/missing'","severity":"error","fingerprint":"` +
+				findingFingerprint("index.html", "/docs/missing", "broken link '%s'%s", nil) + `"}]}`},
+		{"/docs/guide/", http.StatusOK, `{"url":"/docs/guide/","document":"guide/index.html","findings":[]}`},
+		{"https://production.example/docs/", http.StatusNotFound, `{"error":"no document is served at the url"}`},
+		{"https://staging.example/docs/missing", http.StatusNotFound, `{"error":"no document is served at the url"}`},
+		{"", http.StatusBadRequest, `{"error":"missing or malformed url parameter"}`},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + "/?url=" + url.QueryEscape(test.url))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != test.status || strings.TrimSpace(string(body)) != test.expected {
+			t.Errorf("Unexpected response for %q: %d %s", test.url, resp.StatusCode, body)
+		}
+		if resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+			t.Error("Cross-origin requests are not allowed")
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	w := New(WithTracer(tracer))
+	addWebsite("testdata/relative", w)
+	verifyErrors(t, w.Validate(), []string{})
+
+	counts := make(map[string]int)
+	for _, name := range tracer.spans {
+		counts[name]++
+	}
+	if counts["linkup.parse"] != 4 {
+		t.Error("Unexpected parse span count", counts["linkup.parse"])
+	}
+	if counts["linkup.resolve"] != 4 {
+		t.Error("Unexpected resolve span count", counts["linkup.resolve"])
+	}
+	if counts["linkup.validate"] != 1 {
+		t.Error("Unexpected validate span count", counts["linkup.validate"])
+	}
+	if tracer.open != 0 {
+		t.Error("Spans were not ended", tracer.open)
+	}
+}
+
+type recordingTracer struct {
+	spans []string
+	open  int
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+}
+
+func (r *recordingTracer) StartSpan(name string, attributes map[string]string) Span {
+	r.spans = append(r.spans, name)
+	r.open++
+	return &recordingSpan{tracer: r}
+}
+
+func (s *recordingSpan) SetAttribute(key, value string) {}
+
+func (s *recordingSpan) End(err error) {
+	s.tracer.open--
+}
+
+func TestEventLog(t *testing.T) {
+	var log bytes.Buffer
+	w := New(WithEventLog(&log))
+	addWebsite("testdata/link_tag", w)
+	verifyErrors(t, w.Validate(), []string{
+		"index.html: broken relative link 'fake.css'",
+	})
+
+	var events []string
+	decoder := json.NewDecoder(&log)
+	for decoder.More() {
+		var e struct {
+			Event    string `json:"event"`
+			Document string `json:"document"`
+			Href     string `json:"href"`
+		}
+		if err := decoder.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e.Event+" "+e.Document+" "+e.Href)
+	}
+
+	expected := []string{
+		"document index.html ",
+		"file styles.css ",
+		"validate_start  ",
+		"finding index.html fake.css",
+		"validate_end  ",
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Error("Unexpected events", events)
+	}
+}
+
+// fakeChecker reports the listed results for external links and success for all other links.
+type fakeChecker map[string]ExternalResult
+
+func (f fakeChecker) Check(ctx context.Context, url string) ExternalResult {
+	result, exists := f[url]
+	if !exists {
+		result.StatusCode = 200
+	}
+	result.URL = url
+	return result
+}
+
+// slowChecker never responds before the context is done.
+type slowChecker struct{}
+
+func (slowChecker) Check(ctx context.Context, url string) ExternalResult {
+	<-ctx.Done()
+	return ExternalResult{URL: url, Err: ctx.Err()}
+}
+
 func verifyErrors(t *testing.T, actualErrors []error, expectedErrors []string) {
 	if len(actualErrors) != len(expectedErrors) {
 		t.Error("Error count mismatch", len(actualErrors), len(expectedErrors))
@@ -194,6 +3101,84 @@ func verifyErrors(t *testing.T, actualErrors []error, expectedErrors []string) {
 	}
 }
 
+// syntheticPage returns a typical page of a synthetic website with the given number of pages spread over directories of 100 pages each.
+func syntheticPage(i, pages int) string {
+	var page strings.Builder
+	page.WriteString(`<html><head><title>Page</title><link rel="stylesheet" href="/style.css"></head><body>`)
+	for h := 0; h < 5; h++ {
+		fmt.Fprintf(&page, `<h2 id="section-%d">Section</h2><p>Text <a href="#section-%d">again</a></p>`, h, h)
+	}
+	fmt.Fprintf(&page, `<img src="/img/photo.png" srcset="/img/photo.png 1x, /img/photo.png 2x">`)
+	for j := 1; j <= 5; j++ {
+		target := (i + j*37) % pages
+		fmt.Fprintf(&page, `<a href="/d%d/page%d.html#section-%d">Related</a>`, target/100, target, j%5)
+		fmt.Fprintf(&page, `<a href="../d%d/page%d.html">Related</a>`, target/100, target)
+	}
+	page.WriteString(`</body></html>`)
+	return page.String()
+}
+
+// syntheticSite registers a synthetic website with the given number of pages.
+func syntheticSite(w *Website, pages int) {
+	w.AddFile("style.css")
+	w.AddFile("img/photo.png")
+	for i := 0; i < pages; i++ {
+		w.AddDocumentFromReader(fmt.Sprintf("d%d/page%d.html", i/100, i), strings.NewReader(syntheticPage(i, pages)))
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	page := syntheticPage(0, 1000)
+	b.SetBytes(int64(len(page)))
+	for i := 0; i < b.N; i++ {
+		New().AddDocumentFromReader("index.html", strings.NewReader(page))
+	}
+}
+
+func BenchmarkSrcset(b *testing.B) {
+	srcset := "/img/photo-480.png 480w, /img/photo-800.png 800w, /img/photo-1200.png 1200w, data:image/png;base64,AAAA 1x"
+	for i := 0; i < b.N; i++ {
+		srcsetURLs(srcset)
+	}
+}
+
+func BenchmarkResolvePath(b *testing.B) {
+	w := New()
+	syntheticSite(w, 1000)
+	w.paths = newPathIndex(w.root)
+	from := w.paths.entities["d5"]
+	b.Run("Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w.resolvePath(w.root, "/d7/page742.html")
+		}
+	})
+	b.Run("DotSegments", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w.resolvePath(from, "../d7/page742.html")
+		}
+	})
+	b.Run("Tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			isPathValid(w.root, splitPath("/d7/page742.html"))
+		}
+	})
+}
+
+func BenchmarkValidate(b *testing.B) {
+	for _, pages := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("%dPages", pages), func(b *testing.B) {
+			w := New()
+			syntheticSite(w, pages)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if errs := w.Validate(); len(errs) > 0 {
+					b.Fatal(errs[0])
+				}
+			}
+		})
+	}
+}
+
 func addWebsite(path string, website *Website) {
 	// Change the current working directory.
 	dir, err := os.Getwd()
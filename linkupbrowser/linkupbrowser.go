@@ -0,0 +1,206 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package linkupbrowser checks external links with a headless Chrome browser driven by chromedp.
+// It is meant for the few hosts that serve bot-blocking challenges or render their content client-side,
+// so it should only be used for the hosts that need it:
+//
+//	checker := linkupbrowser.New()
+//	defer checker.Close()
+//	w := linkup.New(linkup.WithHostPolicy("*.example.com", linkup.HostPolicy{Checker: checker}))
+package linkupbrowser
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/hgs3/linkup"
+)
+
+// Checker loads pages in a headless browser and reports the status of the final document,
+// after bot-blocking challenges had a chance to redirect to the real page.
+type Checker struct {
+	// ChallengeWait is the longest time spent waiting for a challenge page to be replaced by the real page.
+	// If zero, five seconds are used.
+	ChallengeWait time.Duration
+
+	// Options configure the browser process, such as chromedp.ExecPath.
+	// If nil, chromedp.DefaultExecAllocatorOptions are used.
+	Options []chromedp.ExecAllocatorOption
+
+	once          sync.Once
+	browser       context.Context
+	cancelAlloc   context.CancelFunc
+	cancelBrowser context.CancelFunc
+}
+
+// challengeTitles are the titles of well-known bot-blocking interstitial pages.
+var challengeTitles = []string{"Just a moment", "Attention Required", "Checking your browser", "DDoS-Guard"}
+
+// New returns a checker that starts the browser on its first check.
+func New() *Checker {
+	return &Checker{}
+}
+
+// Close stops the browser.
+func (c *Checker) Close() {
+	if c.cancelBrowser != nil {
+		c.cancelBrowser()
+		c.cancelAlloc()
+	}
+}
+
+func (c *Checker) start() {
+	c.once.Do(func() {
+		options := c.Options
+		if options == nil {
+			options = chromedp.DefaultExecAllocatorOptions[:]
+		}
+		var alloc context.Context
+		alloc, c.cancelAlloc = chromedp.NewExecAllocator(context.Background(), options...)
+		c.browser, c.cancelBrowser = chromedp.NewContext(alloc)
+	})
+}
+
+// Check navigates a new tab to the URL and records the response for the final document.
+// If the check level in the context is linkup.CheckAnchor, the fragment of the URL must name an element
+// of the rendered page, which includes elements created by scripts.
+func (c *Checker) Check(ctx context.Context, link string) linkup.ExternalResult {
+	c.start()
+
+	tab, cancel := chromedp.NewContext(c.browser)
+	defer cancel()
+	go func() {
+		// Abandon the tab when the check is cancelled.
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-tab.Done():
+		}
+	}()
+
+	var doc document
+	chromedp.ListenTarget(tab, func(ev interface{}) {
+		if response, ok := ev.(*network.EventResponseReceived); ok && response.Type == network.ResourceTypeDocument {
+			doc.record(response.Response)
+		}
+	})
+
+	start := time.Now()
+	err := chromedp.Run(tab, network.Enable(), chromedp.Navigate(link))
+	if err == nil {
+		err = c.waitForChallenge(tab)
+	}
+	latency := time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		result := doc.result(link)
+		result.Latency = latency
+		result.Err = err
+		return result
+	}
+
+	missingAnchor := false
+	if linkup.CheckLevelFromContext(ctx) == linkup.CheckAnchor {
+		if u, err := url.Parse(link); err == nil && len(u.Fragment) > 0 {
+			var found bool
+			script := `(function(f) { return !!document.getElementById(f) || !!document.getElementsByName(f).length; })(` + quote(u.Fragment) + `)`
+			if err := chromedp.Run(tab, chromedp.Evaluate(script, &found)); err != nil {
+				result := doc.result(link)
+				result.Latency = latency
+				result.Err = err
+				return result
+			}
+			missingAnchor = !found
+		}
+	}
+
+	result := doc.result(link)
+	result.Latency = latency
+	result.MissingAnchor = missingAnchor
+	return result
+}
+
+// document records the response for the latest document a tab loaded.
+// Responses arrive from the browser concurrently with the check, including during challenges and redirects.
+type document struct {
+	mu       sync.Mutex
+	response *network.Response
+}
+
+func (d *document) record(response *network.Response) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.response = response
+}
+
+// result returns the outcome of checking the link as of the latest response.
+func (d *document) result(link string) linkup.ExternalResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := linkup.ExternalResult{URL: link, ContentLength: -1}
+	if d.response != nil {
+		result.FinalURL = d.response.URL
+		result.StatusCode = int(d.response.Status)
+		result.ContentType = d.response.MimeType
+	}
+	return result
+}
+
+// waitForChallenge waits while the page is a known bot-blocking challenge, which replaces itself once it is solved.
+func (c *Checker) waitForChallenge(tab context.Context) error {
+	wait := c.ChallengeWait
+	if wait <= 0 {
+		wait = 5 * time.Second
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		var title string
+		if err := chromedp.Run(tab, chromedp.Title(&title)); err != nil {
+			return err
+		}
+		if !isChallenge(title) || time.Now().After(deadline) {
+			return nil
+		}
+		if err := chromedp.Run(tab, chromedp.Sleep(250*time.Millisecond)); err != nil {
+			return err
+		}
+	}
+}
+
+func isChallenge(title string) bool {
+	for _, challenge := range challengeTitles {
+		if strings.Contains(title, challenge) {
+			return true
+		}
+	}
+	return false
+}
+
+// quote returns the string as a JavaScript string literal.
+func quote(s string) string {
+	// JSON strings are valid JavaScript literals and line separators are escaped.
+	data, _ := json.Marshal(s)
+	return string(data)
+}
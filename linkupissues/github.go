@@ -0,0 +1,158 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkupissues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GitHubTracker files issues in a GitHub repository through the REST API.
+type GitHubTracker struct {
+	// Owner and Repo name the repository, as in github.com/Owner/Repo.
+	Owner string
+	Repo  string
+
+	// Token is a token allowed to read and write the issues of the repository.
+	Token string
+
+	// Label is applied to the filed issues and used to find them again.
+	// If empty, "linkup" is used.
+	Label string
+
+	// Endpoint is the URL of the API. If empty, https://api.github.com is used.
+	// GitHub Enterprise Server installations use https://hostname/api/v3.
+	Endpoint string
+
+	// Client sends the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+type githubIssue struct {
+	Number int      `json:"number,omitempty"`
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Open returns the open issues with the label that were filed by this package.
+func (t *GitHubTracker) Open(ctx context.Context) ([]Filed, error) {
+	var filed []Filed
+	for page := 1; ; page++ {
+		query := url.Values{"state": {"open"}, "labels": {t.label()}, "per_page": {"100"}, "page": {strconv.Itoa(page)}}
+		var issues []githubIssue
+		if err := t.do(ctx, http.MethodGet, t.repo()+"/issues?"+query.Encode(), nil, &issues); err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue, ok := ParseFiled(strconv.Itoa(issue.Number), issue.Body); ok {
+				filed = append(filed, issue)
+			}
+		}
+		if len(issues) < 100 {
+			return filed, nil
+		}
+	}
+}
+
+// Create opens a new issue with the label.
+func (t *GitHubTracker) Create(ctx context.Context, issue Issue) error {
+	return t.do(ctx, http.MethodPost, t.repo()+"/issues", githubIssue{Title: issue.Title, Body: issue.Body, Labels: []string{t.label()}}, nil)
+}
+
+// Update replaces the title and body of the issue with the given number.
+func (t *GitHubTracker) Update(ctx context.Context, id string, issue Issue) error {
+	return t.do(ctx, http.MethodPatch, t.repo()+"/issues/"+id, githubIssue{Title: issue.Title, Body: issue.Body}, nil)
+}
+
+func (t *GitHubTracker) label() string {
+	if t.Label == "" {
+		return "linkup"
+	}
+	return t.Label
+}
+
+func (t *GitHubTracker) repo() string {
+	endpoint := t.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.github.com"
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/repos/" + url.PathEscape(t.Owner) + "/" + url.PathEscape(t.Repo)
+}
+
+func (t *GitHubTracker) do(ctx context.Context, method, link string, in, out interface{}) error {
+	request, err := newRequest(ctx, method, link, in)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/vnd.github+json")
+	if t.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+	return send(t.Client, request, out)
+}
+
+// newRequest creates a request whose body, if any, is the JSON encoding of in.
+func newRequest(ctx context.Context, method, link string, in interface{}) (*http.Request, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(method, link, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return request.WithContext(ctx), nil
+}
+
+// send sends the request and decodes the JSON response into out, if it is not nil.
+func send(client *http.Client, request *http.Request, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s: %s", request.Method, request.URL.Path, response.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
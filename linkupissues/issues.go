@@ -0,0 +1,225 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package linkupissues files issues for broken links in an issue tracker such as GitHub or JIRA.
+//
+// Findings are grouped into one issue per broken external host or per document with broken links.
+// Each issue records the fingerprints of its findings, so running the integration again
+// leaves unchanged issues alone and updates the issues whose findings changed instead of filing duplicates:
+//
+//	issues := linkupissues.Issues(w.Validate(), linkupissues.ByHost)
+//	tracker := &linkupissues.GitHubTracker{Owner: "hgs3", Repo: "website", Token: token}
+//	result, err := linkupissues.File(ctx, tracker, issues)
+package linkupissues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hgs3/linkup"
+)
+
+// Grouping selects which findings share an issue.
+type Grouping int
+
+const (
+	// ByHost files an issue per external host with broken links.
+	ByHost Grouping = iota
+
+	// ByDocument files an issue per document with broken links.
+	ByDocument
+)
+
+// Issue is the issue filed for a group of broken links.
+type Issue struct {
+	// Key identifies the group across runs, such as "host:example.com" or "document:docs/index.html".
+	Key string
+
+	// Title summarizes the issue.
+	Title string
+
+	// Body lists the broken links followed by markers recording the key and fingerprints.
+	Body string
+
+	// Fingerprints are the fingerprints of the findings, sorted.
+	Fingerprints []string
+}
+
+// Filed is an issue that was filed by a previous run and is still open.
+type Filed struct {
+	// ID identifies the issue in the tracker, such as the issue number or key.
+	ID string
+
+	// Key is the key of the issue.
+	Key string
+
+	// Fingerprints are the fingerprints recorded in the issue.
+	Fingerprints []string
+}
+
+// Tracker is an issue tracker issues can be filed in.
+type Tracker interface {
+	// Open returns the open issues filed by previous runs.
+	Open(ctx context.Context) ([]Filed, error)
+
+	// Create files a new issue.
+	Create(ctx context.Context, issue Issue) error
+
+	// Update replaces the title and body of a filed issue.
+	Update(ctx context.Context, id string, issue Issue) error
+}
+
+// Result counts what File did.
+type Result struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// Issues groups the errors among the findings into issues.
+// Warnings and informational findings are ignored, as are findings for internal links when grouping by host.
+func Issues(errs []error, grouping Grouping) []Issue {
+	groups := make(map[string][]*linkup.LinkError)
+	var keys []string
+	for _, err := range errs {
+		var linkErr *linkup.LinkError
+		if !errors.As(err, &linkErr) || linkErr.Severity != linkup.SeverityError {
+			continue
+		}
+		var key string
+		switch grouping {
+		case ByHost:
+			if linkErr.External == nil {
+				continue
+			}
+			key = "host:" + host(linkErr.External.URL)
+		default:
+			key = "document:" + linkErr.Document
+		}
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], linkErr)
+	}
+	sort.Strings(keys)
+
+	issues := make([]Issue, 0, len(keys))
+	for _, key := range keys {
+		issues = append(issues, newIssue(key, groups[key]))
+	}
+	return issues
+}
+
+func newIssue(key string, findings []*linkup.LinkError) Issue {
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Document != findings[j].Document {
+			return findings[i].Document < findings[j].Document
+		}
+		return findings[i].Message < findings[j].Message
+	})
+
+	issue := Issue{Key: key}
+	name := strings.SplitN(key, ":", 2)[1]
+	if strings.HasPrefix(key, "host:") {
+		issue.Title = fmt.Sprintf("Broken links to %s", name)
+	} else {
+		issue.Title = fmt.Sprintf("Broken links in %s", name)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "LinkUp found %d broken links:\n\n", len(findings))
+	seen := make(map[string]bool)
+	for _, finding := range findings {
+		fmt.Fprintf(&body, "- %s: %s\n", finding.Document, finding.Message)
+		if !seen[finding.Fingerprint] {
+			seen[finding.Fingerprint] = true
+			issue.Fingerprints = append(issue.Fingerprints, finding.Fingerprint)
+		}
+	}
+	sort.Strings(issue.Fingerprints)
+	fmt.Fprintf(&body, "\n<!-- linkup-key: %s -->\n", key)
+	for _, fingerprint := range issue.Fingerprints {
+		fmt.Fprintf(&body, "<!-- linkup-fingerprint: %s -->\n", fingerprint)
+	}
+	issue.Body = body.String()
+	return issue
+}
+
+var (
+	keyMarker         = regexp.MustCompile(`<!-- linkup-key: (.+?) -->`)
+	fingerprintMarker = regexp.MustCompile(`<!-- linkup-fingerprint: (\S+) -->`)
+)
+
+// ParseFiled recovers the key and fingerprints recorded in the body of an issue.
+// It returns false if the body was not written by this package.
+func ParseFiled(id, body string) (Filed, bool) {
+	match := keyMarker.FindStringSubmatch(body)
+	if match == nil {
+		return Filed{}, false
+	}
+	filed := Filed{ID: id, Key: match[1]}
+	for _, match := range fingerprintMarker.FindAllStringSubmatch(body, -1) {
+		filed.Fingerprints = append(filed.Fingerprints, match[1])
+	}
+	sort.Strings(filed.Fingerprints)
+	return filed, true
+}
+
+// File creates an issue for every new group of broken links and updates the filed issues whose findings changed.
+// Issues whose findings are unchanged are left alone, so the integration can run on every build.
+func File(ctx context.Context, tracker Tracker, issues []Issue) (Result, error) {
+	var result Result
+	open, err := tracker.Open(ctx)
+	if err != nil {
+		return result, err
+	}
+	filed := make(map[string]Filed, len(open))
+	for _, issue := range open {
+		filed[issue.Key] = issue
+	}
+
+	for _, issue := range issues {
+		previous, exists := filed[issue.Key]
+		switch {
+		case !exists:
+			if err := tracker.Create(ctx, issue); err != nil {
+				return result, err
+			}
+			result.Created++
+		case strings.Join(previous.Fingerprints, ",") != strings.Join(issue.Fingerprints, ","):
+			if err := tracker.Update(ctx, previous.ID, issue); err != nil {
+				return result, err
+			}
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+// host returns the lowercase host of the URL.
+func host(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		return strings.ToLower(u.Host)
+	}
+	return link
+}
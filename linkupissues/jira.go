@@ -0,0 +1,132 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkupissues
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// JiraTracker files issues in a JIRA project through the REST API.
+type JiraTracker struct {
+	// URL is the base URL of the JIRA site, such as https://example.atlassian.net.
+	URL string
+
+	// Project is the key of the project the issues are filed in.
+	Project string
+
+	// Email and Token authenticate with basic authentication, as JIRA Cloud API tokens do.
+	Email string
+	Token string
+
+	// IssueType is the type of the filed issues. If empty, "Bug" is used.
+	IssueType string
+
+	// Label is applied to the filed issues and used to find them again.
+	// If empty, "linkup" is used.
+	Label string
+
+	// Client sends the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+type jiraFields struct {
+	Project     *jiraName `json:"project,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Description string    `json:"description,omitempty"`
+	IssueType   *jiraName `json:"issuetype,omitempty"`
+	Labels      []string  `json:"labels,omitempty"`
+}
+
+type jiraName struct {
+	Key  string `json:"key,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type jiraIssue struct {
+	Key    string     `json:"key,omitempty"`
+	Fields jiraFields `json:"fields"`
+}
+
+// Open returns the unresolved issues of the project with the label that were filed by this package.
+func (t *JiraTracker) Open(ctx context.Context) ([]Filed, error) {
+	var filed []Filed
+	jql := "project = \"" + t.Project + "\" AND labels = \"" + t.label() + "\" AND resolution = Unresolved"
+	for start := 0; ; {
+		query := url.Values{"jql": {jql}, "fields": {"description"}, "startAt": {strconv.Itoa(start)}, "maxResults": {"100"}}
+		var page struct {
+			Total  int         `json:"total"`
+			Issues []jiraIssue `json:"issues"`
+		}
+		if err := t.do(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, issue := range page.Issues {
+			if issue, ok := ParseFiled(issue.Key, issue.Fields.Description); ok {
+				filed = append(filed, issue)
+			}
+		}
+		start += len(page.Issues)
+		if len(page.Issues) == 0 || start >= page.Total {
+			return filed, nil
+		}
+	}
+}
+
+// Create files a new issue with the label in the project.
+func (t *JiraTracker) Create(ctx context.Context, issue Issue) error {
+	issueType := t.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	fields := jiraFields{
+		Project:     &jiraName{Key: t.Project},
+		Summary:     issue.Title,
+		Description: issue.Body,
+		IssueType:   &jiraName{Name: issueType},
+		Labels:      []string{t.label()},
+	}
+	return t.do(ctx, http.MethodPost, "/rest/api/2/issue", jiraIssue{Fields: fields}, nil)
+}
+
+// Update replaces the summary and description of the issue with the given key.
+func (t *JiraTracker) Update(ctx context.Context, id string, issue Issue) error {
+	fields := jiraFields{Summary: issue.Title, Description: issue.Body}
+	return t.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(id), jiraIssue{Fields: fields}, nil)
+}
+
+func (t *JiraTracker) label() string {
+	if t.Label == "" {
+		return "linkup"
+	}
+	return t.Label
+}
+
+func (t *JiraTracker) do(ctx context.Context, method, path string, in, out interface{}) error {
+	request, err := newRequest(ctx, method, strings.TrimSuffix(t.URL, "/")+path, in)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if t.Email != "" || t.Token != "" {
+		request.SetBasicAuth(t.Email, t.Token)
+	}
+	return send(t.Client, request, out)
+}
@@ -0,0 +1,139 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkupissues
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hgs3/linkup"
+)
+
+// fakeGitHub stores issues in memory and serves the subset of the GitHub API used by GitHubTracker.
+type fakeGitHub struct {
+	mu     sync.Mutex
+	issues []githubIssue
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" || !strings.HasPrefix(r.URL.Path, "/repos/hgs3/site/issues") {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	var issue githubIssue
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("labels") != "linkup" {
+			http.Error(w, "unexpected label", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(f.issues)
+	case http.MethodPost:
+		json.NewDecoder(r.Body).Decode(&issue)
+		issue.Number = len(f.issues) + 1
+		f.issues = append(f.issues, issue)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPatch:
+		json.NewDecoder(r.Body).Decode(&issue)
+		number, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/repos/hgs3/site/issues/"))
+		f.issues[number-1].Title = issue.Title
+		f.issues[number-1].Body = issue.Body
+	}
+}
+
+func findings(pages map[string]string, checker linkup.ExternalChecker) []error {
+	w := linkup.New(linkup.WithExternalChecker(checker))
+	for name, page := range pages {
+		w.AddDocumentFromReader(name, strings.NewReader(page))
+	}
+	return w.Validate()
+}
+
+type checker map[string]int
+
+func (c checker) Check(ctx context.Context, link string) linkup.ExternalResult {
+	return linkup.ExternalResult{URL: link, StatusCode: c[link]}
+}
+
+func TestFileGitHubIssues(t *testing.T) {
+	github := &fakeGitHub{}
+	server := httptest.NewServer(github)
+	defer server.Close()
+	tracker := &GitHubTracker{Owner: "hgs3", Repo: "site", Token: "secret", Endpoint: server.URL}
+	links := checker{"https://example.com/a": 404, "https://example.com/b": 200, "https://other.example/": 500}
+
+	pages := map[string]string{
+		"index.html": `<a href="https://example.com/a">A</a><a href="https://example.com/b">B</a><a href="missing.html">Missing</a>`,
+		"about.html": `<a href="https://other.example/">Other</a>`,
+	}
+	issues := Issues(findings(pages, links), ByHost)
+	if len(issues) != 2 || issues[0].Key != "host:example.com" || issues[0].Title != "Broken links to example.com" || len(issues[0].Fingerprints) != 1 {
+		t.Fatal("Unexpected issues", issues)
+	}
+	if byDocument := Issues(findings(pages, links), ByDocument); len(byDocument) != 2 || byDocument[1].Title != "Broken links in index.html" {
+		t.Error("Unexpected issues", byDocument)
+	}
+
+	result, err := File(context.Background(), tracker, issues)
+	if err != nil || result != (Result{Created: 2}) {
+		t.Fatal("Unexpected result", result, err)
+	}
+
+	// Filing the same findings again must not duplicate the issues.
+	result, err = File(context.Background(), tracker, issues)
+	if err != nil || result != (Result{Unchanged: 2}) {
+		t.Error("Unexpected result", result, err)
+	}
+
+	// A new broken link to a known host updates its issue.
+	links["https://example.com/b"] = 410
+	result, err = File(context.Background(), tracker, Issues(findings(pages, links), ByHost))
+	if err != nil || result != (Result{Updated: 1, Unchanged: 1}) {
+		t.Error("Unexpected result", result, err)
+	}
+	if len(github.issues) != 2 || !strings.Contains(github.issues[0].Body, "https://example.com/b") || github.issues[0].Labels[0] != "linkup" {
+		t.Error("Unexpected issues", github.issues)
+	}
+
+	tracker.Token = "wrong"
+	if _, err := File(context.Background(), tracker, issues); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestParseFiled(t *testing.T) {
+	pages := map[string]string{"my page.html": `<a href="https://example.com/a">A</a>`}
+	issues := Issues(findings(pages, checker{"https://example.com/a": 404}), ByDocument)
+	if len(issues) != 1 || issues[0].Key != "document:my page.html" {
+		t.Fatal("Unexpected issues", issues)
+	}
+	filed, ok := ParseFiled("1", issues[0].Body)
+	if !ok || filed.Key != issues[0].Key || len(filed.Fingerprints) != 1 || filed.Fingerprints[0] != issues[0].Fingerprints[0] {
+		t.Error("Unexpected filed issue", filed, ok)
+	}
+	if _, ok := ParseFiled("2", "A report written by hand"); ok {
+		t.Error("Expected an issue not written by LinkUp")
+	}
+}
@@ -0,0 +1,66 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package linkupotel bridges LinkUp's tracing hooks to OpenTelemetry.
+//
+//	tracer := otel.Tracer("github.com/hgs3/linkup")
+//	w := linkup.New(linkup.WithTracer(linkupotel.NewTracer(ctx, tracer)))
+package linkupotel
+
+import (
+	"context"
+
+	"github.com/hgs3/linkup"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracer struct {
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+type span struct {
+	span trace.Span
+}
+
+// NewTracer returns a linkup.Tracer that records spans with the OpenTelemetry tracer.
+// All spans are created as children of the span carried by ctx, if any.
+func NewTracer(ctx context.Context, t trace.Tracer) linkup.Tracer {
+	return &tracer{ctx: ctx, tracer: t}
+}
+
+func (t *tracer) StartSpan(name string, attributes map[string]string) linkup.Span {
+	attrs := make([]attribute.KeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+	_, s := t.tracer.Start(t.ctx, name, trace.WithAttributes(attrs...))
+	return &span{span: s}
+}
+
+func (s *span) SetAttribute(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+func (s *span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,32 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+/*
+Package linkuppb contains the gRPC service definition for running LinkUp remotely.
+Tooling can submit a website, stream findings as validation progresses, and fetch a summary when it completes.
+
+Server implements the service on top of a linkup.Website per submitted site:
+
+	server := grpc.NewServer()
+	linkuppb.RegisterLinkUpServer(server, linkuppb.NewServer(linkup.WithConcurrency(4)))
+	server.Serve(listener)
+
+The Go bindings in linkup.pb.go and linkup_grpc.pb.go are generated from linkup.proto with protoc.
+Run "go generate" after changing the service definition.
+*/
+package linkuppb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative linkup.proto
@@ -0,0 +1,200 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkuppb
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/hgs3/linkup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the LinkUp service by validating every submitted website in the background.
+// Findings are streamed to callers as the website reports them to its event log.
+// Jobs are kept for the lifetime of the server so their findings and summaries can be fetched at any time.
+type Server struct {
+	UnimplementedLinkUpServer
+
+	options []linkup.Option
+	mu      sync.Mutex
+	jobs    map[string]*job
+}
+
+// NewServer returns a server validating submitted websites with the options, such as linkup.WithConcurrency.
+// Whether external links are checked is chosen by each submission.
+func NewServer(options ...linkup.Option) *Server {
+	return &Server{options: options, jobs: make(map[string]*job)}
+}
+
+// job is a website validated on behalf of a caller.
+type job struct {
+	mu       sync.Mutex
+	findings []*Finding
+	finished bool
+	summary  linkup.Summary
+
+	// updated is closed and replaced whenever a finding is added or the job finishes.
+	updated chan struct{}
+}
+
+// Write receives the events of the website, one JSON line at a time, and records its findings.
+func (j *job) Write(p []byte) (int, error) {
+	var e struct {
+		Event       string `json:"event"`
+		Document    string `json:"document"`
+		Href        string `json:"href"`
+		Message     string `json:"message"`
+		Severity    string `json:"severity"`
+		Fingerprint string `json:"fingerprint"`
+		Kind        string `json:"kind"`
+		Target      string `json:"target"`
+		Line        int32  `json:"line"`
+		Column      int32  `json:"column"`
+		Status      int32  `json:"status"`
+	}
+	if err := json.Unmarshal(p, &e); err != nil {
+		return 0, err
+	}
+	if e.Event == "finding" {
+		j.update(func() {
+			j.findings = append(j.findings, &Finding{
+				Document:    e.Document,
+				Href:        e.Href,
+				Message:     e.Message,
+				Fingerprint: e.Fingerprint,
+				Severity:    e.Severity,
+				Kind:        e.Kind,
+				Target:      e.Target,
+				Line:        e.Line,
+				Column:      e.Column,
+				StatusCode:  e.Status,
+			})
+		})
+	}
+	return len(p), nil
+}
+
+// update changes the job and wakes the streams waiting for it.
+func (j *job) update(change func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	change()
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+// SubmitSite registers the files of a website and starts validating it.
+func (s *Server) SubmitSite(ctx context.Context, req *SubmitSiteRequest) (*SubmitSiteResponse, error) {
+	j := &job{updated: make(chan struct{})}
+	options := append(append([]linkup.Option(nil), s.options...), linkup.WithExternalChecks(req.CheckExternal), linkup.WithEventLog(j))
+	w := linkup.New(options...)
+	for _, file := range req.Files {
+		var err error
+		if file.Document {
+			err = w.AddDocumentFromReader(file.Name, bytes.NewReader(file.Content))
+		} else {
+			err = w.AddFile(file.Name)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot register '%s': %v", file.Name, err)
+		}
+	}
+	j.summary = w.Summary()
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot create a job id: %v", err)
+	}
+	jobID := hex.EncodeToString(id)
+	s.mu.Lock()
+	s.jobs[jobID] = j
+	s.mu.Unlock()
+
+	go func() {
+		w.Validate()
+		j.update(func() { j.finished = true })
+	}()
+	return &SubmitSiteResponse{JobId: jobID}, nil
+}
+
+// StreamFindings streams the findings of a job as they are produced, starting with those already reported.
+// The stream ends when the job finishes.
+func (s *Server) StreamFindings(req *StreamFindingsRequest, stream LinkUp_StreamFindingsServer) error {
+	j, err := s.job(req.JobId)
+	if err != nil {
+		return err
+	}
+	sent := 0
+	for {
+		j.mu.Lock()
+		pending := j.findings[sent:]
+		finished := j.finished
+		updated := j.updated
+		j.mu.Unlock()
+
+		for _, finding := range pending {
+			if err := stream.Send(finding); err != nil {
+				return err
+			}
+		}
+		sent += len(pending)
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-updated:
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, stream.Context().Err().Error())
+		}
+	}
+}
+
+// GetSummary returns the summary of a job.
+func (s *Server) GetSummary(ctx context.Context, req *GetSummaryRequest) (*Summary, error) {
+	j, err := s.job(req.JobId)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &Summary{
+		JobId:     req.JobId,
+		Finished:  j.finished,
+		Documents: int64(j.summary.Documents),
+		Files:     int64(j.summary.Files),
+		Links:     int64(j.summary.Links),
+		Findings:  int64(len(j.findings)),
+	}, nil
+}
+
+// job returns the job with the id.
+func (s *Server) job(id string) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, exists := s.jobs[id]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "unknown job '%s'", id)
+	}
+	return j, nil
+}
@@ -0,0 +1,115 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkuptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/hgs3/linkup"
+)
+
+// UpdateEnv is the environment variable that rewrites golden files with the actual findings instead of comparing them when set to "1".
+const UpdateEnv = "LINKUP_UPDATE_GOLDEN"
+
+// Finding is a finding as it is written to a golden report.
+type Finding struct {
+	Document    string `json:"document"`
+	Source      string `json:"source,omitempty"`
+	Href        string `json:"href,omitempty"`
+	Message     string `json:"message"`
+	Severity    string `json:"severity"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Findings converts validation results into findings sorted by document, message, and link, so reports are stable.
+func Findings(errs []error) []Finding {
+	findings := make([]Finding, 0, len(errs))
+	for _, err := range errs {
+		finding := Finding{Message: err.Error(), Severity: linkup.SeverityError.String()}
+		var linkErr *linkup.LinkError
+		if errors.As(err, &linkErr) {
+			finding = Finding{
+				Document:    linkErr.Document,
+				Source:      linkErr.Source,
+				Href:        linkErr.Href,
+				Message:     linkErr.Message,
+				Severity:    linkErr.Severity.String(),
+				Fingerprint: linkErr.Fingerprint,
+			}
+		}
+		findings = append(findings, finding)
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Document != b.Document {
+			return a.Document < b.Document
+		}
+		if a.Message != b.Message {
+			return a.Message < b.Message
+		}
+		return a.Href < b.Href
+	})
+	return findings
+}
+
+// AssertGolden compares the findings against the golden JSON report and fails the test if they differ.
+// Run the tests with LINKUP_UPDATE_GOLDEN=1 to create or update the golden file.
+func AssertGolden(t testing.TB, golden string, errs []error) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(Findings(errs), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual = append(actual, '\n')
+
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(golden, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with %s=1 to create it)", err, UpdateEnv)
+	}
+	if !bytes.Equal(bytes.TrimSpace(expected), bytes.TrimSpace(actual)) {
+		t.Errorf("findings do not match %s (run with %s=1 to update it)\nexpected:\n%s\nactual:\n%s", golden, UpdateEnv, expected, actual)
+	}
+}
+
+// ValidateDirectory registers the directory as a website, validates it, and compares the findings against the golden JSON report.
+func ValidateDirectory(t testing.TB, dir, golden string, options ...linkup.Option) {
+	t.Helper()
+
+	w := linkup.New(options...)
+	if err := w.AddDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	AssertGolden(t, golden, w.Validate())
+}
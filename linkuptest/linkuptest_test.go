@@ -0,0 +1,77 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkuptest_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hgs3/linkup"
+	"github.com/hgs3/linkup/linkuptest"
+)
+
+func TestServer(t *testing.T) {
+	server := linkuptest.NewServer()
+	defer server.Close()
+	server.Handle("https://www.google.com/", linkuptest.Response{})
+	server.Handle("https://www.youtube.com/", linkuptest.Response{StatusCode: 301, Header: http.Header{"Location": {"https://www.youtube.com/home"}}})
+	server.Handle("https://www.youtube.com/home", linkuptest.Response{})
+	server.Handle("https://www.wikipedia.org/", linkuptest.Response{Delay: time.Second})
+
+	w := linkup.New(linkup.WithExternalChecker(server.Checker()), linkup.WithRequestTimeout(100*time.Millisecond))
+	if err := w.AddDirectory("../testdata/external"); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []string
+	for _, err := range w.Validate() {
+		messages = append(messages, err.Error())
+	}
+	if strings.Join(messages, "\n") != "index.html: encountered error when pinging 'https://www.wikipedia.org/'" {
+		t.Error("Unexpected findings", messages)
+	}
+
+	w = linkup.New(linkup.WithExternalChecker(server.Checker()))
+	if err := w.AddDirectory("../testdata/external_error"); err != nil {
+		t.Fatal(err)
+	}
+	messages = nil
+	for _, err := range w.Validate() {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"index.html: encountered status code 404 when pinging 'https://www.google.com/does_not_exist'",
+		"index.html: encountered error when pinging 'https://fake12371ivnd985Vkf8K98Qnm.com/'",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Error("Unexpected findings", messages)
+	}
+
+	requests := strings.Join(server.Requests(), "\n")
+	if !strings.Contains(requests, "HEAD https://www.youtube.com/home") || !strings.Contains(requests, "HEAD https://www.google.com/does_not_exist") {
+		t.Error("Unexpected requests", requests)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	server := linkuptest.NewServer()
+	defer server.Close()
+	server.Handle("https://www.google.com/", linkuptest.Response{})
+	linkuptest.ValidateDirectory(t, "../testdata/external_error", "testdata/external_error.json", linkup.WithExternalChecker(server.Checker()))
+}
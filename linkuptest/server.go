@@ -0,0 +1,176 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package linkuptest provides helpers for testing link validation offline.
+//
+// Server answers external link checks deterministically, so tests do not depend on live websites:
+//
+//	server := linkuptest.NewServer()
+//	defer server.Close()
+//	server.Handle("https://example.com/", linkuptest.Response{})
+//	server.Handle("https://example.com/gone", linkuptest.Response{StatusCode: 404})
+//	w := linkup.New(linkup.WithExternalChecker(server.Checker()))
+//
+// ValidateDirectory pins the findings for a built website to a golden JSON report,
+// which is convenient for testing static site generator plugins:
+//
+//	linkuptest.ValidateDirectory(t, "public", "testdata/public.json")
+package linkuptest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hgs3/linkup"
+)
+
+// originalScheme is the request header carrying the scheme of the URL being checked.
+const originalScheme = "X-Linkup-Scheme"
+
+// Response is the canned response for a URL.
+type Response struct {
+	// StatusCode is the status of the response.
+	// If zero, 200 is used.
+	StatusCode int
+
+	// Header holds the response headers, such as the Location of a redirect.
+	Header http.Header
+
+	// Body is the response body, which is omitted for HEAD requests.
+	Body string
+
+	// Delay holds back the response, which is useful for testing timeouts.
+	Delay time.Duration
+}
+
+// Server is a fake web server that answers for every host.
+// Requests for any URL, http or https, are routed to an httptest server by the client returned from Client.
+// URLs on hosts without a registered URL fail like hosts that do not exist,
+// and unregistered URLs on known hosts receive a 404 response.
+type Server struct {
+	server    *httptest.Server
+	mu        sync.Mutex
+	responses map[string]Response
+	hosts     map[string]bool
+	requests  []string
+}
+
+// NewServer starts a fake web server.
+// The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		responses: make(map[string]Response),
+		hosts:     make(map[string]bool),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Handle registers the response for the URL.
+// The URL must match the checked link exactly, except for its fragment.
+func (s *Server) Handle(link string, response Response) {
+	u, err := url.Parse(link)
+	if err != nil {
+		panic(fmt.Sprintf("linkuptest: invalid URL '%s': %v", link, err))
+	}
+	u.Fragment = ""
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[u.String()] = response
+	s.hosts[strings.ToLower(u.Host)] = true
+}
+
+// Client returns an HTTP client whose requests are answered by the server.
+func (s *Server) Client() *http.Client {
+	return &http.Client{Transport: &transport{server: s}}
+}
+
+// Checker returns an external link checker whose requests are answered by the server.
+func (s *Server) Checker() *linkup.HTTPChecker {
+	return &linkup.HTTPChecker{Client: s.Client()}
+}
+
+// Requests returns the requests received so far, formatted as "METHOD URL".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	link := r.Header.Get(originalScheme) + "://" + r.Host + r.URL.RequestURI()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+link)
+	response, exists := s.responses[link]
+	s.mu.Unlock()
+
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+	if response.Delay > 0 {
+		select {
+		case <-time.After(response.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	for name, values := range response.Header {
+		w.Header()[name] = values
+	}
+	if response.StatusCode == 0 {
+		response.StatusCode = http.StatusOK
+	}
+	w.WriteHeader(response.StatusCode)
+	if r.Method != "HEAD" {
+		io.WriteString(w, response.Body)
+	}
+}
+
+// transport routes every request to the server.
+type transport struct {
+	server *Server
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.server.mu.Lock()
+	known := t.server.hosts[strings.ToLower(req.URL.Host)]
+	t.server.mu.Unlock()
+	if !known {
+		return nil, &net.DNSError{Err: "no such host", Name: req.URL.Hostname(), IsNotFound: true}
+	}
+
+	routed := req.Clone(req.Context())
+	routed.Host = req.URL.Host
+	routed.URL.Scheme = "http"
+	routed.URL.Host = t.server.server.Listener.Addr().String()
+	routed.Header.Set(originalScheme, req.URL.Scheme)
+	return t.server.server.Client().Transport.RoundTrip(routed)
+}
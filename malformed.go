@@ -0,0 +1,94 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// EmptyLinkPolicy selects how links with an empty or whitespace-only URL, such as href="", are reported.
+// Such links refer to the page they appear on, which is rarely intended.
+type EmptyLinkPolicy int
+
+const (
+	// EmptyLinksIgnore accepts empty links. It is the default policy.
+	EmptyLinksIgnore EmptyLinkPolicy = iota
+
+	// EmptyLinksWarn reports empty links as warnings.
+	EmptyLinksWarn
+
+	// EmptyLinksError reports empty links as errors.
+	EmptyLinksError
+)
+
+// WithEmptyLinks selects how links with an empty or whitespace-only URL are reported.
+func WithEmptyLinks(policy EmptyLinkPolicy) Option {
+	return func(w *Website) {
+		w.emptyLinks = policy
+	}
+}
+
+// checkEmpty reports a link with an empty or whitespace-only URL according to the empty link policy.
+func checkEmpty(website *Website, entity *fsEntity, raw string) *LinkError {
+	var severity Severity
+	switch website.emptyLinks {
+	case EmptyLinksWarn:
+		severity = SeverityWarning
+	case EmptyLinksError:
+		severity = SeverityError
+	default:
+		return nil
+	}
+	if len(raw) > 0 {
+		return website.newFinding(entity, raw, severity, KindMalformed, "whitespace-only link (it refers to the page itself)")
+	}
+	return website.newFinding(entity, raw, severity, KindMalformed, "empty link (it refers to the page itself)")
+}
+
+// checkMalformed warns about a link containing a raw space, line break, or control character.
+// Browsers strip leading and trailing whitespace but treat the characters inside a URL inconsistently,
+// so they should be percent-encoded. Data URIs are exempt since they may be wrapped.
+func checkMalformed(website *Website, entity *fsEntity, raw string) *LinkError {
+	trimmed := strings.TrimSpace(raw)
+	if isDataURI(trimmed) {
+		return nil
+	}
+	for i, r := range trimmed {
+		if r == ' ' || unicode.IsControl(r) {
+			return website.newFinding(entity, raw, SeverityWarning, KindMalformed, "malformed URL '%s' (%s at offset %d should be percent-encoded)",
+				trimmed, describeRune(r), i)
+		}
+	}
+	return nil
+}
+
+// describeRune names a character for a diagnostic message.
+func describeRune(r rune) string {
+	switch r {
+	case ' ':
+		return "a space"
+	case '\t':
+		return "a tab"
+	case '\n':
+		return "a line feed"
+	case '\r':
+		return "a carriage return"
+	}
+	return fmt.Sprintf("the control character U+%04X", r)
+}
@@ -0,0 +1,94 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// WithAssetManifest resolves links to the logical names of fingerprinted assets, such as "app.css",
+// to the files the build emitted, such as "app.3f9c2b.css", so template sources can be validated against a built website.
+// The manifest maps logical names to emitted files, as read by LoadAssetManifest.
+// Logical names are matched against the linked path relative to the root of the domain and, failing that, its base name.
+// Emitted files starting with a slash are relative to the root of the domain,
+// others are relative to the root when the full path matched and to the directory of the link when the base name matched.
+func WithAssetManifest(manifest map[string]string) Option {
+	return func(w *Website) {
+		if w.assetManifest == nil {
+			w.assetManifest = make(map[string]string)
+		}
+		for logical, emitted := range manifest {
+			w.assetManifest[strings.TrimPrefix(path.Clean("/"+logical), "/")] = emitted
+		}
+	}
+}
+
+// LoadAssetManifest reads the asset manifest written by a bundler, such as the manifest.json of webpack-manifest-plugin,
+// which maps logical names to emitted files, or the .vite/manifest.json of Vite, which maps source files to chunks with a "file".
+func LoadAssetManifest(name string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	manifest := make(map[string]string, len(entries))
+	for logical, entry := range entries {
+		var emitted string
+		if json.Unmarshal(entry, &emitted) == nil {
+			manifest[logical] = emitted
+			continue
+		}
+		var chunk struct {
+			File string `json:"file"`
+		}
+		if json.Unmarshal(entry, &chunk) == nil && len(chunk.File) > 0 {
+			manifest[logical] = chunk.File
+		}
+	}
+	return manifest, nil
+}
+
+// resolveAsset resolves a link to the logical name of a fingerprinted asset to the emitted file.
+func (w *Website) resolveAsset(base *fsEntity, href string) *fsEntity {
+	name := href
+	if !strings.HasPrefix(name, "/") {
+		name = path.Join(base.fullname, name)
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	emitted, exists := w.assetManifest[name]
+	dir := ""
+	if !exists {
+		if emitted, exists = w.assetManifest[path.Base(name)]; !exists {
+			return nil
+		}
+		dir = path.Dir(name)
+	}
+	if stripped, inside := w.stripBasePath(emitted); strings.HasPrefix(emitted, "/") && inside {
+		emitted = stripped
+	} else {
+		emitted = path.Join("/", dir, emitted)
+	}
+	return w.lookupPath(w.root, emitted)
+}
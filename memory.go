@@ -0,0 +1,118 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import "unsafe"
+
+// entitySlabSize is the number of entities allocated at once.
+const entitySlabSize = 256
+
+// entityArena allocates file system entities in slabs and interns the strings they share,
+// such as "index.html" and links to common stylesheets, which keeps very large websites light.
+type entityArena struct {
+	slab    []fsEntity
+	strings map[string]string
+}
+
+func newEntityArena() *entityArena {
+	return &entityArena{strings: make(map[string]string)}
+}
+
+// allocate returns a new entity from the current slab, starting a new slab when it is full.
+func (a *entityArena) allocate(name string) *fsEntity {
+	if len(a.slab) == 0 {
+		a.slab = make([]fsEntity, entitySlabSize)
+	}
+	entity := &a.slab[0]
+	a.slab = a.slab[1:]
+	entity.name = a.intern(name)
+	return entity
+}
+
+// intern returns a shared copy of the string.
+func (a *entityArena) intern(s string) string {
+	if interned, exists := a.strings[s]; exists {
+		return interned
+	}
+	a.strings[s] = s
+	return s
+}
+
+// Summary describes the registered website.
+type Summary struct {
+	// Documents is the number of registered HTML documents.
+	Documents int
+
+	// Files is the number of registered non-HTML files.
+	Files int
+
+	// Directories is the number of directories, including the root.
+	Directories int
+
+	// Links is the number of links found in the documents.
+	Links int
+
+	// IDs is the number of distinct element ids found in the documents.
+	IDs int
+
+	// InternedStrings is the number of distinct names and links shared between documents.
+	InternedStrings int
+
+	// MemoryBytes is a rough estimate of the memory held by the registered website, excluding external link results.
+	MemoryBytes int64
+
+	// Pages are the link metrics of every document, sorted by document name.
+	Pages []PageMetrics
+}
+
+// Summary counts the registered files and links, estimates the memory they hold, and computes the link metrics of every document.
+func (w *Website) Summary() Summary {
+	const mapEntryBytes = 48 // Rough per-entry cost of a Go map including its bucket overhead.
+
+	var summary Summary
+	summary.MemoryBytes += int64(cap(w.arena.slab)) * int64(unsafe.Sizeof(fsEntity{}))
+	var visit func(entity *fsEntity)
+	visit = func(entity *fsEntity) {
+		summary.MemoryBytes += int64(unsafe.Sizeof(fsEntity{})) + int64(len(entity.fullname))
+		summary.MemoryBytes += int64(len(entity.children)+len(entity.ids)+len(entity.hidden)) * mapEntryBytes
+		summary.MemoryBytes += int64(cap(entity.hrefs)) * int64(unsafe.Sizeof(""))
+		switch {
+		case entity.directory:
+			summary.Directories++
+			for _, child := range entity.children {
+				visit(child)
+			}
+		case entity.document:
+			summary.Documents++
+			summary.Links += len(entity.hrefs)
+			summary.IDs += len(entity.ids)
+			for id := range entity.ids {
+				summary.MemoryBytes += int64(len(id))
+			}
+		default:
+			summary.Files++
+		}
+	}
+	visit(w.root)
+
+	summary.Pages = w.pageMetrics()
+	summary.InternedStrings = len(w.arena.strings)
+	for s := range w.arena.strings {
+		summary.MemoryBytes += int64(len(s)) + mapEntryBytes
+	}
+	return summary
+}
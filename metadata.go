@@ -0,0 +1,86 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// metadata counts the elements describing a document.
+type metadata struct {
+	titles       int
+	h1s          int
+	descriptions int
+	canonicals   int
+}
+
+// WithMetadataAudit warns about documents that do not have exactly one title, one h1 heading,
+// one meta description, and one canonical link.
+// Metadata is not audited by default.
+func WithMetadataAudit(enabled bool) Option {
+	return func(w *Website) {
+		w.metadataAudit = enabled
+	}
+}
+
+// count records the element if it describes the document.
+func (m *metadata) count(element string, s *goquery.Selection) {
+	switch element {
+	case "title":
+		m.titles++
+	case "h1":
+		m.h1s++
+	case "meta":
+		if strings.EqualFold(s.AttrOr("name", ""), "description") && len(strings.TrimSpace(s.AttrOr("content", ""))) > 0 {
+			m.descriptions++
+		}
+	case "link":
+		for _, rel := range strings.Fields(s.AttrOr("rel", "")) {
+			if strings.EqualFold(rel, "canonical") {
+				m.canonicals++
+			}
+		}
+	}
+}
+
+// validateMetadata warns about missing or repeated metadata elements.
+func validateMetadata(website *Website, entity *fsEntity) []error {
+	if !website.metadataAudit {
+		return nil
+	}
+	var errors []error
+	checks := []struct {
+		count int
+		name  string
+	}{
+		{entity.metadata.titles, "<title>"},
+		{entity.metadata.h1s, "<h1>"},
+		{entity.metadata.descriptions, "meta description"},
+		{entity.metadata.canonicals, "canonical link"},
+	}
+	for _, check := range checks {
+		switch {
+		case check.count == 0:
+			errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "document has no %s", check.name))
+		case check.count > 1:
+			errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "document has %d %s elements (it should only have one)", check.count, check.name))
+		}
+	}
+	return errors
+}
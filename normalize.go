@@ -0,0 +1,131 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Normalization configures how external links are rewritten before they are checked.
+// Equivalent links normalize to the same URL so they are checked only once.
+type Normalization struct {
+	// LowercaseHost lowercases the host name.
+	LowercaseHost bool
+
+	// StripDefaultPort removes :80 from http URLs and :443 from https URLs.
+	StripDefaultPort bool
+
+	// CollapseSlashes replaces runs of slashes in the path with a single slash.
+	CollapseSlashes bool
+
+	// ResolveDots resolves . and .. segments in the path.
+	ResolveDots bool
+
+	// StripParams lists query parameters to remove, such as tracking parameters.
+	// A trailing asterisk matches any parameter with the given prefix, as in "utm_*".
+	StripParams []string
+}
+
+// DefaultNormalization enables every normalization rule and strips the utm_* tracking parameters.
+var DefaultNormalization = Normalization{
+	LowercaseHost:    true,
+	StripDefaultPort: true,
+	CollapseSlashes:  true,
+	ResolveDots:      true,
+	StripParams:      []string{"utm_*"},
+}
+
+// WithNormalization normalizes external links before they are checked and cached.
+// External links are not normalized by default.
+func WithNormalization(normalization Normalization) Option {
+	return func(w *Website) {
+		w.normalization = normalization
+	}
+}
+
+// Normalize applies the normalization rules to the link.
+// The link is returned unchanged if it cannot be parsed.
+func (n Normalization) Normalize(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || len(u.Host) == 0 {
+		return link
+	}
+
+	if n.LowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+
+	if n.StripDefaultPort {
+		port := u.Port()
+		if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = strings.TrimSuffix(u.Host, ":"+port)
+		}
+	}
+
+	if n.CollapseSlashes {
+		for strings.Contains(u.Path, "//") {
+			u.Path = strings.Replace(u.Path, "//", "/", -1)
+		}
+		u.RawPath = ""
+	}
+
+	if n.ResolveDots && len(u.Path) > 0 {
+		cleaned := path.Clean(u.Path)
+		if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		u.Path = cleaned
+		u.RawPath = ""
+	}
+
+	if len(n.StripParams) > 0 && len(u.RawQuery) > 0 {
+		query := u.Query()
+		stripped := false
+		for name := range query {
+			if n.strips(name) {
+				query.Del(name)
+				stripped = true
+			}
+		}
+		if stripped {
+			u.RawQuery = query.Encode()
+		}
+	}
+
+	return u.String()
+}
+
+func (n Normalization) strips(param string) bool {
+	return matchParam(n.StripParams, param)
+}
+
+// matchParam reports whether the query parameter matches one of the patterns.
+// A trailing asterisk in a pattern matches any parameter with the given prefix.
+func matchParam(patterns []string, param string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(param, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if param == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// assetRels are the link relations that load a resource used to render the page.
+var assetRels = map[string]bool{
+	"stylesheet":       true,
+	"icon":             true,
+	"apple-touch-icon": true,
+	"mask-icon":        true,
+	"manifest":         true,
+	"preload":          true,
+	"modulepreload":    true,
+	"prefetch":         true,
+}
+
+// WithNotFoundPage names the custom 404 page, relative to the root of the domain.
+// Web servers serve it in place of missing pages at any depth, so relative asset URLs in it break;
+// every relative URL of its scripts, images, sources, stylesheets, and icons is reported as a warning.
+// The default is "404.html". An empty name disables the check.
+func WithNotFoundPage(name string) Option {
+	return func(w *Website) {
+		w.notFoundPage = prepareFileName(name)
+	}
+}
+
+// findRelativeAssets returns the relative URLs of the assets loaded by the document.
+func findRelativeAssets(doc *goquery.Document) []string {
+	var relative []string
+	add := func(href string) {
+		href = strings.TrimSpace(href)
+		if len(href) > 0 && !strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "#") && !strings.Contains(href, ":") {
+			relative = append(relative, href)
+		}
+	}
+	doc.Find("script[src], img, source, link[href]").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "link" {
+			for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+				if assetRels[rel] {
+					add(s.AttrOr("href", ""))
+					break
+				}
+			}
+			return
+		}
+		if src, exists := s.Attr("src"); exists {
+			add(src)
+		}
+		if srcset, exists := s.Attr("srcset"); exists {
+			for _, image := range srcsetURLs(srcset) {
+				add(image)
+			}
+		}
+	})
+	return relative
+}
+
+// srcsetURLs returns the image candidate URLs of a srcset attribute.
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	for _, image := range strings.Split(srcset, ",") {
+		index := strings.LastIndex(image, " ")
+		if index < 0 {
+			urls = append(urls, image)
+		} else {
+			urls = append(urls, image[:index])
+		}
+	}
+	return urls
+}
+
+// validateNotFoundPage warns about the relative asset URLs of the custom 404 page.
+func validateNotFoundPage(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, href := range entity.relativeAssets {
+		errors = append(errors, website.newFinding(entity, href, SeverityWarning, KindPolicy, "404 page uses the relative asset URL '%s' (it breaks when served for deep URLs)", href))
+	}
+	return errors
+}
@@ -0,0 +1,161 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures optional behavior of a Website.
+// Options are passed to New.
+type Option func(*Website)
+
+// WithTracer instruments document parsing, link resolution, and external link checks with spans created by tracer.
+// Tracing is disabled by default.
+func WithTracer(tracer Tracer) Option {
+	return func(w *Website) {
+		if tracer != nil {
+			w.tracer = tracer
+		}
+	}
+}
+
+// WithExternalChecks enables or disables checking external links.
+// External links are checked by default.
+// Disabling them is useful on air-gapped or flaky networks where only internal links and targets can be verified.
+func WithExternalChecks(enabled bool) Option {
+	return func(w *Website) {
+		w.externalChecks = enabled
+	}
+}
+
+// WithInternalChecks enables or disables validating internal links, same page targets, and ids.
+// Internal checks are enabled by default.
+// Disabling them leaves only external links to be checked, which is useful when a static site generator already verifies the internal structure.
+func WithInternalChecks(enabled bool) Option {
+	return func(w *Website) {
+		w.internalChecks = enabled
+	}
+}
+
+// WithReportUnchecked reports every external link that was not checked as an informational LinkError.
+func WithReportUnchecked(enabled bool) Option {
+	return func(w *Website) {
+		w.reportUnchecked = enabled
+	}
+}
+
+// WithRequestTimeout bounds the time spent checking a single external link.
+// The default timeout is two seconds.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(w *Website) {
+		if timeout > 0 {
+			w.requestTimeout = timeout
+		}
+	}
+}
+
+// WithHTTPClient sends the requests checking external links with the client,
+// for proxies, custom TLS configurations, and custom transports.
+// The client's Timeout, if any, applies in addition to WithRequestTimeout; use the latter for longer timeouts.
+// It configures the default checker, or the HTTPChecker given with WithExternalChecker if it comes first,
+// and has no effect on other checkers.
+func WithHTTPClient(client *http.Client) Option {
+	return func(w *Website) {
+		if checker, ok := w.checker.(*HTTPChecker); ok {
+			checker.Client = client
+		}
+	}
+}
+
+// WithMaxRedirects follows at most hops redirects when checking an external link; links redirecting more often fail.
+// With zero hops redirects are not followed, so they are reported by their status code.
+// Like WithHTTPClient, it configures the default checker or an HTTPChecker given with WithExternalChecker first.
+// Up to 10 redirects are followed by default.
+func WithMaxRedirects(hops int) Option {
+	return func(w *Website) {
+		if checker, ok := w.checker.(*HTTPChecker); ok {
+			checker.MaxRedirects = hops
+			if hops <= 0 {
+				checker.MaxRedirects = -1
+			}
+		}
+	}
+}
+
+// WithStrictRedirects reports external links that permanently redirect, with 301 or 308, as warnings
+// suggesting the URL they lead to, so stale links can be updated.
+func WithStrictRedirects(enabled bool) Option {
+	return func(w *Website) {
+		w.strictRedirects = enabled
+	}
+}
+
+// WithAcceptedStatusCodes sets the HTTP status codes with which external links are considered working,
+// replacing the default of any 2xx status. Accepting a redirect status such as 301 only has an effect
+// when redirects are not followed, see WithMaxRedirects.
+func WithAcceptedStatusCodes(codes ...int) Option {
+	return func(w *Website) {
+		w.acceptedStatus = make(map[int]bool)
+		for _, code := range codes {
+			w.acceptedStatus[code] = true
+		}
+	}
+}
+
+// isPermanentRedirect reports whether the status code redirects permanently.
+func isPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// WithOverallDeadline bounds the time spent by a single call to Validate.
+// External links that could not be checked before the deadline are reported as warnings.
+// There is no deadline by default.
+func WithOverallDeadline(deadline time.Duration) Option {
+	return func(w *Website) {
+		w.overallDeadline = deadline
+	}
+}
+
+// WithCircuitBreaker stops checking external links on a host once checks against it failed the given number of times in a row,
+// for example because its name does not resolve or connections are refused.
+// The remaining links to the host are reported as unreachable instead of waiting out the timeout for each one.
+// The circuit breaker is disabled by default.
+func WithCircuitBreaker(failures int) Option {
+	return func(w *Website) {
+		w.breakerThreshold = failures
+	}
+}
+
+// WithLinkAttributes extracts links from additional attributes, such as data-download-url, keyed by element name.
+// The element name "*" matches every element. Element and attribute names are case-insensitive.
+// Each attribute value is treated as a single URL.
+func WithLinkAttributes(attributes map[string][]string) Option {
+	return func(w *Website) {
+		if w.linkAttributes == nil {
+			w.linkAttributes = make(map[string][]string)
+		}
+		for element, attrs := range attributes {
+			element = strings.ToLower(element)
+			for _, attr := range attrs {
+				w.linkAttributes[element] = append(w.linkAttributes[element], strings.ToLower(attr))
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithPaginationCheck verifies rel="next" and rel="prev" links form consistent, loop-free chains:
+// the page linked with rel="next" must link back with rel="prev" and vice versa.
+// Links to pages that do not exist are reported as broken links.
+// Pagination is not checked by default.
+func WithPaginationCheck(enabled bool) Option {
+	return func(w *Website) {
+		w.paginationCheck = enabled
+	}
+}
+
+// recordPagination remembers the first rel="next" and rel="prev" links of the document.
+func recordPagination(entity *fsEntity, s *goquery.Selection) {
+	href, exists := s.Attr("href")
+	if !exists {
+		return
+	}
+	for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+		switch rel {
+		case "next":
+			if len(entity.next) == 0 {
+				entity.next = href
+			}
+		case "prev", "previous":
+			if len(entity.prev) == 0 {
+				entity.prev = href
+			}
+		}
+	}
+}
+
+// resolveInternal returns the file an internal link refers to or nil if it does not exist.
+func (w *Website) resolveInternal(entity *fsEntity, href string) *fsEntity {
+	href = sanitizeHref(href)
+	if path, site := w.sitePath(href); site {
+		href = path
+	}
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	if len(href) == 0 || w.isExternal(href) || strings.Contains(href, ":") {
+		return nil
+	}
+	href, inside := w.stripBasePath(href)
+	if !inside {
+		return nil
+	}
+	if strings.HasPrefix(href, "/") {
+		return w.resolvePath(w.root, href)
+	}
+	return w.resolvePath(entity.parent, href)
+}
+
+// validatePagination verifies the pagination links of the document are reciprocated and do not loop.
+func validatePagination(website *Website, entity *fsEntity) []error {
+	if !website.paginationCheck {
+		return nil
+	}
+
+	var errors []error
+	if next := website.resolveInternal(entity, entity.next); next != nil {
+		if website.resolveInternal(next, next.prev) != entity {
+			errors = append(errors, website.newFinding(entity, entity.next, SeverityWarning, KindPolicy, "rel=next target '%s' does not link back with rel=prev", entity.next))
+		}
+	}
+	if prev := website.resolveInternal(entity, entity.prev); prev != nil {
+		if website.resolveInternal(prev, prev.next) != entity {
+			errors = append(errors, website.newFinding(entity, entity.prev, SeverityWarning, KindPolicy, "rel=prev target '%s' does not link back with rel=next", entity.prev))
+		}
+	}
+
+	// Follow the chain and report a loop once, from the page of the loop whose name sorts first.
+	visited := map[*fsEntity]bool{entity: true}
+	for page := website.resolveInternal(entity, entity.next); page != nil; page = website.resolveInternal(page, page.next) {
+		if page == entity {
+			errors = append(errors, website.newFinding(entity, entity.next, SeverityWarning, KindPolicy, "rel=next chain loops back to this page"))
+			break
+		}
+		if visited[page] || page.fullname < entity.fullname {
+			// The loop does not include this page or is reported by another page.
+			break
+		}
+		visited[page] = true
+	}
+	return errors
+}
@@ -0,0 +1,86 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// WithLinePositions records where every link appears in its document,
+// so findings report the Line and Column of their link. It costs a second pass over every document.
+func WithLinePositions(enabled bool) Option {
+	return func(w *Website) {
+		w.linePositions = enabled
+	}
+}
+
+// position is a location in a document, counting lines and columns from 1.
+type position struct {
+	line   int
+	column int
+}
+
+// advance returns the position after the text.
+func (p position) advance(text string) position {
+	for _, r := range text {
+		if r == '\n' {
+			p.line++
+			p.column = 1
+		} else {
+			p.column++
+		}
+	}
+	return p
+}
+
+// urlAttributes are the attributes whose values are located by linkPositions.
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "srcset": true, "action": true, "formaction": true, "poster": true,
+	"data": true, "cite": true, "background": true, "longdesc": true, "content": true, "xlink:href": true,
+}
+
+// linkPositions locates the first occurrence of every URL attribute value in the document.
+func linkPositions(document []byte) map[string]position {
+	positions := make(map[string]position)
+	current := position{line: 1, column: 1}
+	tokenizer := html.NewTokenizer(bytes.NewReader(document))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		raw := string(tokenizer.Raw())
+		if tokenType == html.StartTagToken || tokenType == html.SelfClosingTagToken {
+			for _, attribute := range tokenizer.Token().Attr {
+				if _, exists := positions[attribute.Val]; exists || !urlAttributes[attribute.Key] || len(attribute.Val) == 0 {
+					continue
+				}
+				// Point at the value if it appears verbatim and at the tag otherwise, as when it contains character references.
+				offset := strings.Index(raw, attribute.Val)
+				if offset < 0 {
+					offset = 0
+				}
+				positions[attribute.Val] = current.advance(raw[:offset])
+			}
+		}
+		current = current.advance(raw)
+	}
+	return positions
+}
@@ -0,0 +1,220 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Preset configures LinkUp for the output of a static site generator or hosting service.
+type Preset struct {
+	// Dir is the directory holding the built website.
+	Dir string
+
+	// Options configure the website according to the generator's conventions.
+	Options []Option
+}
+
+// PresetFunc creates a preset for the project or output directory.
+type PresetFunc func(dir string) (*Preset, error)
+
+var presets = map[string]PresetFunc{
+	"docusaurus":   DocusaurusPreset,
+	"github-pages": GitHubPagesPreset,
+	"hugo":         HugoPreset,
+	"jekyll":       JekyllPreset,
+	"mdbook":       MdBookPreset,
+	"mkdocs":       MkDocsPreset,
+}
+
+// LoadPreset creates the named preset, such as "hugo", for the directory.
+// The directory may be the project holding the generator's configuration or the directory the website was built into.
+func LoadPreset(name, dir string) (*Preset, error) {
+	preset, exists := presets[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("unknown preset '%s' (expected one of %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	return preset(dir)
+}
+
+// PresetNames returns the names of the presets in alphabetical order.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findProject locates the generator's configuration file in the directory or its parent, which is where it lives when the output directory is given.
+// It returns the project directory and the configuration file, or empty strings if neither directory has one of the files.
+func findProject(dir string, configs ...string) (string, string) {
+	for _, candidate := range []string{dir, filepath.Dir(filepath.Clean(dir))} {
+		for _, config := range configs {
+			name := filepath.Join(candidate, config)
+			if info, err := os.Stat(name); err == nil && !info.IsDir() {
+				return candidate, name
+			}
+		}
+	}
+	return "", ""
+}
+
+// outputDir returns the output directory of a project: the given directory if it is not the project itself, otherwise the named subdirectory.
+func outputDir(dir, project, output string) string {
+	if len(project) == 0 || filepath.Clean(dir) != filepath.Clean(project) {
+		return dir
+	}
+	return filepath.Join(project, output)
+}
+
+// readSettings reads the top-level settings of a TOML, YAML, or JSON configuration file.
+func readSettings(name string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(name) == ".json" {
+		var values map[string]interface{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		settings := make(map[string][]string)
+		for key, value := range values {
+			switch value := value.(type) {
+			case []interface{}:
+				for _, item := range value {
+					settings[key] = append(settings[key], fmt.Sprint(item))
+				}
+			case map[string]interface{}:
+			default:
+				settings[key] = []string{fmt.Sprint(value)}
+			}
+		}
+		return settings, nil
+	}
+	return parseSettings(data), nil
+}
+
+// parseSettings reads the top-level keys of TOML or YAML, which is enough for the handful of settings the presets need.
+// Values may be scalars, inline lists like ["a", "b"], or YAML block lists.
+// Keys in TOML tables are prefixed with the table name, as in "build.build-dir", and nested YAML mappings are skipped.
+func parseSettings(data []byte) map[string][]string {
+	settings := make(map[string][]string)
+	listKey := ""
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			table = strings.Trim(trimmed, "[] ") + "."
+			if strings.HasPrefix(trimmed, "[[") {
+				table = "[]" // Arrays of tables are skipped.
+			}
+			listKey = ""
+			continue
+		}
+		if table == "[]" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			if len(listKey) > 0 && strings.HasPrefix(trimmed, "- ") {
+				settings[listKey] = append(settings[listKey], unquote(strings.TrimSpace(trimmed[2:])))
+			}
+			continue
+		}
+
+		listKey = ""
+		i := strings.IndexAny(trimmed, ":=")
+		if i < 0 {
+			continue
+		}
+		key := table + unquote(strings.TrimSpace(trimmed[:i]))
+		value := stripComment(strings.TrimSpace(trimmed[i+1:]))
+		switch {
+		case len(value) == 0:
+			listKey = key
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			settings[key] = []string{}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); len(item) > 0 {
+					settings[key] = append(settings[key], unquote(item))
+				}
+			}
+		default:
+			settings[key] = []string{unquote(value)}
+		}
+	}
+	return settings
+}
+
+// frontMatter returns the settings in the YAML or TOML front matter of a content file.
+func frontMatter(data []byte) map[string][]string {
+	for _, fence := range []string{"---", "+++"} {
+		if !bytes.HasPrefix(data, []byte(fence)) {
+			continue
+		}
+		rest := data[len(fence):]
+		if end := bytes.Index(rest, []byte("\n"+fence)); end >= 0 {
+			return parseSettings(rest[:end])
+		}
+	}
+	return map[string][]string{}
+}
+
+// setting returns the first value of the setting or the fallback if it is not set.
+func setting(settings map[string][]string, key, fallback string) string {
+	if values := settings[key]; len(values) > 0 {
+		return values[0]
+	}
+	return fallback
+}
+
+// stripComment removes a trailing comment from an unquoted value.
+func stripComment(value string) string {
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `'`) {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[:end+2]
+		}
+		return value
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		return strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// unquote removes the quotes around a value.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
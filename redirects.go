@@ -0,0 +1,116 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"path"
+	"strings"
+)
+
+// WithRedirects declares the redirects the website is served with, mapping old paths to the paths or URLs they redirect to,
+// such as the aliases of a static site generator or the rules of a _redirects file.
+// Paths are relative to the root of the domain and a path naming a directory also matches its index file.
+// Links to a redirected path are reported as warnings so they can be updated,
+// and as errors if the redirect leads to a file that does not exist.
+// Chains of redirects are followed and reported with every hop, and redirects that loop are reported as errors.
+// Redirects to external URLs are not followed.
+func WithRedirects(redirects map[string]string) Option {
+	return func(w *Website) {
+		if w.redirects == nil {
+			w.redirects = make(map[string]string)
+		}
+		for from, to := range redirects {
+			w.redirects[redirectKey(from)] = to
+		}
+	}
+}
+
+// redirectKey normalizes a path so the different ways of linking to a page match the same redirect.
+func redirectKey(name string) string {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	for _, index := range indexFiles {
+		if name == index {
+			return ""
+		}
+		name = strings.TrimSuffix(name, "/"+index)
+	}
+	return name
+}
+
+// redirect returns where the internal link redirects to, if it does.
+func (w *Website) redirect(entity *fsEntity, href string) (string, bool) {
+	if len(w.redirects) == 0 {
+		return "", false
+	}
+	name, ok := linkedPath(entity, href)
+	if !ok {
+		return "", false
+	}
+	to, exists := w.redirects[redirectKey(name)]
+	return to, exists
+}
+
+// redirectChain follows the internal redirects that start at the given path and returns every path or URL redirected to in order.
+// It also reports whether the chain loops back to a path it already passed through.
+func (w *Website) redirectChain(from, to string) ([]string, bool) {
+	seen := map[string]bool{redirectKey(from): true}
+	chain := []string{to}
+	for !w.isExternal(to) {
+		key := to
+		if i := strings.IndexAny(key, "?#"); i >= 0 {
+			key = key[:i]
+		}
+		key = redirectKey(key)
+		if seen[key] {
+			return chain, true
+		}
+		seen[key] = true
+		next, exists := w.redirects[key]
+		if !exists {
+			break
+		}
+		to = next
+		chain = append(chain, to)
+	}
+	return chain, false
+}
+
+// checkRedirect reports a link to a redirected path.
+// Links whose redirect leads to another redirect are reported with the full chain so the redirects can be collapsed.
+func (w *Website) checkRedirect(entity *fsEntity, raw, href, to string) *LinkError {
+	from, _ := linkedPath(entity, href)
+	chain, loop := w.redirectChain(from, to)
+	hops := strings.Join(chain, "' -> '")
+	if loop {
+		return w.newLinkError(entity, raw, KindBroken, "broken link '%s' (it redirects in a loop: '%s')", href, hops)
+	}
+
+	to = chain[len(chain)-1]
+	if !w.isExternal(to) {
+		target := to
+		if i := strings.IndexAny(target, "?#"); i >= 0 {
+			target = target[:i]
+		}
+		if w.resolvePath(w.root, target) == nil {
+			return w.newLinkError(entity, raw, KindBroken, "broken link '%s' (it redirects to '%s', which does not exist)", href, hops)
+		}
+	}
+	if len(chain) > 1 {
+		return w.newFinding(entity, raw, SeverityWarning, KindRedirect, "link '%s' redirects through a chain: '%s'", href, hops)
+	}
+	return w.newFinding(entity, raw, SeverityWarning, KindRedirect, "link '%s' redirects to '%s'", href, to)
+}
@@ -0,0 +1,145 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithTargetBlankCheck warns about external links that open in a new tab with target="_blank"
+// but do not have rel="noopener" or rel="noreferrer", which stops the opened page from controlling the opener through window.opener.
+func WithTargetBlankCheck(enabled bool) Option {
+	return func(w *Website) {
+		w.targetBlankCheck = enabled
+	}
+}
+
+// RelPolicy is a set of link types, given in the rel attribute, that links to a host must or must not have.
+type RelPolicy struct {
+	// Require lists the link types every link to the host must have, such as "nofollow" and "sponsored" for affiliate links.
+	Require []string
+
+	// Forbid lists the link types links to the host must not have, such as "nofollow" for links to partner websites.
+	Forbid []string
+}
+
+type relPolicyRule struct {
+	pattern string
+	policy  RelPolicy
+}
+
+// relLink is an external link subject to a rel policy.
+type relLink struct {
+	href   string
+	rels   []string
+	policy RelPolicy
+}
+
+// WithRelPolicy reports links to hosts matching the glob pattern, as in "*.affiliate.example", whose rel attribute violates the policy.
+// This helps websites meet advertising disclosure requirements, such as marking affiliate links as sponsored.
+// Patterns are tried in the order they are given and the first match wins.
+func WithRelPolicy(pattern string, policy RelPolicy) Option {
+	return func(w *Website) {
+		w.relPolicies = append(w.relPolicies, relPolicyRule{strings.ToLower(pattern), policy})
+	}
+}
+
+// recordRelPolicy records the link if a rel policy applies to its host.
+func (w *Website) recordRelPolicy(entity *fsEntity, s *goquery.Selection) {
+	href, exists := s.Attr("href")
+	if !exists {
+		return
+	}
+	link := sanitizeHref(href)
+	if !w.isExternal(link) {
+		return
+	}
+	host := linkHost(link)
+	for _, rule := range w.relPolicies {
+		if globMatch(rule.pattern, host) {
+			entity.relLinks = append(entity.relLinks, relLink{href: href, rels: relValues(s), policy: rule.policy})
+			return
+		}
+	}
+}
+
+// validateRelPolicies reports links missing required link types or having forbidden ones.
+func validateRelPolicies(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, link := range entity.relLinks {
+		var missing, forbidden []string
+		for _, rel := range link.policy.Require {
+			if !hasRel(link.rels, strings.ToLower(rel)) {
+				missing = append(missing, rel)
+			}
+		}
+		for _, rel := range link.policy.Forbid {
+			if hasRel(link.rels, strings.ToLower(rel)) {
+				forbidden = append(forbidden, rel)
+			}
+		}
+		if len(missing) > 0 {
+			errors = append(errors, website.newLinkError(entity, link.href, KindPolicy, "link '%s' is missing rel=\"%s\"", sanitizeHref(link.href), strings.Join(missing, " ")))
+		}
+		if len(forbidden) > 0 {
+			errors = append(errors, website.newLinkError(entity, link.href, KindPolicy, "link '%s' must not have rel=\"%s\"", sanitizeHref(link.href), strings.Join(forbidden, " ")))
+		}
+	}
+	return errors
+}
+
+// relValues returns the lowercased link types of the element's rel attribute.
+func relValues(s *goquery.Selection) []string {
+	return strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+}
+
+// hasRel reports whether the link types include the type.
+func hasRel(rels []string, rel string) bool {
+	for _, value := range rels {
+		if value == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// recordTargetBlank records the link if it opens an external page in a new tab without severing the opener.
+func (w *Website) recordTargetBlank(entity *fsEntity, s *goquery.Selection) {
+	href, exists := s.Attr("href")
+	if !exists || !strings.EqualFold(strings.TrimSpace(s.AttrOr("target", "")), "_blank") {
+		return
+	}
+	if link := sanitizeHref(href); !w.isExternal(link) && !strings.HasPrefix(link, "//") {
+		return
+	}
+	rels := relValues(s)
+	if !hasRel(rels, "noopener") && !hasRel(rels, "noreferrer") {
+		entity.openerLinks = append(entity.openerLinks, href)
+	}
+}
+
+// validateTargetBlank warns about external links opened in a new tab without rel="noopener".
+func validateTargetBlank(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, href := range entity.openerLinks {
+		errors = append(errors, website.newFinding(entity, href, SeverityWarning, KindPolicy,
+			"link '%s' opens in a new tab without rel=\"noopener\"", sanitizeHref(href)))
+	}
+	return errors
+}
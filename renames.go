@@ -0,0 +1,123 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Rename records that a file was renamed or deleted.
+type Rename struct {
+	// From is the old name of the file relative to the root of the domain.
+	From string
+
+	// To is the new name of the file relative to the root of the domain.
+	// It is empty if the file was deleted.
+	To string
+
+	// Commit identifies the change that renamed or deleted the file.
+	Commit string
+}
+
+// WithRenames enhances broken link errors whose target is known to have been renamed or deleted.
+// The renames must be ordered from newest to oldest.
+func WithRenames(renames []Rename) Option {
+	return func(w *Website) {
+		if w.renames == nil {
+			w.renames = make(map[string]Rename)
+		}
+		for _, rename := range renames {
+			// Only the most recent change to a name is relevant.
+			if _, exists := w.renames[rename.From]; !exists {
+				w.renames[rename.From] = rename
+			}
+		}
+	}
+}
+
+// GitRenames returns the files renamed or deleted by the last n commits of the git repository containing dir, newest first.
+// The directory is treated as the root of the domain so only files beneath it are reported.
+// The git executable must be installed.
+func GitRenames(dir string, n int) ([]Rename, error) {
+	cmd := exec.Command("git", "-c", "core.quotepath=off", "log", "-n", strconv.Itoa(n),
+		"--format=commit %h", "--name-status", "-M", "--diff-filter=RD", "--relative", "--", ".")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var renames []Rename
+	commit := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "commit ") {
+			commit = strings.TrimPrefix(line, "commit ")
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		switch {
+		case len(fields) == 3 && strings.HasPrefix(fields[0], "R"):
+			renames = append(renames, Rename{From: fields[1], To: fields[2], Commit: commit})
+		case len(fields) == 2 && fields[0] == "D":
+			renames = append(renames, Rename{From: fields[1], Commit: commit})
+		}
+	}
+	return renames, scanner.Err()
+}
+
+// followRename returns the most recent rename of the named file, following the file through subsequent renames.
+func (w *Website) followRename(name string) (Rename, bool) {
+	rename, exists := w.renames[name]
+	if !exists {
+		return rename, false
+	}
+	for i := 0; i < len(w.renames) && len(rename.To) > 0; i++ {
+		next, exists := w.renames[rename.To]
+		if !exists || isPathValid(w.root, splitPath(rename.To)) != nil {
+			break
+		}
+		rename = next
+	}
+	return rename, true
+}
+
+// renameHint explains why a broken link no longer resolves if its target was renamed or deleted.
+func (w *Website) renameHint(entity *fsEntity, href string) string {
+	name, ok := linkedPath(entity, href)
+	if !ok {
+		return ""
+	}
+
+	rename, ok := w.followRename(name)
+	if !ok {
+		return ""
+	}
+
+	if len(rename.To) == 0 {
+		return fmt.Sprintf(" (this target was deleted in commit %s)", rename.Commit)
+	}
+	return fmt.Sprintf(" (this target was renamed to '%s' in commit %s)", rename.To, rename.Commit)
+}
@@ -0,0 +1,109 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// WithClientRoutes validates fragment router links, such as "/#/settings/profile", against the routes of a single-page app
+// instead of the ids of the target page.
+// A fragment is treated as a client-side route if it begins with a slash.
+// Routes are written like "/settings/profile" where a segment beginning with a colon, as in "/users/:id", matches any single segment
+// and a trailing "*" matches the rest of the route.
+// Routes are not recognized by default.
+func WithClientRoutes(routes []string) Option {
+	return func(w *Website) {
+		for _, route := range routes {
+			w.clientRoutes = append(w.clientRoutes, compileRoute(route))
+		}
+	}
+}
+
+// WithClientRoutePatterns validates fragment router links against regular expressions.
+// Each expression must match the whole route, including the leading slash, for the link to be valid.
+func WithClientRoutePatterns(patterns []*regexp.Regexp) Option {
+	return func(w *Website) {
+		for _, pattern := range patterns {
+			w.clientRoutes = append(w.clientRoutes, regexp.MustCompile("^(?:"+pattern.String()+")$"))
+		}
+	}
+}
+
+// LoadRoutes reads a route manifest listing one client-side route per line.
+// Blank lines and lines beginning with "#" are ignored.
+func LoadRoutes(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var routes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		routes = append(routes, line)
+	}
+	return routes, scanner.Err()
+}
+
+// compileRoute converts a route into a regular expression matching the whole route.
+func compileRoute(route string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i, segment := range strings.Split(route, "/") {
+		if i > 0 {
+			pattern.WriteString("/")
+		}
+		switch {
+		case segment == "*":
+			pattern.WriteString(".*")
+		case strings.HasPrefix(segment, ":"):
+			pattern.WriteString("[^/]+")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(segment))
+		}
+	}
+	pattern.WriteString("/?$")
+	return regexp.MustCompile(pattern.String())
+}
+
+// isClientRoute reports whether the fragment should be validated as a client-side route.
+func (w *Website) isClientRoute(fragment string) bool {
+	return len(w.clientRoutes) > 0 && strings.HasPrefix(fragment, "/")
+}
+
+// matchesClientRoute reports whether the fragment matches a known client-side route.
+// The query string of the route, if any, is ignored.
+func (w *Website) matchesClientRoute(fragment string) bool {
+	if i := strings.IndexByte(fragment, '?'); i >= 0 {
+		fragment = fragment[:i]
+	}
+	for _, route := range w.clientRoutes {
+		if route.MatchString(fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	schemeHandlersMu sync.RWMutex
+	schemeHandlers   = make(map[string]ExternalChecker)
+)
+
+func init() {
+	ftp := &FTPChecker{}
+	RegisterSchemeHandler("ftp", ftp)
+	RegisterSchemeHandler("ftps", ftp)
+}
+
+// RegisterSchemeHandler makes a checker available for links with the given scheme, such as "ipfs" or "magnet",
+// to every Website created afterwards. Links with a registered scheme are treated as external links.
+// The ftp and ftps schemes are registered by default.
+// Websites can override the handler for a scheme with WithSchemeHandler.
+// If RegisterSchemeHandler is called twice with the same scheme or if handler is nil, it panics.
+func RegisterSchemeHandler(scheme string, handler ExternalChecker) {
+	scheme = strings.ToLower(scheme)
+	schemeHandlersMu.Lock()
+	defer schemeHandlersMu.Unlock()
+	if handler == nil {
+		panic("linkup: RegisterSchemeHandler handler is nil")
+	}
+	if _, dup := schemeHandlers[scheme]; dup {
+		panic(fmt.Sprintf("linkup: RegisterSchemeHandler called twice for scheme '%s'", scheme))
+	}
+	schemeHandlers[scheme] = handler
+}
+
+// SchemeHandlers returns the sorted names of the registered schemes.
+func SchemeHandlers() []string {
+	schemeHandlersMu.RLock()
+	defer schemeHandlersMu.RUnlock()
+	schemes := make([]string, 0, len(schemeHandlers))
+	for scheme := range schemeHandlers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// WithSchemeHandler checks links with the given scheme using handler for this website only.
+// A nil handler stops links with the scheme from being treated as external links.
+// The http and https schemes are always checked by the checker set with WithExternalChecker.
+func WithSchemeHandler(scheme string, handler ExternalChecker) Option {
+	return func(w *Website) {
+		scheme = strings.ToLower(scheme)
+		if handler == nil {
+			delete(w.schemes, scheme)
+			return
+		}
+		w.schemes[scheme] = handler
+	}
+}
+
+// registeredSchemes returns a copy of the registered scheme handlers.
+func registeredSchemes() map[string]ExternalChecker {
+	schemeHandlersMu.RLock()
+	defer schemeHandlersMu.RUnlock()
+	schemes := make(map[string]ExternalChecker, len(schemeHandlers))
+	for scheme, handler := range schemeHandlers {
+		schemes[scheme] = handler
+	}
+	return schemes
+}
+
+// linkScheme returns the lowercase scheme of a link or an empty string if it has none.
+func linkScheme(link string) string {
+	i := strings.IndexByte(link, ':')
+	if i <= 0 {
+		return ""
+	}
+	for j, r := range link[:i] {
+		letter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (j == 0 || !((r >= '0' && r <= '9') || r == '+' || r == '-' || r == '.')) {
+			return ""
+		}
+	}
+	return strings.ToLower(link[:i])
+}
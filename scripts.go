@@ -0,0 +1,81 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// navigationPattern matches the obvious ways an inline event handler navigates to a literal URL.
+var navigationPattern = regexp.MustCompile(`(?:location(?:\.href)?\s*=|location\.(?:assign|replace)\s*\(|window\.open\s*\()\s*(?:'([^'\\]*)'|"([^"\\]*)")`)
+
+// DefaultScriptLinkPattern matches string literals holding absolute paths to pages or directories, as in "/docs/intro.html" or '/blog/'.
+var DefaultScriptLinkPattern = regexp.MustCompile("[\"'`](/[\\w\\-./]*(?:\\.html?|/))[\"'`]")
+
+// WithScriptLinks scans the bodies of inline scripts for links matching the pattern.
+// If the pattern has a capturing group, the first group is the link; otherwise the whole match is.
+// Only same-site absolute paths, those beginning with a single slash, are extracted
+// since relative strings in scripts are resolved by the script rather than the document.
+// Pass DefaultScriptLinkPattern to extract path literals to pages and directories.
+// Inline scripts are not scanned by default.
+func WithScriptLinks(pattern *regexp.Regexp) Option {
+	return func(w *Website) {
+		w.scriptLinks = pattern
+	}
+}
+
+// scriptLinks returns the same-site absolute paths found in the body of an inline script.
+func scriptLinks(pattern *regexp.Regexp, script string) []string {
+	var links []string
+	for _, match := range pattern.FindAllStringSubmatch(script, -1) {
+		link := match[0]
+		if len(match) > 1 {
+			link = match[1]
+		}
+		if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// WithEventHandlerLinks extracts links from inline event handlers, such as onclick="location.href='/pricing.html'".
+// Only assignments to location or location.href and calls to location.assign, location.replace, and window.open
+// with a string literal are recognized.
+// Event handlers are not scanned by default.
+func WithEventHandlerLinks(enabled bool) Option {
+	return func(w *Website) {
+		w.eventHandlerLinks = enabled
+	}
+}
+
+// eventHandlerLinks returns the links navigated to by the inline event handlers of the element.
+func eventHandlerLinks(s *goquery.Selection) []string {
+	var links []string
+	for _, attr := range s.Nodes[0].Attr {
+		if !strings.HasPrefix(strings.ToLower(attr.Key), "on") {
+			continue
+		}
+		for _, match := range navigationPattern.FindAllStringSubmatch(attr.Val, -1) {
+			links = append(links, match[1]+match[2])
+		}
+	}
+	return links
+}
@@ -0,0 +1,115 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PageFindings are the findings of a single page as they are served by FindingsHandler.
+type PageFindings struct {
+	// URL is the URL the findings were requested for.
+	URL string `json:"url"`
+
+	// Document is the name of the document the URL resolves to.
+	Document string `json:"document"`
+
+	// Findings are the findings of the document.
+	Findings []PageFinding `json:"findings"`
+}
+
+// PageFinding is a finding of a page as it is served by FindingsHandler.
+type PageFinding struct {
+	Href        string `json:"href,omitempty"`
+	Message     string `json:"message"`
+	Severity    string `json:"severity"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// FindingsHandler serves the findings of a validation as JSON, one page at a time,
+// so a bookmarklet or browser extension can highlight broken links while browsing a staging copy of the website.
+// The page is given by the url query parameter, as in /?url=https://staging.example/docs/, and is resolved
+// relative to the URL of WithSiteURL and the base path of WithBasePath. URLs on other hosts than the site URL are not found.
+// Responses allow cross-origin requests since they are made from the pages being browsed.
+func (w *Website) FindingsHandler(errs []error) http.Handler {
+	byDocument := make(map[string][]PageFinding)
+	for _, err := range errs {
+		var linkErr *LinkError
+		if errors.As(err, &linkErr) {
+			byDocument[linkErr.Document] = append(byDocument[linkErr.Document], PageFinding{
+				Href:        linkErr.Href,
+				Message:     linkErr.Message,
+				Severity:    linkErr.Severity.String(),
+				Fingerprint: linkErr.Fingerprint,
+			})
+		}
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Access-Control-Allow-Origin", "*")
+		rw.Header().Set("Content-Type", "application/json")
+		raw := r.URL.Query().Get("url")
+		u, err := url.Parse(raw)
+		if len(raw) == 0 || err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(rw).Encode(map[string]string{"error": "missing or malformed url parameter"})
+			return
+		}
+
+		entity := w.pageEntity(u)
+		if entity == nil {
+			rw.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(rw).Encode(map[string]string{"error": "no document is served at the url"})
+			return
+		}
+		page := PageFindings{URL: raw, Document: entity.fullname, Findings: byDocument[entity.fullname]}
+		if page.Findings == nil {
+			page.Findings = []PageFinding{}
+		}
+		json.NewEncoder(rw).Encode(page)
+	})
+}
+
+// pageEntity returns the document served at the URL.
+func (w *Website) pageEntity(u *url.URL) *fsEntity {
+	if u.IsAbs() && w.siteURL != nil && !w.isSiteLink(u.String()) {
+		return nil
+	}
+	name := u.EscapedPath()
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if w.siteURL != nil && len(w.siteURL.Path) > 1 && strings.HasPrefix(name+"/", w.siteURL.Path) {
+		name = "/" + strings.TrimPrefix(strings.TrimPrefix(name+"/", w.siteURL.Path), "/")
+	}
+	if len(name) == 0 {
+		name = "/"
+	}
+	name, ok := w.stripBasePath(name)
+	if !ok {
+		return nil
+	}
+	entity := w.lookupPath(w.root, name)
+	if entity == nil || !entity.document {
+		return nil
+	}
+	return entity
+}
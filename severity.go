@@ -0,0 +1,79 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+// SeverityOverride changes the severity of the findings it matches, to reduce noise from known offenders
+// or to escalate problems a team cares about. Every condition that is set must match.
+type SeverityOverride struct {
+	// Link is a glob pattern matched against the link of the finding, such as "*://*.linkedin.com/*".
+	// In the pattern "*" matches any run of characters, including slashes, and "?" matches a single character.
+	Link string
+
+	// Message is a glob pattern matched against the message of the finding, such as "*redirects to*".
+	// Messages are matched as reported, so with WithCatalog the pattern must match the translated message;
+	// use Kinds to select findings regardless of the language.
+	Message string
+
+	// Kinds matches findings of any of the kinds, such as KindRedirect. If empty, findings of every kind match.
+	Kinds []Kind
+
+	// Status matches findings for external links that responded with the HTTP status code.
+	Status int
+
+	// Severity is the severity the matching findings are reported with.
+	Severity Severity
+}
+
+// WithSeverityOverride changes the severity of the findings matched by the override.
+// Overrides are tried in the order they are given and the first match wins.
+func WithSeverityOverride(override SeverityOverride) Option {
+	return func(w *Website) {
+		w.severityOverrides = append(w.severityOverrides, override)
+	}
+}
+
+// matches reports whether the override applies to the finding.
+func (o *SeverityOverride) matches(err *LinkError) bool {
+	if len(o.Link) > 0 && !globMatch(o.Link, sanitizeHref(err.Href)) {
+		return false
+	}
+	if len(o.Message) > 0 && !globMatch(o.Message, err.Message) {
+		return false
+	}
+	if o.Status != 0 && (err.External == nil || err.External.StatusCode != o.Status) {
+		return false
+	}
+	if len(o.Kinds) > 0 {
+		for _, kind := range o.Kinds {
+			if err.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// overrideSeverity applies the first matching severity override to the finding.
+func (w *Website) overrideSeverity(err *LinkError) {
+	for i := range w.severityOverrides {
+		if w.severityOverrides[i].matches(err) {
+			err.Severity = w.severityOverrides[i].Severity
+			return
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Slugifier derives the id a static site generator assigns to a heading from the heading's text.
+type Slugifier interface {
+	Slugify(text string) string
+}
+
+// SlugifierFunc adapts an ordinary function to a Slugifier so custom slug rules can be used.
+type SlugifierFunc func(text string) string
+
+// Slugify calls f(text).
+func (f SlugifierFunc) Slugify(text string) string {
+	return f(text)
+}
+
+var (
+	// GitHubSlugs generates heading ids the way GitHub renders Markdown:
+	// the text is lowercased, punctuation is removed, and spaces become hyphens.
+	GitHubSlugs Slugifier = githubSlugifier{}
+
+	// HugoSlugs generates heading ids the way Hugo does by default, which follows the GitHub rules.
+	HugoSlugs Slugifier = githubSlugifier{}
+
+	// JekyllSlugs generates heading ids the way Jekyll does through kramdown:
+	// only ASCII letters, digits, spaces, and hyphens are kept, everything before the first letter is removed,
+	// spaces become hyphens, and the result is lowercased.
+	JekyllSlugs Slugifier = jekyllSlugifier{}
+
+	// GoldmarkSlugs generates heading ids the way the Goldmark Markdown parser does:
+	// ASCII letters and digits are kept and lowercased, spaces, hyphens, and underscores become hyphens,
+	// and everything else is removed.
+	GoldmarkSlugs Slugifier = goldmarkSlugifier{}
+
+	// KramdownSlugs generates heading ids the way the kramdown Markdown parser does, which is what Jekyll uses.
+	KramdownSlugs Slugifier = jekyllSlugifier{}
+
+	// MkDocsSlugs generates heading ids the way MkDocs does through the Python-Markdown toc extension:
+	// non-ASCII characters and punctuation other than hyphens and underscores are removed, the result is lowercased,
+	// and runs of spaces and hyphens become a single hyphen.
+	MkDocsSlugs Slugifier = mkdocsSlugifier{}
+
+	// PandocSlugs generates heading ids the way Pandoc does:
+	// only letters, digits, underscores, hyphens, and periods are kept, everything before the first letter is removed,
+	// spaces become hyphens, and the result is lowercased.
+	PandocSlugs Slugifier = pandocSlugifier{}
+)
+
+// WithHeadingSlugs infers ids for headings without an id attribute from their text.
+// This avoids false positives when validating templates whose heading ids are generated at render time.
+// Like most generators, duplicate slugs on the same page are made unique with a numeric suffix.
+func WithHeadingSlugs(slugifier Slugifier) Option {
+	return func(w *Website) {
+		w.slugifier = slugifier
+	}
+}
+
+func isHeading(s *goquery.Selection) bool {
+	switch strings.ToLower(goquery.NodeName(s)) {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	}
+	return false
+}
+
+func inferHeadingID(entity *fsEntity, slugifier Slugifier, s *goquery.Selection) string {
+	slug := slugifier.Slugify(strings.Join(strings.Fields(s.Text()), " "))
+	if len(slug) == 0 {
+		return ""
+	}
+	id := slug
+	for n := 1; entity.ids[id] > 0; n++ {
+		id = fmt.Sprintf("%s-%d", slug, n)
+	}
+	if id != slug {
+		entity.slugCollisions = append(entity.slugCollisions, slugCollision{text: s.Text(), slug: slug, id: id})
+	}
+	entity.ids[id]++
+	return id
+}
+
+// slugCollision records a heading whose inferred id was already taken on the page.
+type slugCollision struct {
+	text string
+	slug string
+	id   string
+}
+
+// validateSlugCollisions warns about headings whose inferred ids collide, since fragment links may bind to the wrong section.
+func validateSlugCollisions(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, collision := range entity.slugCollisions {
+		errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument,
+			"heading '%s' collides with the id '%s' (generators will rename it to '%s')",
+			strings.Join(strings.Fields(collision.text), " "), collision.slug, collision.id))
+	}
+	return errors
+}
+
+type githubSlugifier struct{}
+
+func (githubSlugifier) Slugify(text string) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			slug.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			slug.WriteRune(r)
+		}
+	}
+	return slug.String()
+}
+
+type jekyllSlugifier struct{}
+
+func (jekyllSlugifier) Slugify(text string) string {
+	var slug strings.Builder
+	for _, r := range text {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if slug.Len() == 0 && !isLetter {
+			continue
+		}
+		switch {
+		case r == ' ':
+			slug.WriteRune('-')
+		case r == '-' || isLetter || (r >= '0' && r <= '9'):
+			slug.WriteRune(unicode.ToLower(r))
+		}
+	}
+	if slug.Len() == 0 {
+		return "section"
+	}
+	return slug.String()
+}
+
+type goldmarkSlugifier struct{}
+
+func (goldmarkSlugifier) Slugify(text string) string {
+	var slug strings.Builder
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			slug.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			slug.WriteRune(unicode.ToLower(r))
+		case r == ' ' || r == '\t' || r == '-' || r == '_':
+			slug.WriteRune('-')
+		}
+	}
+	if slug.Len() == 0 {
+		return "heading"
+	}
+	return slug.String()
+}
+
+type pandocSlugifier struct{}
+
+func (pandocSlugifier) Slugify(text string) string {
+	var slug strings.Builder
+	for _, r := range text {
+		if slug.Len() == 0 && !unicode.IsLetter(r) {
+			continue
+		}
+		switch {
+		case unicode.IsSpace(r):
+			slug.WriteRune('-')
+		case r == '_' || r == '-' || r == '.' || unicode.IsLetter(r) || unicode.IsNumber(r):
+			slug.WriteRune(unicode.ToLower(r))
+		}
+	}
+	if slug.Len() == 0 {
+		return "section"
+	}
+	return slug.String()
+}
+
+type mkdocsSlugifier struct{}
+
+func (mkdocsSlugifier) Slugify(text string) string {
+	var kept strings.Builder
+	for _, r := range text {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || r == '_' || r == '-') {
+			kept.WriteRune(unicode.ToLower(r))
+		}
+	}
+	var slug strings.Builder
+	separator := false
+	for _, r := range strings.TrimSpace(kept.String()) {
+		if r == '-' || unicode.IsSpace(r) {
+			separator = true
+			continue
+		}
+		if separator {
+			slug.WriteRune('-')
+			separator = false
+		}
+		slug.WriteRune(r)
+	}
+	if separator {
+		slug.WriteRune('-')
+	}
+	return slug.String()
+}
@@ -0,0 +1,217 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxGeminiRedirects is the number of redirects followed before a Gemini link is considered broken.
+const maxGeminiRedirects = 5
+
+var errTooManyRedirects = errors.New("too many redirects")
+
+// WithSmallWebChecks enables or disables checking gemini:// and gopher:// links.
+// They are not checked by default.
+func WithSmallWebChecks(enabled bool) Option {
+	return func(w *Website) {
+		var gemini, gopher ExternalChecker
+		if enabled {
+			gemini, gopher = &GeminiChecker{}, &GopherChecker{}
+		}
+		WithSchemeHandler("gemini", gemini)(w)
+		WithSchemeHandler("gopher", gopher)(w)
+	}
+}
+
+// GeminiChecker verifies gemini:// links by requesting them and following redirects.
+// Since Gemini status codes have two digits, the result reports 200 for success, input, and client certificate
+// responses, which all prove the resource exists, and the Gemini status code otherwise (for example 51 for not found).
+type GeminiChecker struct {
+	// TLSConfig configures the TLS connection.
+	// If nil, certificates are not verified, since Gemini servers commonly use self-signed certificates.
+	TLSConfig *tls.Config
+}
+
+// Check requests the URL and interprets the status of the response header.
+func (c *GeminiChecker) Check(ctx context.Context, link string) (result ExternalResult) {
+	result = ExternalResult{URL: link, ContentLength: -1}
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	target := link
+	for i := 0; i <= maxGeminiRedirects; i++ {
+		status, meta, expiry, err := c.request(ctx, target)
+		result.FinalURL = target
+		result.CertExpiry = expiry
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		switch status / 10 {
+		case 1, 2, 6:
+			result.StatusCode = 200
+			if status/10 == 2 {
+				result.ContentType = meta
+			}
+			return result
+		case 3:
+			base, err := url.Parse(target)
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			next, err := base.Parse(meta)
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			target = next.String()
+		default:
+			result.StatusCode = status
+			return result
+		}
+	}
+	result.Err = errTooManyRedirects
+	return result
+}
+
+// request sends a single request and returns the status and meta of the response header.
+func (c *GeminiChecker) request(ctx context.Context, link string) (int, string, time.Time, error) {
+	var expiry time.Time
+	u, err := url.Parse(link)
+	if err != nil {
+		return 0, "", expiry, err
+	}
+	if !strings.EqualFold(u.Scheme, "gemini") {
+		return 0, "", expiry, fmt.Errorf("redirected to unsupported scheme '%s'", u.Scheme)
+	}
+
+	config := &tls.Config{InsecureSkipVerify: true}
+	if c.TLSConfig != nil {
+		config = c.TLSConfig.Clone()
+	}
+	if len(config.ServerName) == 0 {
+		config.ServerName = u.Hostname()
+	}
+
+	conn, err := dialSmallWeb(ctx, u, "1965")
+	if err != nil {
+		return 0, "", expiry, err
+	}
+	defer conn.Close()
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return 0, "", expiry, err
+	}
+	if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+		expiry = certs[0].NotAfter
+	}
+
+	if _, err := io.WriteString(tlsConn, link+"\r\n"); err != nil {
+		return 0, "", expiry, err
+	}
+	// The header is at most 1029 bytes: a two digit status, a space, 1024 bytes of meta, and CRLF.
+	header, err := bufio.NewReader(io.LimitReader(tlsConn, 1029)).ReadString('\n')
+	if err != nil {
+		return 0, "", expiry, err
+	}
+	header = strings.TrimRight(header, "\r\n")
+	if len(header) < 2 {
+		return 0, "", expiry, fmt.Errorf("malformed response header '%s'", header)
+	}
+	status, err := strconv.Atoi(header[:2])
+	if err != nil {
+		return 0, "", expiry, fmt.Errorf("malformed response header '%s'", header)
+	}
+	return status, strings.TrimSpace(header[2:]), expiry, nil
+}
+
+// GopherChecker verifies gopher:// links by requesting their selector.
+// The result reports 200 if the server sends a response and 404 if the response is empty or a gopher error item.
+type GopherChecker struct{}
+
+// Check requests the selector of the URL and inspects the start of the response.
+func (c *GopherChecker) Check(ctx context.Context, link string) (result ExternalResult) {
+	result = ExternalResult{URL: link, FinalURL: link, ContentLength: -1}
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	u, err := url.Parse(link)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// The first character of the path is the item type, which is not sent to the server.
+	selector := u.Path
+	if len(selector) > 1 {
+		selector = selector[2:]
+	} else {
+		selector = ""
+	}
+
+	conn, err := dialSmallWeb(ctx, u, "70")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, selector+"\r\n"); err != nil {
+		result.Err = err
+		return result
+	}
+
+	line, err := bufio.NewReader(io.LimitReader(conn, 4096)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		result.Err = err
+		return result
+	}
+	if len(line) == 0 || (line[0] == '3' && strings.Contains(line, "\t")) {
+		result.StatusCode = 404
+		return result
+	}
+	result.StatusCode = 200
+	return result
+}
+
+// dialSmallWeb connects to the host of the URL, using the default port of the protocol if none is given.
+func dialSmallWeb(ctx context.Context, u *url.URL, defaultPort string) (net.Conn, error) {
+	address := u.Host
+	if len(u.Port()) == 0 {
+		address = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
@@ -0,0 +1,117 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WithSourceMap attributes the findings of generated documents to the source files they were generated from,
+// such as Markdown content or templates, by setting the Source of every LinkError.
+// The map goes from paths relative to the root of the domain, where a path naming a directory also matches its index file,
+// to the names of the source files. Generators can provide the map, as the Hugo preset does, or use WithSourceDir.
+func WithSourceMap(sources map[string]string) Option {
+	return func(w *Website) {
+		if w.sources == nil {
+			w.sources = make(map[string]string)
+		}
+		for page, source := range sources {
+			w.sources[redirectKey(page)] = source
+		}
+	}
+}
+
+// LoadSourceMap reads a source map from the named JSON file holding an object that maps page paths to source files.
+func LoadSourceMap(name string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var sources map[string]string
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// WithSourceDir attributes findings to the source files in the directory by matching their paths with the paths of the documents,
+// for generators that do not provide a source map. The source "docs/setup.md" is matched with the documents
+// "docs/setup.html" and "docs/setup/index.html", and "docs/index.md" or "docs/_index.md" with "docs/index.html".
+// Sources explicitly mapped with WithSourceMap take precedence.
+func WithSourceDir(dir string) Option {
+	return func(w *Website) {
+		w.sourceDirs = append(w.sourceDirs, dir)
+	}
+}
+
+// sourceExtensions are the extensions of the source files matched by WithSourceDir.
+var sourceExtensions = []string{".md", ".markdown", ".mdx", ".rst", ".adoc", ".org", ".html", ".htm", ".tmpl", ".njk", ".liquid", ".hbs"}
+
+// guessSources matches the files in the source directories with the documents, without overriding explicit mappings.
+func (w *Website) guessSources() {
+	for _, dir := range w.sourceDirs {
+		filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(name))
+			known := false
+			for _, extension := range sourceExtensions {
+				known = known || ext == extension
+			}
+			rel, err := filepath.Rel(dir, name)
+			if !known || err != nil {
+				return nil
+			}
+
+			rel = filepath.ToSlash(rel)
+			page := strings.TrimSuffix(rel, path.Ext(rel))
+			candidates := []string{page + ".html", page + "/index.html"}
+			if base := path.Base(page); base == "index" || base == "_index" || base == "README" {
+				candidates = []string{path.Join(path.Dir(page), "index.html")}
+			}
+			for _, candidate := range candidates {
+				key := redirectKey(candidate)
+				if _, exists := w.sources[key]; exists {
+					break
+				}
+				if target := w.lookupPath(w.root, "/"+candidate); target != nil && target.document {
+					if w.sources == nil {
+						w.sources = make(map[string]string)
+					}
+					w.sources[key] = filepath.ToSlash(name)
+					break
+				}
+			}
+			return nil
+		})
+	}
+	w.sourceDirs = nil
+}
+
+// source returns the source file the document was generated from, if known.
+func (w *Website) source(entity *fsEntity) string {
+	if len(w.sources) == 0 {
+		return ""
+	}
+	return w.sources[redirectKey(entity.fullname)]
+}
@@ -0,0 +1,187 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// WithStrictParsing warns about markup the HTML parser silently repairs: unclosed and stray tags, misnested elements,
+// nested links, self-closed non-void elements, and duplicate attributes.
+// The repaired document may differ from what the author intended, such as a link wrapping the wrong content.
+func WithStrictParsing(enabled bool) Option {
+	return func(w *Website) {
+		w.strictParsing = enabled
+	}
+}
+
+// parseIssue is markup the HTML parser repaired.
+type parseIssue struct {
+	line    int
+	message string
+}
+
+// voidElements are the elements that never have content or an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// optionalEndTags are the elements whose end tag may be omitted.
+var optionalEndTags = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true,
+	"option": true, "optgroup": true, "colgroup": true, "caption": true, "thead": true, "tbody": true,
+	"tfoot": true, "tr": true, "td": true, "th": true, "rb": true, "rt": true, "rtc": true, "rp": true,
+}
+
+// strictParse tokenizes the document and reports the markup the HTML parser would repair.
+func strictParse(reader io.Reader) []parseIssue {
+	var issues []parseIssue
+	var open []string
+	foreign := 0
+	line := 1
+	report := func(format string, args ...interface{}) {
+		issues = append(issues, parseIssue{line: line, message: fmt.Sprintf(format, args...)})
+	}
+
+	tokenizer := html.NewTokenizer(reader)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		raw := string(tokenizer.Raw())
+		token := tokenizer.Token()
+		name := token.Data
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			seen := make(map[string]bool)
+			for _, key := range attributeNames(raw) {
+				if seen[key] {
+					report("duplicate attribute '%s' on <%s> (only the first is used)", key, name)
+				}
+				seen[key] = true
+			}
+			if name == "a" {
+				for _, element := range open {
+					if element == "a" {
+						report("<a> is nested inside another <a> (the outer link is closed early)")
+						break
+					}
+				}
+			}
+			if voidElements[name] {
+				break
+			}
+			if tokenType == html.SelfClosingTagToken {
+				if foreign == 0 {
+					report("<%s/> is not a void element (the slash is ignored and the element stays open)", name)
+					open = append(open, name)
+				}
+				break
+			}
+			if name == "svg" || name == "math" {
+				foreign++
+			}
+			open = append(open, name)
+
+		case html.EndTagToken:
+			index := len(open) - 1
+			for index >= 0 && open[index] != name {
+				index--
+			}
+			if index < 0 {
+				if !voidElements[name] && !optionalEndTags[name] {
+					report("stray end tag </%s>", name)
+				}
+				break
+			}
+			for _, element := range open[index+1:] {
+				if !optionalEndTags[element] {
+					report("</%s> closes <%s> before its child <%s>", name, name, element)
+				}
+			}
+			if name == "svg" || name == "math" {
+				foreign--
+			}
+			open = open[:index]
+		}
+		line += strings.Count(raw, "\n")
+	}
+
+	for _, element := range open {
+		if !optionalEndTags[element] {
+			report("<%s> is never closed", element)
+		}
+	}
+	return issues
+}
+
+// attributeNames returns the lowercased attribute names of a raw start tag, including duplicates,
+// which the tokenizer discards.
+func attributeNames(tag string) []string {
+	var names []string
+	tag = strings.TrimRight(strings.TrimPrefix(tag, "<"), ">")
+	i := strings.IndexAny(tag, " \t\n\f\r/")
+	if i < 0 {
+		return nil
+	}
+	tag = tag[i:]
+	for {
+		tag = strings.TrimLeft(tag, " \t\n\f\r/")
+		if len(tag) == 0 {
+			return names
+		}
+		end := strings.IndexAny(tag[1:], " \t\n\f\r/=") + 1
+		if end == 0 {
+			end = len(tag)
+		}
+		names = append(names, strings.ToLower(tag[:end]))
+		tag = strings.TrimLeft(tag[end:], " \t\n\f\r")
+		if !strings.HasPrefix(tag, "=") {
+			continue
+		}
+		tag = strings.TrimLeft(tag[1:], " \t\n\f\r")
+		if len(tag) > 0 && (tag[0] == '"' || tag[0] == '\'') {
+			if end := strings.IndexByte(tag[1:], tag[0]); end >= 0 {
+				tag = tag[end+2:]
+			} else {
+				tag = ""
+			}
+		} else if end := strings.IndexAny(tag, " \t\n\f\r"); end >= 0 {
+			tag = tag[end:]
+		} else {
+			tag = ""
+		}
+	}
+}
+
+// validateParseIssues warns about the markup the HTML parser repaired.
+func validateParseIssues(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, issue := range entity.parseIssues {
+		err := website.finding(entity, "", SeverityWarning, KindDocument, "line %d: %s", issue.line, issue.message)
+		err.Line = issue.line
+		errors = append(errors, website.report(err))
+	}
+	return errors
+}
@@ -0,0 +1,44 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithPageTitles captures the title of every external page, so editors can confirm a link still points at
+// the intended content rather than, say, a parked domain. Links that would be checked with CheckHead
+// are fetched with CheckGet instead; WithCheckLevel rules still take precedence.
+// The titles are reported as the Title of ExternalResults, along with the FinalURL the link led to.
+func WithPageTitles(enabled bool) Option {
+	return func(w *Website) {
+		w.pageTitles = enabled
+	}
+}
+
+// pageTitle returns the text of the title element of an HTML page with its whitespace collapsed.
+func pageTitle(doc *goquery.Document) string {
+	return strings.Join(strings.Fields(doc.Find("title").First().Text()), " ")
+}
+
+// isHTML reports whether the media type of a response is an HTML document.
+func isHTML(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, "text/html") || strings.HasPrefix(contentType, "application/xhtml+xml")
+}
@@ -0,0 +1,94 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// WithTOCCheck warns when the in-page table of contents falls out of sync with the headings of the page.
+// A table of contents is a nav element, or an element with the toc or table-of-contents class or the toc or
+// TableOfContents id, holding same page links.
+// Headings with an id whose level is within the range of levels listed in the table of contents must be listed,
+// and entries must link to headings. Entries linking to ids that do not exist are reported as broken same page links.
+// Tables of contents are not checked by default.
+func WithTOCCheck(enabled bool) Option {
+	return func(w *Website) {
+		w.tocCheck = enabled
+	}
+}
+
+// isTOC reports whether the element holds a table of contents.
+func isTOC(s *goquery.Selection) bool {
+	if strings.EqualFold(goquery.NodeName(s), "nav") {
+		return true
+	}
+	switch s.AttrOr("id", "") {
+	case "toc", "TableOfContents":
+		return true
+	}
+	for _, class := range strings.Fields(s.AttrOr("class", "")) {
+		if class == "toc" || class == "table-of-contents" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTOC warns about headings missing from the table of contents and entries that do not link to headings.
+func validateTOC(website *Website, entity *fsEntity) []error {
+	if !website.tocCheck || len(entity.toc) == 0 {
+		return nil
+	}
+
+	headings := make(map[string]heading)
+	for _, h := range entity.headings {
+		if len(h.id) > 0 {
+			headings[h.id] = h
+		}
+	}
+
+	var errors []error
+	listed := make(map[string]bool)
+	minLevel, maxLevel := 7, 0
+	for _, entry := range entity.toc {
+		id := strings.TrimPrefix(entry, "#")
+		listed[id] = true
+		h, isHeading := headings[id]
+		if !isHeading {
+			if _, exists := entity.ids[id]; exists {
+				errors = append(errors, website.newFinding(entity, entry, SeverityWarning, KindAnchor, "table of contents entry '%s' does not link to a heading", entry))
+			}
+			continue
+		}
+		if h.level < minLevel {
+			minLevel = h.level
+		}
+		if h.level > maxLevel {
+			maxLevel = h.level
+		}
+	}
+
+	for _, h := range entity.headings {
+		if len(h.id) > 0 && !listed[h.id] && h.level >= minLevel && h.level <= maxLevel {
+			errors = append(errors, website.newFinding(entity, "", SeverityWarning, KindDocument, "heading '%s' is missing from the table of contents", h.text))
+		}
+	}
+	return errors
+}
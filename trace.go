@@ -0,0 +1,53 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+// Tracer creates spans describing where time is spent while validating a website.
+// It is a minimal hook that can be bridged to OpenTelemetry or any other tracing system.
+//
+// The following spans are created:
+//
+//	linkup.parse     parsing a single HTML document
+//	linkup.validate  validating the whole website
+//	linkup.resolve   resolving the links of a single document
+//	linkup.ping      checking a single external link
+type Tracer interface {
+	// StartSpan begins a new span with the given name and attributes.
+	StartSpan(name string, attributes map[string]string) Span
+}
+
+// Span is a single unit of traced work.
+type Span interface {
+	// SetAttribute records an attribute on the span.
+	SetAttribute(key, value string)
+
+	// End completes the span.
+	// The error is nil if the work succeeded.
+	End(err error)
+}
+
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(name string, attributes map[string]string) Span {
+	return noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key, value string) {}
+
+func (noopSpan) End(err error) {}
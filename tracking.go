@@ -0,0 +1,94 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultTrackingParams lists the query parameters commonly used for campaign tracking and session identifiers.
+var DefaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_eid",
+	"jsessionid",
+	"phpsessid",
+	"aspsessionid*",
+	"sessionid",
+	"sid",
+}
+
+// WithTrackingParams reports external links carrying any of the given query parameters as warnings.
+// Parameter names are matched case-insensitively and a trailing asterisk matches any parameter with the given prefix.
+// Session ids embedded in the path, as in ";jsessionid=...", are reported too.
+// Pass DefaultTrackingParams to report the common tracking and session parameters.
+// Links are not checked for tracking parameters by default.
+func WithTrackingParams(params []string) Option {
+	return func(w *Website) {
+		w.trackingParams = nil
+		for _, param := range params {
+			w.trackingParams = append(w.trackingParams, strings.ToLower(param))
+		}
+	}
+}
+
+// checkTracking returns a warning if the external link carries tracking parameters.
+func checkTracking(website *Website, entity *fsEntity, raw, href string) *LinkError {
+	if len(website.trackingParams) == 0 {
+		return nil
+	}
+	found := trackingParams(website.trackingParams, href)
+	if len(found) == 0 {
+		return nil
+	}
+	return website.newFinding(entity, raw, SeverityWarning, KindPolicy, "external link '%s' carries tracking parameters (%s)", href, strings.Join(found, ", "))
+}
+
+// trackingParams returns the sorted names of the parameters in the link that match the patterns.
+func trackingParams(patterns []string, link string) []string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+
+	var found []string
+	for name := range u.Query() {
+		if matchParam(patterns, strings.ToLower(name)) {
+			found = append(found, name)
+		}
+	}
+
+	// Servlet containers and some PHP setups append the session id as a path parameter.
+	segments := strings.Split(u.Path, ";")
+	for _, segment := range segments[1:] {
+		name := segment
+		if i := strings.Index(segment, "="); i >= 0 {
+			name = segment[:i]
+		}
+		if matchParam(patterns, strings.ToLower(name)) {
+			found = append(found, name)
+		}
+	}
+
+	sort.Strings(found)
+	return found
+}
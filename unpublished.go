@@ -0,0 +1,47 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+// WithUnpublished marks the registered files matching the glob patterns as unpublished,
+// such as drafts or future-dated posts, which will not exist in production.
+// Patterns are matched against names relative to the root of the domain, as in "drafts/*" or "blog/2031-*",
+// where "*" matches any run of characters, including slashes, and "?" matches a single character.
+// Links from published pages to unpublished files are reported as broken,
+// while unpublished pages may link to each other freely.
+func WithUnpublished(patterns ...string) Option {
+	return func(w *Website) {
+		for _, pattern := range patterns {
+			w.unpublished = append(w.unpublished, prepareFileName(pattern))
+		}
+	}
+}
+
+// isUnpublished reports whether the file, or a directory holding it, is marked as unpublished.
+func (w *Website) isUnpublished(entity *fsEntity) bool {
+	if len(w.unpublished) == 0 {
+		return false
+	}
+	for current := entity; current != nil && current.parent != nil; current = current.parent {
+		name := calcFullName(current)
+		for _, pattern := range w.unpublished {
+			if globMatch(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultCitationSelector selects links inside cite elements and inside elements with a citation or reference class,
+// as used by wikis and research blogs.
+const DefaultCitationSelector = "cite a[href], .citation a[href], .reference a[href], .references a[href]"
+
+// archivePattern matches links to copies kept by web archives.
+var archivePattern = regexp.MustCompile(`^https?://(?:web\.archive\.org|archive\.org/web|archive\.(?:today|ph|is|li|md|vn|fo)|webcitation\.org|perma\.cc)/`)
+
+// archiveAttributes are the attributes commonly used to attach an archived copy to a link.
+var archiveAttributes = []string{"data-archive", "data-archive-url", "data-archived-url", "data-archived"}
+
+// WithArchivedCitations warns about external links matched by the CSS selector, typically citations,
+// that lack an accompanying archived copy on archive.org, archive.today, or a similar service.
+// A citation is archived if its element has a data-archive, data-archive-url, data-archived-url, or data-archived
+// attribute with an archive link, or if another link within its parent element points to an archive.
+// Pass DefaultCitationSelector to check the common citation markup. Citations are not checked by default.
+func WithArchivedCitations(selector string) Option {
+	return func(w *Website) {
+		w.citationSelector = selector
+	}
+}
+
+// findUnarchivedCitations returns the external citation links of the document without an archived copy.
+func findUnarchivedCitations(doc *goquery.Document, selector string) []string {
+	var unarchived []string
+	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if !strings.HasPrefix(href, "http") || isArchiveLink(href) {
+			return
+		}
+		for _, attr := range archiveAttributes {
+			if isArchiveLink(strings.TrimSpace(s.AttrOr(attr, ""))) {
+				return
+			}
+		}
+		archived := false
+		s.Parent().Find("a[href]").EachWithBreak(func(i int, sibling *goquery.Selection) bool {
+			archived = isArchiveLink(strings.TrimSpace(sibling.AttrOr("href", "")))
+			return !archived
+		})
+		if !archived {
+			unarchived = append(unarchived, href)
+		}
+	})
+	return unarchived
+}
+
+// isArchiveLink reports whether the link points to a copy kept by a web archive.
+func isArchiveLink(href string) bool {
+	return archivePattern.MatchString(strings.ToLower(href))
+}
+
+// validateCitations warns about the citations of the document that lack an archived copy.
+func validateCitations(website *Website, entity *fsEntity) []error {
+	var errors []error
+	for _, href := range entity.unarchived {
+		errors = append(errors, website.newFinding(entity, href, SeverityWarning, KindPolicy, "citation '%s' has no archived copy", href))
+	}
+	return errors
+}
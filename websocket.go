@@ -0,0 +1,180 @@
+// LinkUp - A tool for catching broken website links.
+// Copyright (C) 2020-2021 Henry G. Stratmann III
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package linkup
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// websocketGUID is appended to the handshake key to compute the accept header (see RFC 6455).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var errBadHandshake = errors.New("websocket handshake failed")
+
+// WebSocketChecker verifies ws:// and wss:// links.
+// By default it completes the opening handshake, but it can be limited to the underlying TCP or TLS connect
+// for servers that reject clients without application-specific headers.
+//
+// The result reports 200 when the endpoint is alive; if the server answers the handshake with anything
+// other than 101 Switching Protocols, its status code is reported instead.
+type WebSocketChecker struct {
+	// ConnectOnly skips the handshake and only verifies a connection can be established.
+	ConnectOnly bool
+
+	// Origin is sent as the Origin header of the handshake, since many servers reject cross-origin clients.
+	Origin string
+
+	// TLSConfig configures the TLS connection for wss links.
+	// If nil, the default configuration is used.
+	TLSConfig *tls.Config
+}
+
+// WithWebSocketChecker verifies ws:// and wss:// links with the checker.
+// WebSocket links are not checked by default.
+func WithWebSocketChecker(checker ExternalChecker) Option {
+	return func(w *Website) {
+		WithSchemeHandler("ws", checker)(w)
+		WithSchemeHandler("wss", checker)(w)
+	}
+}
+
+// Check connects to the endpoint and, unless ConnectOnly is set, completes the opening handshake.
+func (c *WebSocketChecker) Check(ctx context.Context, link string) (result ExternalResult) {
+	result = ExternalResult{URL: link, FinalURL: link, ContentLength: -1}
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	u, err := url.Parse(link)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	conn, err := c.dial(ctx, u)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	if state, ok := conn.(*tls.Conn); ok {
+		if certs := state.ConnectionState().PeerCertificates; len(certs) > 0 {
+			result.CertExpiry = certs[0].NotAfter
+		}
+	}
+
+	if c.ConnectOnly {
+		result.StatusCode = 200
+		return result
+	}
+
+	result.StatusCode, result.Err = c.handshake(conn, u)
+	return result
+}
+
+// dial connects to the endpoint, negotiating TLS for wss links.
+func (c *WebSocketChecker) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	secure := strings.EqualFold(u.Scheme, "wss")
+	address := u.Host
+	if len(u.Port()) == 0 {
+		port := "80"
+		if secure {
+			port = "443"
+		}
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if !secure {
+		return conn, nil
+	}
+
+	config := &tls.Config{}
+	if c.TLSConfig != nil {
+		config = c.TLSConfig.Clone()
+	}
+	if len(config.ServerName) == 0 {
+		config.ServerName = u.Hostname()
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// handshake sends the opening handshake and verifies the server accepted it.
+func (c *WebSocketChecker) handshake(conn net.Conn, u *url.URL) (int, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return 0, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	target := *u
+	target.Scheme = "http"
+	if strings.EqualFold(u.Scheme, "wss") {
+		target.Scheme = "https"
+	}
+	req, err := http.NewRequest("GET", target.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(c.Origin) > 0 {
+		req.Header.Set("Origin", c.Origin)
+	}
+	if err := req.Write(conn); err != nil {
+		return 0, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return resp.StatusCode, nil
+	}
+
+	digest := sha1.Sum([]byte(key + websocketGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(digest[:]) {
+		return resp.StatusCode, errBadHandshake
+	}
+	return 200, nil
+}